@@ -0,0 +1,175 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/storage"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+const migrateStorageBatchSize = 50
+
+func init() {
+	rootCmd.AddCommand(migrateStorageCmd)
+
+	migrateStorageCmd.PersistentFlags().String("database-url", "", "database URL")
+	migrateStorageCmd.MarkPersistentFlagRequired("database-url")
+
+	migrateStorageCmd.PersistentFlags().String("storage-url", "", "object storage URL")
+	migrateStorageCmd.MarkPersistentFlagRequired("storage-url")
+
+	migrateStorageCmd.PersistentFlags().String("storage-key-prefix", "", "storage key prefix")
+}
+
+type storageMigrator struct {
+	database         db.DB
+	storage          *storage.Storage
+	storageKeyPrefix string
+}
+
+// migrateDeployment moves files of legacy deployment to content-addressed
+// blobs.
+func (m *storageMigrator) migrateDeployment(ctx context.Context, deployment *models.Deployment) error {
+	now := time.Now().UTC()
+
+	for _, entry := range deployment.Metadata.Files {
+		if entry.Hash == "" {
+			continue
+		}
+
+		exists, err := m.database.UseBlob(ctx, deployment.AppID, entry.Hash, now)
+		if err != nil {
+			return err
+		} else if exists {
+			continue
+		}
+
+		key := deployment.StorageKeyPrefix + entry.Path
+		if err := m.verifyObject(ctx, key, entry.Hash); err != nil {
+			return fmt.Errorf("%s: %w", entry.Path, err)
+		}
+
+		blob := models.NewBlob(now, deployment.AppID, entry.Hash, entry.Size, m.storageKeyPrefix)
+		if err := m.storage.Copy(ctx, blob.StorageKey, key); err != nil {
+			return fmt.Errorf("%s: %w", entry.Path, err)
+		}
+		if err := m.database.CreateBlob(ctx, blob); err != nil {
+			return err
+		}
+	}
+
+	err := db.WithTx(ctx, m.database, func(tx db.Tx) error {
+		if err := tx.AddDeploymentBlobs(ctx, deployment, deployment.BlobHashes()); err != nil {
+			return err
+		}
+
+		blobKeyPrefix := models.BlobKeyPrefix(m.storageKeyPrefix, deployment.AppID)
+		deployment.BlobKeyPrefix = &blobKeyPrefix
+		deployment.UpdatedAt = now
+		return tx.SetDeploymentBlobKeyPrefix(ctx, deployment)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Legacy objects are no longer used.
+	for _, entry := range deployment.Metadata.Files {
+		key := deployment.StorageKeyPrefix + entry.Path
+		if err := m.storage.Delete(ctx, key); err != nil {
+			logger.Warn("failed to delete legacy object", zap.String("key", key), zap.Error(err))
+		}
+	}
+
+	return nil
+}
+
+func (m *storageMigrator) verifyObject(ctx context.Context, key string, expectedHash string) error {
+	reader, err := m.storage.OpenRead(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	hash := deploy.NewFileHash()
+	if _, err := io.Copy(hash, reader); err != nil {
+		return err
+	}
+	if hash.Sum() != expectedHash {
+		return deploy.ErrUnexpectedFileHash
+	}
+	return nil
+}
+
+func (m *storageMigrator) run(ctx context.Context) error {
+	var migrated, failed int
+	afterID := ""
+	for {
+		deployments, err := m.database.ListDeploymentsWithoutBlobs(ctx, afterID, migrateStorageBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range deployments {
+			afterID = d.ID
+			if err := m.migrateDeployment(ctx, d); err != nil {
+				logger.Error("failed to migrate deployment",
+					zap.String("app", d.AppID),
+					zap.String("deployment", d.ID),
+					zap.Error(err),
+				)
+				failed++
+				continue
+			}
+			logger.Info("migrated deployment",
+				zap.String("app", d.AppID),
+				zap.String("deployment", d.ID),
+			)
+			migrated++
+		}
+
+		if len(deployments) < migrateStorageBatchSize {
+			break
+		}
+	}
+
+	logger.Info("migrated deployments", zap.Int("migrated", migrated), zap.Int("failed", failed))
+	return nil
+}
+
+var migrateStorageCmd = &cobra.Command{
+	Use:   "migrate-storage",
+	Short: "Migrate files of existing deployments to content-addressed storage",
+	Run: func(cmd *cobra.Command, args []string) {
+		database, err := db.New(viper.GetString("database-url"))
+		if err != nil {
+			logger.Fatal("failed to setup database", zap.Error(err))
+			return
+		}
+
+		storage, err := storage.New(cmd.Context(), viper.GetString("storage-url"))
+		if err != nil {
+			logger.Fatal("failed to setup object storage", zap.Error(err))
+			return
+		}
+
+		migrator := &storageMigrator{
+			database:         database,
+			storage:          storage,
+			storageKeyPrefix: viper.GetString("storage-key-prefix"),
+		}
+		if err := migrator.run(cmd.Context()); err != nil {
+			logger.Fatal("failed to migrate storage", zap.Error(err))
+			return
+		}
+
+		logger.Info("done")
+	},
+}
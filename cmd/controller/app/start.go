@@ -1,8 +1,10 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/carlmjohnson/versioninfo"
 	"github.com/dustin/go-humanize"
+	"github.com/oursky/pageship/internal/cache"
 	"github.com/oursky/pageship/internal/command"
 	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/cron"
@@ -21,12 +24,15 @@ import (
 	"github.com/oursky/pageship/internal/handler/site"
 	"github.com/oursky/pageship/internal/handler/site/middleware"
 	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/metrics"
 	sitedb "github.com/oursky/pageship/internal/site/db"
 	"github.com/oursky/pageship/internal/storage"
+	"github.com/oursky/pageship/internal/tracing"
 	"github.com/oursky/pageship/internal/watch"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
 )
 
 var errUnknownDomain = errors.New("unknown domain")
@@ -44,6 +50,8 @@ func init() {
 
 	startCmd.PersistentFlags().Bool("migrate", false, "migrate before starting")
 	startCmd.PersistentFlags().String("addr", ":8001", "listen address")
+	startCmd.PersistentFlags().String("metrics-addr", "", "metrics admin listen address")
+	startCmd.PersistentFlags().Bool("tracing", false, "export traces through OTLP")
 
 	startCmd.PersistentFlags().Bool("tls", false, "use TLS")
 	startCmd.PersistentFlags().String("tls-addr", ":443", "TLS listen address")
@@ -57,14 +65,26 @@ func init() {
 	startCmd.PersistentFlags().String("host-id-scheme", string(config.HostIDSchemeDefault), "host ID scheme")
 	startCmd.PersistentFlags().StringSlice("reserved-apps", []string{defaultControllerHostID}, "reserved app IDs")
 	startCmd.PersistentFlags().String("api-acl", "", "API ACL file")
+	startCmd.PersistentFlags().String("oidc-issuers", "", "trusted OIDC issuers file")
+	startCmd.PersistentFlags().String("ssh-user-ca", "", "trusted SSH user CA public keys file")
 
 	startCmd.PersistentFlags().String("token-authority", "pageship", "auth token authority")
 	startCmd.PersistentFlags().String("token-signing-key", "", "auth token signing key")
 
+	startCmd.PersistentFlags().String("sso-session-key", "", "site visitor SSO session signing key")
+	startCmd.PersistentFlags().String("sso-issuers", "", "trusted site visitor SSO issuers file")
+	startCmd.PersistentFlags().Bool("site-analytics", true, "collect site traffic analytics")
+	startCmd.PersistentFlags().String("blob-cache-size", "64M", "max size of site files cached in memory")
+	startCmd.PersistentFlags().String("blob-cache-max-object-size", "1M", "max size of single cached site file")
+	startCmd.PersistentFlags().String("blob-cache-dir", "", "directory of on-disk site files cache")
+	startCmd.PersistentFlags().String("blob-cache-disk-size", "1G", "max size of site files cached on disk")
+
 	startCmd.PersistentFlags().String("custom-domain-message", "", "message for custom domain users")
 
 	startCmd.PersistentFlags().String("cleanup-expired-crontab", "", "cleanup expired schedule")
 	startCmd.PersistentFlags().Duration("keep-after-expired", time.Hour*24, "keep-after-expired")
+	startCmd.PersistentFlags().Duration("site-analytics-retention", time.Hour*24*90, "retention of site analytics; 0 to keep forever")
+	startCmd.PersistentFlags().String("cleanup-storage-crontab", "", "cleanup storage of deleted deployments schedule")
 	startCmd.PersistentFlags().String("verify-domain-ownership-crontab", "", "verify domain ownership schedule")
 	startCmd.PersistentFlags().Bool("domain-verification-enabled", false, "enable/disable domain verification")
 	startCmd.PersistentFlags().Duration("domain-verification-interval", time.Hour, "duration before next domain verification start for a verified domain")
@@ -79,6 +99,8 @@ type StartConfig struct {
 	DatabaseURL string `mapstructure:"database-url" validate:"url"`
 	StorageURL  string `mapstructure:"storage-url" validate:"url"`
 	Addr        string `mapstructure:"addr" validate:"hostname_port"`
+	MetricsAddr string `mapstructure:"metrics-addr" validate:"omitempty,hostname_port"`
+	Tracing     bool   `mapstructure:"tracing"`
 
 	TLS             bool   `mapstructure:"tls"`
 	TLSAddr         string `mapstructure:"tls-addr" validate:"hostname_port"`
@@ -97,8 +119,16 @@ type StartConfig struct {
 }
 
 type StartSitesConfig struct {
-	HostPattern  string              `mapstructure:"host-pattern"`
-	HostIDScheme config.HostIDScheme `mapstructure:"host-id-scheme" validate:"hostidscheme"`
+	HostPattern    string              `mapstructure:"host-pattern"`
+	HostIDScheme   config.HostIDScheme `mapstructure:"host-id-scheme" validate:"hostidscheme"`
+	SSOSessionKey  string              `mapstructure:"sso-session-key"`
+	SSOIssuersFile string              `mapstructure:"sso-issuers" validate:"omitempty,filepath"`
+	SiteAnalytics  bool                `mapstructure:"site-analytics"`
+
+	BlobCacheSize          string `mapstructure:"blob-cache-size" validate:"size"`
+	BlobCacheMaxObjectSize string `mapstructure:"blob-cache-max-object-size" validate:"size"`
+	BlobCacheDir           string `mapstructure:"blob-cache-dir"`
+	BlobCacheDiskSize      string `mapstructure:"blob-cache-disk-size" validate:"size"`
 }
 
 type StartControllerConfig struct {
@@ -108,6 +138,8 @@ type StartControllerConfig struct {
 	TokenAuthority    string   `mapstructure:"token-authority"`
 	ReservedApps      []string `mapstructure:"reserved-apps"`
 	APIACLFile        string   `mapstructure:"api-acl" validate:"omitempty,filepath"`
+	OIDCIssuersFile   string   `mapstructure:"oidc-issuers" validate:"omitempty,filepath"`
+	SSHUserCAFile     string   `mapstructure:"ssh-user-ca" validate:"omitempty,filepath"`
 
 	CustomDomainMessage       string `mapstructure:"custom-domain-message"`
 	DomainVerificationEnabled bool   `mapstructure:"domain-verification-enabled" validate:"omitempty"`
@@ -116,6 +148,8 @@ type StartControllerConfig struct {
 type StartCronConfig struct {
 	CleanupExpiredCrontab        string        `mapstructure:"cleanup-expired-crontab" validate:"omitempty,cron"`
 	KeepAfterExpired             time.Duration `mapstructure:"keep-after-expired" validate:"min=0"`
+	SiteAnalyticsRetention       time.Duration `mapstructure:"site-analytics-retention" validate:"min=0"`
+	CleanupStorageCrontab        string        `mapstructure:"cleanup-storage-crontab" validate:"omitempty,cron"`
 	VerifyDomainOwnershipCrontab string        `mapstructure:"verify-domain-ownership-crontab" validate:"omitempty,cron"`
 	DomainVerificationEnabled    bool          `mapstructure:"domain-verification-enabled" validate:"omitempty"`
 	DomainVerificationInterval   time.Duration `mapstructure:"domain-verification-interval" validate:"min=1"`
@@ -142,24 +176,76 @@ func (s *setup) checkDomain(name string) error {
 }
 
 func (s *setup) sites(conf StartSitesConfig) error {
+	ssoSessionKey := conf.SSOSessionKey
+	if ssoSessionKey == "" {
+		logger.Warn("SSO session key not specified; a temporary generated key would be used.")
+		ssoSessionKey = generateSecret()
+	}
+
+	var ssoIssuers []config.SSOIssuerConfig
+	if conf.SSOIssuersFile != "" {
+		issuers, err := loadSSOIssuers(conf.SSOIssuersFile)
+		if err != nil {
+			return fmt.Errorf("load SSO issuers: %w", err)
+		}
+		logger.Info("loaded SSO issuers", zap.Int("count", len(issuers)))
+		ssoIssuers = issuers
+	}
+
 	domainResolver := &domaindb.Resolver{
 		HostIDScheme: conf.HostIDScheme,
 		DB:           s.database,
 	}
+	blobCacheSize, _ := humanize.ParseBytes(conf.BlobCacheSize)
+	blobCacheMaxObjectSize, _ := humanize.ParseBytes(conf.BlobCacheMaxObjectSize)
+	blobCacheDiskSize, _ := humanize.ParseBytes(conf.BlobCacheDiskSize)
+	if conf.BlobCacheDir == "" {
+		blobCacheDiskSize = 0
+	}
+
+	// Read site files from storage directly if cache is disabled.
+	var blobCache *cache.BlobCache
+	if blobCacheSize > 0 || blobCacheDiskSize > 0 {
+		c, err := cache.NewBlobCache(cache.BlobCacheConfig{
+			MemorySize:    int64(blobCacheSize),
+			DiskDir:       conf.BlobCacheDir,
+			DiskSize:      int64(blobCacheDiskSize),
+			MaxObjectSize: int64(blobCacheMaxObjectSize),
+		})
+		if err != nil {
+			return err
+		}
+		blobCache = c
+	}
+
 	siteResolver := &sitedb.Resolver{
 		HostIDScheme: conf.HostIDScheme,
 		DB:           s.database,
 		Storage:      s.storage,
+		BlobCache:    blobCache,
+	}
+	handlerConf := site.HandlerConfig{
+		HostPattern: conf.HostPattern,
+		Middlewares: middleware.Default,
+		SSO: site.SSOConfig{
+			SessionKey:         []byte(ssoSessionKey),
+			Issuers:            ssoIssuers,
+			ResolveCredentials: siteResolver.ResolveCredentials,
+		},
 	}
+
+	if conf.SiteAnalytics {
+		analytics := sitedb.NewAnalytics(logger.Named("analytics"), s.database)
+		handlerConf.Analytics = analytics
+		s.works = append(s.works, analytics.Run)
+	}
+
 	handler, err := site.NewHandler(
 		s.ctx,
 		logger.Named("site"),
 		domainResolver,
 		siteResolver,
-		site.HandlerConfig{
-			HostPattern: conf.HostPattern,
-			Middlewares: middleware.Default,
-		},
+		handlerConf,
 	)
 	if err != nil {
 		return err
@@ -196,6 +282,24 @@ func (s *setup) controller(domain string, conf StartControllerConfig, sitesConf
 		DomainVerificationEnabled: conf.DomainVerificationEnabled,
 	}
 
+	if conf.OIDCIssuersFile != "" {
+		issuers, err := loadOIDCIssuers(conf.OIDCIssuersFile)
+		if err != nil {
+			return fmt.Errorf("load OIDC issuers: %w", err)
+		}
+		logger.Info("loaded OIDC issuers", zap.Int("count", len(issuers)))
+		controllerConf.OIDCIssuers = issuers
+	}
+
+	if conf.SSHUserCAFile != "" {
+		keys, err := loadSSHUserCAKeys(conf.SSHUserCAFile)
+		if err != nil {
+			return fmt.Errorf("load SSH user CA: %w", err)
+		}
+		logger.Info("loaded SSH user CA keys", zap.Int("count", len(keys)))
+		controllerConf.SSHUserCAKeys = keys
+	}
+
 	if conf.APIACLFile != "" {
 		aclLog := logger.Named("api-acl")
 		acl, err := watch.NewFile(
@@ -253,12 +357,88 @@ func (s *setup) controller(domain string, conf StartControllerConfig, sitesConf
 	return nil
 }
 
+func loadOIDCIssuers(path string) ([]config.OIDCIssuerConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return config.LoadOIDCIssuers(f)
+}
+
+func loadSSOIssuers(path string) ([]config.SSOIssuerConfig, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return config.LoadSSOIssuers(f)
+}
+
+func loadSSHUserCAKeys(path string) ([]ssh.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []ssh.PublicKey
+	for len(bytes.TrimSpace(data)) > 0 {
+		key, _, _, rest, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+		data = rest
+	}
+	return keys, nil
+}
+
+func (s *setup) metrics(addr string) {
+	mux := new(http.ServeMux)
+	mux.Handle("/metrics", metrics.Handler())
+
+	server := &httputil.Server{
+		Logger:  logger.Named("metrics"),
+		Addr:    addr,
+		Handler: mux,
+	}
+	s.works = append(s.works, server.Run)
+
+	logger.Info("setup metrics", zap.String("addr", addr))
+}
+
+func (s *setup) tracing() error {
+	shutdown, err := tracing.Setup(s.ctx, "pageship")
+	if err != nil {
+		return err
+	}
+
+	s.works = append(s.works, func(ctx context.Context) error {
+		<-ctx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		return shutdown(ctx)
+	})
+
+	logger.Info("setup tracing")
+	return nil
+}
+
 func (s *setup) cron(conf StartCronConfig) error {
 	cronjobs := []command.CronJob{
 		&cron.CleanupExpired{
-			Schedule:         conf.CleanupExpiredCrontab,
-			KeepAfterExpired: conf.KeepAfterExpired,
-			DB:               s.database,
+			Schedule:               conf.CleanupExpiredCrontab,
+			KeepAfterExpired:       conf.KeepAfterExpired,
+			SiteAnalyticsRetention: conf.SiteAnalyticsRetention,
+			DB:                     s.database,
+			Storage:                s.storage,
+		},
+		&cron.CleanupStorage{
+			Schedule: conf.CleanupStorageCrontab,
+			DB:       s.database,
+			Storage:  s.storage,
 		},
 	}
 	if conf.DomainVerificationEnabled {
@@ -380,6 +560,17 @@ var startCmd = &cobra.Command{
 			}
 		}
 
+		if cmdArgs.Tracing {
+			if err := setup.tracing(); err != nil {
+				logger.Fatal("failed to setup tracing", zap.Error(err))
+				return
+			}
+		}
+
+		if cmdArgs.MetricsAddr != "" {
+			setup.metrics(cmdArgs.MetricsAddr)
+		}
+
 		command.Run(setup.works)
 	},
 }
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sort"
+	"strings"
 	"text/tabwriter"
+	"time"
 
 	"github.com/oursky/pageship/internal/api"
 	"github.com/pelletier/go-toml/v2"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func init() {
@@ -17,6 +21,11 @@ func init() {
 	appsCmd.AddCommand(appsCreateCmd)
 	appsCmd.AddCommand(appsShowCmd)
 	appsCmd.AddCommand(appsConfigureCmd)
+	appsCmd.AddCommand(appsAuditCmd)
+	appsCmd.AddCommand(appsDeleteCmd)
+	appsDeleteCmd.PersistentFlags().BoolP("yes", "y", false, "skip confirmation")
+	appsAuditCmd.PersistentFlags().Int("limit", 50, "number of entries to show")
+	appsAuditCmd.PersistentFlags().String("before", "", "show entries before this entry ID")
 }
 
 var appsCmd = &cobra.Command{
@@ -161,3 +170,105 @@ var appsConfigureCmd = &cobra.Command{
 		return nil
 	},
 }
+
+var appsAuditCmd = &cobra.Command{
+	Use:   "audit [app-id] [--limit number of entries] [--before entry ID]",
+	Short: "Show audit log of app",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appID := ""
+		if len(args) > 0 {
+			appID = args[0]
+		}
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			return err
+		}
+		before, err := cmd.Flags().GetString("before")
+		if err != nil {
+			return err
+		}
+
+		logs, err := API().ListAuditLogs(cmd.Context(), appID, before, limit)
+		if err != nil {
+			return fmt.Errorf("failed to get audit log: %w", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+		fmt.Fprintln(w, "ID\tTIME\tACTION\tTARGET\tSUBJECT\tRULE\tSOURCE IP\tDETAILS")
+		for _, l := range logs {
+			keys := make([]string, 0, len(l.Details))
+			for k := range l.Details {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			details := make([]string, len(keys))
+			for i, k := range keys {
+				details[i] = fmt.Sprintf("%s=%s", k, l.Details[k])
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+				l.ID,
+				l.CreatedAt.Local().Format(time.DateTime),
+				l.Action,
+				l.Target,
+				l.Subject,
+				l.CredentialRule,
+				l.SourceIP,
+				strings.Join(details, " "),
+			)
+		}
+		w.Flush()
+
+		if limit > 0 && len(logs) == limit {
+			Info("To show more entries, use --before %s", logs[len(logs)-1].ID)
+		}
+		return nil
+	},
+}
+
+var appsDeleteCmd = &cobra.Command{
+	Use:   "delete [app-id] [--yes]",
+	Short: "Delete app",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appID := ""
+		if len(args) > 0 {
+			appID = args[0]
+		}
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		if !viper.GetBool("yes") {
+			Warn("All sites, deployments and domains of app %q would be deleted.", appID)
+			_, err := Prompt("Enter app ID to confirm", func(value string) error {
+				if value != appID {
+					return fmt.Errorf("app ID does not match")
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		err := API().DeleteApp(cmd.Context(), appID)
+		if err != nil {
+			return fmt.Errorf("failed to delete app: %w", err)
+		}
+
+		Info("App %q deleted.", appID)
+		return nil
+	},
+}
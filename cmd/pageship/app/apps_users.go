@@ -0,0 +1,123 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	appsCmd.AddCommand(appsUsersCmd)
+	appsUsersCmd.PersistentFlags().String("app", "", "app ID")
+
+	appsUsersCmd.AddCommand(appsUsersAddCmd)
+	appsUsersCmd.AddCommand(appsUsersRemoveCmd)
+	appsUsersAddCmd.PersistentFlags().String("access", string(config.AccessLevelDefault), "access level (reader, deployer, admin)")
+	appsUsersRemoveCmd.PersistentFlags().BoolP("yes", "y", false, "skip confirmation")
+}
+
+var appsUsersCmd = &cobra.Command{
+	Use:   "users",
+	Short: "Manage app team members",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		users, err := API().ListUsers(cmd.Context(), appID)
+		if err != nil {
+			return fmt.Errorf("failed to list users: %w", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tACCESS\tCREDENTIALS")
+		for _, user := range users {
+			name := "-"
+			if user.Name != "" {
+				name = user.Name
+			}
+
+			var creds []string
+			for _, id := range user.Credentials {
+				creds = append(creds, string(id))
+			}
+			credentials := "-"
+			if len(creds) > 0 {
+				credentials = strings.Join(creds, ", ")
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", user.ID, name, user.Access, credentials)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+var appsUsersAddCmd = &cobra.Command{
+	Use:   "add <user-id> [--access level]",
+	Short: "Add user to app team, or update access level of team member",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userID := args[0]
+		access := config.AccessLevel(viper.GetString("access"))
+		if !access.IsValid() {
+			return fmt.Errorf("invalid access level: %s", access)
+		}
+
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		err := API().AddUser(cmd.Context(), appID, userID, access)
+		if err != nil {
+			return fmt.Errorf("failed to add user: %w", err)
+		}
+
+		Info("User %q added to app %q with %s access.", userID, appID, access)
+		return nil
+	},
+}
+
+var appsUsersRemoveCmd = &cobra.Command{
+	Use:   "remove <user-id> [--yes]",
+	Short: "Remove user from app team",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		userID := args[0]
+
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		if !viper.GetBool("yes") {
+			if err := Confirm(fmt.Sprintf("Remove user %q from app %q", userID, appID)); err != nil {
+				return err
+			}
+		}
+
+		err := API().DeleteUser(cmd.Context(), appID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to remove user: %w", err)
+		}
+
+		Info("User %q removed from app %q.", userID, appID)
+		return nil
+	},
+}
@@ -11,13 +11,20 @@ import (
 	"github.com/oursky/pageship/internal/api"
 	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/models"
+	"github.com/spf13/viper"
 )
 
-const reauthThreshold time.Duration = time.Minute * 10
+// reauthThreshold must be shorter than lifetime of tokens issued by server (5
+// minutes), otherwise tokens are always refreshed.
+const reauthThreshold time.Duration = time.Minute * 1
 
 var initialCheck atomic.Bool
 
 func ensureAuth(ctx context.Context) (string, error) {
+	if token := viper.GetString("token"); token != "" {
+		return token, nil
+	}
+
 	conf, err := config.LoadClientConfig()
 	if err != nil {
 		return "", fmt.Errorf("load config: %w", err)
@@ -28,28 +35,88 @@ func ensureAuth(ctx context.Context) (string, error) {
 		return token, nil
 	}
 
-	if canAuthGitHubOIDC() {
+	if conf.RefreshToken != "" {
+		token, err = refreshAuth(ctx)
+		if err == nil {
+			initialCheck.Store(true)
+			return token, nil
+		}
+		Debug("Failed to refresh token: %s", err)
+	}
+
+	refreshToken := ""
+	if canAuthOIDC() {
+		Info("Authenticating using OIDC token...")
+		token, err = authOIDC(ctx)
+	} else if canAuthGitHubOIDC() {
 		Info("Authenticating using GitHub Actions OIDC token...")
 		token, err = authGitHubOIDC(ctx)
 	} else {
-		token, err = authGitHubSSH(ctx)
+		token, refreshToken, err = authGitHubSSH(ctx)
 	}
 
 	if err == nil {
 		initialCheck.Store(true)
-		_ = saveToken(token)
+		_ = updateClientConfig(func(conf *config.ClientConfig) {
+			conf.AuthToken = token
+			conf.RefreshToken = refreshToken
+		})
 	}
 
 	return token, err
 }
 
+// refreshAuth obtains new token using the stored refresh token, rotating the
+// stored refresh token.
+func refreshAuth(ctx context.Context) (string, error) {
+	if viper.GetString("token") != "" {
+		return "", models.ErrInvalidCredentials
+	}
+
+	conf, err := config.LoadClientConfig()
+	if err != nil {
+		return "", fmt.Errorf("load config: %w", err)
+	}
+	if conf.RefreshToken == "" {
+		return "", models.ErrInvalidCredentials
+	}
+
+	tokens, err := API().RefreshAuthToken(ctx, conf.RefreshToken)
+	if code, ok := api.ErrorStatusCode(err); ok && code == http.StatusUnauthorized {
+		// Refresh token is rejected; discard it and login again.
+		_ = updateClientConfig(func(conf *config.ClientConfig) {
+			conf.AuthToken = ""
+			conf.RefreshToken = ""
+		})
+		return "", err
+	} else if err != nil {
+		return "", err
+	}
+
+	err = updateClientConfig(func(conf *config.ClientConfig) {
+		conf.AuthToken = tokens.Token
+		conf.RefreshToken = tokens.RefreshToken
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return tokens.Token, nil
+}
+
 func saveToken(token string) error {
+	return updateClientConfig(func(conf *config.ClientConfig) {
+		conf.AuthToken = token
+	})
+}
+
+func updateClientConfig(update func(conf *config.ClientConfig)) error {
 	conf, err := config.LoadClientConfig()
 	if err != nil {
 		return fmt.Errorf("load config: %w", err)
 	}
 
-	conf.AuthToken = token
+	update(conf)
 	err = conf.Save()
 	if err != nil {
 		return fmt.Errorf("save config: %w", err)
@@ -2,17 +2,19 @@ package app
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 
 	"github.com/manifoldco/promptui"
+	"github.com/oursky/pageship/internal/api"
 	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/sshkey"
 	"golang.org/x/crypto/ssh"
 )
 
-func authGitHubSSH(ctx context.Context) (string, error) {
+func authGitHubSSH(ctx context.Context) (token string, refreshToken string, err error) {
 	var sources []sshkey.Source
 	defer func() {
 		for _, s := range sources {
@@ -42,23 +44,12 @@ func authGitHubSSH(ctx context.Context) (string, error) {
 			}
 			return prompt.Run()
 		},
-		func() ([]byte, error) {
-			prompt := promptui.Prompt{
-				Label:       "SSH key passphrase",
-				Mask:        '*',
-				HideEntered: true,
-			}
-			result, err := prompt.Run()
-			if err != nil {
-				return nil, err
-			}
-			return []byte(result), nil
-		},
+		promptSSHKeyPassphrase,
 	))
 
 	conf, err := config.LoadClientConfig()
 	if err != nil {
-		return "", fmt.Errorf("load config: %w", err)
+		return "", "", fmt.Errorf("load config: %w", err)
 	}
 	userName := conf.GitHubUsername
 
@@ -75,7 +66,7 @@ func authGitHubSSH(ctx context.Context) (string, error) {
 		result, err := prompt.Run()
 		if err != nil {
 			Info("Cancelled.")
-			return "", ErrCancelled
+			return "", "", ErrCancelled
 		}
 
 		userName = result
@@ -96,22 +87,39 @@ func authGitHubSSH(ctx context.Context) (string, error) {
 
 	ws, err := API().OpenAuthGitHubSSH(ctx)
 	if err != nil {
-		return "", fmt.Errorf("connect to server: %w", err)
+		return "", "", fmt.Errorf("connect to server: %w", err)
 	}
 
 	sshConn, _, _, err := ssh.NewClientConn(ws, "", sshConf)
 	if err != nil {
-		return "", fmt.Errorf("authenticate with server: %w", err)
+		return "", "", fmt.Errorf("authenticate with server: %w", err)
 	}
 	defer sshConn.Close()
 
-	ok, reply, err := sshConn.SendRequest("pageship", true, nil)
+	ok, reply, err := sshConn.SendRequest("pageship", true, []byte("refresh-token"))
 	if err != nil {
-		return "", fmt.Errorf("request token: %w", err)
+		return "", "", fmt.Errorf("request token: %w", err)
 	} else if !ok {
-		return "", fmt.Errorf("request token failed")
+		return "", "", fmt.Errorf("request token failed")
+	}
+
+	// Older servers reply with token only.
+	var tokens api.APIAuthTokens
+	if err := json.Unmarshal(reply, &tokens); err != nil {
+		return string(reply), "", nil
 	}
+	return tokens.Token, tokens.RefreshToken, nil
+}
 
-	token := string(reply)
-	return token, nil
+func promptSSHKeyPassphrase() ([]byte, error) {
+	prompt := promptui.Prompt{
+		Label:       "SSH key passphrase",
+		Mask:        '*',
+		HideEntered: true,
+	}
+	result, err := prompt.Run()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(result), nil
 }
@@ -0,0 +1,18 @@
+package app
+
+import (
+	"context"
+	"os"
+)
+
+// oidcTokenEnv names the variable holding OIDC ID token issued by CI
+// providers, e.g. GitLab CI `id_tokens`.
+const oidcTokenEnv = "PAGESHIP_OIDC_TOKEN"
+
+func canAuthOIDC() bool {
+	return os.Getenv(oidcTokenEnv) != ""
+}
+
+func authOIDC(ctx context.Context) (string, error) {
+	return API().AuthOIDC(ctx, os.Getenv(oidcTokenEnv))
+}
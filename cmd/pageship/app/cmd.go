@@ -19,6 +19,7 @@ var debugMode bool
 func init() {
 	rootCmd.PersistentFlags().Bool("debug", false, "debug mode")
 	rootCmd.PersistentFlags().String("api", "", "server API endpoint")
+	rootCmd.PersistentFlags().String("token", "", "API token")
 
 	cobra.OnInitialize(initConfig)
 }
@@ -65,6 +66,7 @@ func API() *api.Client {
 		apiClient.TokenFunc = func(r *http.Request) (string, error) {
 			return ensureAuth(r.Context())
 		}
+		apiClient.RefreshFunc = refreshAuth
 	}
 	return apiClient
 }
@@ -29,13 +29,9 @@ func init() {
 	deployCmd.PersistentFlags().BoolP("yes", "y", false, "skip confirmation")
 }
 
-func packTar(dir string, tarfile *os.File, conf *config.Config) ([]models.FileEntry, int64, error) {
+func collectFiles(dir string, conf *config.Config) (*deploy.Collector, error) {
 	modTime := time.SystemClock.Now()
-	collector, err := deploy.NewCollector(modTime, tarfile)
-	if err != nil {
-		return nil, 0, err
-	}
-	defer collector.Close()
+	collector := deploy.NewCollector(modTime)
 
 	collector.AddDir("/")
 
@@ -44,31 +40,59 @@ func packTar(dir string, tarfile *os.File, conf *config.Config) ([]models.FileEn
 
 	confJSON, err := json.MarshalIndent(conf, "", "\t")
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 	err = collector.AddFile(fmt.Sprintf("/%s.json", config.SiteConfigName), confJSON)
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
 
 	err = collector.Collect(os.DirFS(publicDir), "/public")
 	if err != nil {
-		return nil, 0, fmt.Errorf("collecting from %s: %w", publicDir, err)
+		return nil, fmt.Errorf("collecting from %s: %w", publicDir, err)
 	}
 
-	collector.Close()
+	return collector, nil
+}
+
+func packTar(collector *deploy.Collector, tarfile *os.File, missingHashes *[]string) (int, int64, error) {
+	include := func(models.FileEntry) bool { return true }
+	if missingHashes != nil {
+		missing := make(map[string]struct{})
+		for _, hash := range *missingHashes {
+			missing[hash] = struct{}{}
+		}
+		include = func(entry models.FileEntry) bool {
+			_, ok := missing[entry.Hash]
+			return ok
+		}
+	}
+
+	count := 0
+	err := collector.Pack(tarfile, func(entry models.FileEntry) bool {
+		if !include(entry) {
+			return false
+		}
+		if _, _, encoded := deploy.ParseEncodedPath(entry.Path); entry.Hash != "" && !encoded {
+			count++
+		}
+		return true
+	})
+	if err != nil {
+		return 0, 0, err
+	}
 
 	_, err = tarfile.Seek(0, io.SeekStart)
 	if err != nil {
-		return nil, 0, err
+		return 0, 0, err
 	}
 
 	fi, err := tarfile.Stat()
 	if err != nil {
-		return nil, 0, err
+		return 0, 0, err
 	}
 
-	return collector.Files(), fi.Size(), nil
+	return count, fi.Size(), nil
 }
 
 func doDeploy(ctx context.Context, appID string, siteName string, deploymentName string, conf *config.Config, dir string) error {
@@ -87,13 +111,13 @@ func doDeploy(ctx context.Context, appID string, siteName string, deploymentName
 	}
 
 	Info("Collecting files...")
-	Debug("Tarball: %s", tarfile.Name())
-	files, tarSize, err := packTar(dir, tarfile, conf)
+	collector, err := collectFiles(dir, conf)
 	if err != nil {
 		return fmt.Errorf("failed to collect files: %w", err)
 	}
+	files := collector.Files()
 
-	Info("%d files found. Tarball size: %s", len(files), humanize.Bytes(uint64(tarSize)))
+	Info("%d files found.", len(files))
 
 	Info("Setting up deployment '%s'...", deploymentName)
 
@@ -119,9 +143,17 @@ func doDeploy(ctx context.Context, appID string, siteName string, deploymentName
 
 	Debug("Deployment ID: %s", deployment.ID)
 
+	Debug("Tarball: %s", tarfile.Name())
+	fileCount, tarSize, err := packTar(collector, tarfile, deployment.MissingHashes)
+	if err != nil {
+		return fmt.Errorf("failed to pack files: %w", err)
+	}
+
+	Info("%d files to upload. Tarball size: %s", fileCount, humanize.Bytes(uint64(tarSize)))
+
 	bar := progressbar.DefaultBytes(tarSize, "uploading")
 	body := io.TeeReader(tarfile, bar)
-	deployment, err = API().UploadDeploymentTarball(ctx, appID, deployment.Name, body, tarSize)
+	uploaded, err := API().UploadDeploymentTarball(ctx, appID, deployment.Name, body, tarSize)
 	if err != nil {
 		return fmt.Errorf("failed to upload tarball: %w", err)
 	}
@@ -129,7 +161,7 @@ func doDeploy(ctx context.Context, appID string, siteName string, deploymentName
 	if siteName != "" {
 		Info("Activating deployment...")
 		_, err = API().UpdateSite(ctx, appID, siteName, &api.SitePatchRequest{
-			DeploymentName: &deployment.Name,
+			DeploymentName: &uploaded.Name,
 		})
 		if err != nil {
 			return fmt.Errorf("failed to activate deployment: %w", err)
@@ -3,6 +3,7 @@ package app
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"text/tabwriter"
 	"time"
 
@@ -13,6 +14,12 @@ import (
 func init() {
 	rootCmd.AddCommand(deploymentsCmd)
 	deploymentsCmd.PersistentFlags().String("app", "", "app ID")
+
+	deploymentsCmd.AddCommand(deploymentsDeleteCmd)
+	deploymentsDeleteCmd.PersistentFlags().BoolP("yes", "y", false, "skip confirmation")
+
+	deploymentsCmd.AddCommand(deploymentsDownloadCmd)
+	deploymentsDownloadCmd.PersistentFlags().StringP("output", "o", "", "output file; <deployment>.tar.zst if not set")
 }
 
 var deploymentsCmd = &cobra.Command{
@@ -61,3 +68,78 @@ var deploymentsCmd = &cobra.Command{
 		return nil
 	},
 }
+
+var deploymentsDeleteCmd = &cobra.Command{
+	Use:   "delete <deployment> [--yes]",
+	Short: "Delete deployment",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deploymentName := args[0]
+
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		if !viper.GetBool("yes") {
+			if err := Confirm(fmt.Sprintf("Delete deployment %q of app %q", deploymentName, appID)); err != nil {
+				return err
+			}
+		}
+
+		err := API().DeleteDeployment(cmd.Context(), appID, deploymentName)
+		if err != nil {
+			return fmt.Errorf("failed to delete deployment: %w", err)
+		}
+
+		Info("Deployment %q deleted.", deploymentName)
+		return nil
+	},
+}
+
+var deploymentsDownloadCmd = &cobra.Command{
+	Use:   "download <deployment> [--output file]",
+	Short: "Download deployment tarball",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		deploymentName := args[0]
+
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		output := viper.GetString("output")
+		if output == "" {
+			output = deploymentName + ".tar.zst"
+		}
+
+		// Download to temp file first, to avoid leaving partial tarball.
+		file, err := os.CreateTemp(filepath.Dir(output), filepath.Base(output)+".*.tmp")
+		if err != nil {
+			return fmt.Errorf("failed to create file: %w", err)
+		}
+		defer os.Remove(file.Name())
+		defer file.Close()
+
+		err = API().DownloadDeploymentTarball(cmd.Context(), appID, deploymentName, file)
+		if err != nil {
+			return fmt.Errorf("failed to download deployment: %w", err)
+		}
+		if err := file.Close(); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		if err := os.Rename(file.Name(), output); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+
+		Info("Deployment %q downloaded to %s.", deploymentName, output)
+		return nil
+	},
+}
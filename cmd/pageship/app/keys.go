@@ -0,0 +1,156 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/sshkey"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh"
+)
+
+func init() {
+	rootCmd.AddCommand(keysCmd)
+
+	keysCmd.AddCommand(keysListCmd)
+
+	keysCmd.AddCommand(keysAddCmd)
+	keysAddCmd.PersistentFlags().String("name", "", "key name")
+
+	keysCmd.AddCommand(keysRemoveCmd)
+	keysRemoveCmd.PersistentFlags().BoolP("yes", "y", false, "skip confirmation")
+}
+
+var keysCmd = &cobra.Command{
+	Use:   "keys",
+	Short: "Manage registered SSH keys",
+}
+
+var keysListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List registered SSH keys",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keys, err := API().ListSSHKeys(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list keys: %w", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tFINGERPRINT\tCREATED AT\tLAST USED")
+		for _, k := range keys {
+			lastUsed := "-"
+			if k.LastUsedAt != nil {
+				lastUsed = k.LastUsedAt.Local().Format(time.DateTime)
+			}
+			createdAt := k.CreatedAt.Local().Format(time.DateTime)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", k.ID, k.Name, k.Fingerprint, createdAt, lastUsed)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+var keysAddCmd = &cobra.Command{
+	Use:   "add <public-key-file> [--name <name>]",
+	Short: "Register SSH public key for login",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to read public key: %w", err)
+		}
+
+		pubKey, comment, _, _, err := ssh.ParseAuthorizedKey(data)
+		if err != nil {
+			return fmt.Errorf("invalid public key: %w", err)
+		}
+
+		name, _ := cmd.Flags().GetString("name")
+		if name == "" {
+			name = comment
+		}
+		if name == "" {
+			name = ssh.FingerprintSHA256(pubKey)
+		}
+
+		signature, err := signSSHKeyProof(cmd.Context(), args[0], pubKey)
+		if err != nil {
+			return fmt.Errorf("failed to sign with key: %w", err)
+		}
+
+		key, err := API().AddSSHKey(cmd.Context(), api.SSHKeyAddRequest{
+			Name:      name,
+			PublicKey: strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pubKey))),
+			Signature: signature,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to add key: %w", err)
+		}
+
+		Info("Key %q added. (id: %q, fingerprint: %s)", key.Name, key.ID, key.Fingerprint)
+		return nil
+	},
+}
+
+// signSSHKeyProof proves possession of the key to server, using SSH agent or
+// the private key file next to the public key file.
+func signSSHKeyProof(ctx context.Context, pubKeyFile string, pubKey ssh.PublicKey) (string, error) {
+	var sources []sshkey.Source
+	agent, err := sshkey.NewAgent()
+	if err != nil {
+		Debug("Failed to connect to SSH agent: %s", err)
+	} else {
+		defer agent.Close()
+		sources = append(sources, agent)
+	}
+	sources = append(sources, sshkey.NewKeyFile(strings.TrimSuffix(pubKeyFile, ".pub"), promptSSHKeyPassphrase))
+
+	signer, err := sshkey.FindSigner(sources, pubKey)
+	if err != nil {
+		return "", err
+	}
+
+	me, err := API().GetMe(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	sig, err := signer.Sign(rand.Reader, models.SSHKeyProofData(me.ID))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ssh.Marshal(sig)), nil
+}
+
+var keysRemoveCmd = &cobra.Command{
+	Use:   "remove <key-id> [--yes]",
+	Short: "Remove registered SSH key",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		keyID := args[0]
+
+		if !viper.GetBool("yes") {
+			if err := Confirm(fmt.Sprintf("Remove key %q", keyID)); err != nil {
+				return err
+			}
+		}
+
+		err := API().RemoveSSHKey(cmd.Context(), keyID)
+		if err != nil {
+			return fmt.Errorf("failed to remove key: %w", err)
+		}
+
+		Info("Key %q removed.", keyID)
+		return nil
+	},
+}
@@ -20,6 +20,10 @@ var loginCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to login: %w", err)
 		}
+		if models.IsAPIToken(token) {
+			Info("Using API token; login is not needed.")
+			return nil
+		}
 
 		claims := &models.TokenClaims{}
 		_, _, err = jwt.NewParser().ParseUnverified(token, claims)
@@ -19,7 +19,15 @@ var logoutCmd = &cobra.Command{
 		if err != nil {
 			return fmt.Errorf("failed to load config: %w", err)
 		}
+		if conf.RefreshToken != "" {
+			err = API().RevokeRefreshToken(cmd.Context(), conf.RefreshToken)
+			if err != nil {
+				Warn("Failed to revoke refresh token: %s", err)
+			}
+		}
+
 		conf.AuthToken = ""
+		conf.RefreshToken = ""
 		err = conf.Save()
 		if err != nil {
 			return fmt.Errorf("failed to save config: %w", err)
@@ -5,6 +5,7 @@ import (
 
 	"github.com/oursky/pageship/internal/config"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 )
 
 func init() {
@@ -20,7 +21,7 @@ var meCmd = &cobra.Command{
 			return fmt.Errorf("failed to load config: %w", err)
 		}
 
-		if conf.AuthToken == "" {
+		if conf.AuthToken == "" && viper.GetString("token") == "" {
 			Info("Logged out.")
 			return nil
 		}
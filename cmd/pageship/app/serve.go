@@ -18,6 +18,7 @@ import (
 	"github.com/oursky/pageship/internal/httputil"
 	"github.com/oursky/pageship/internal/site"
 	sitelocal "github.com/oursky/pageship/internal/site/local"
+	"github.com/oursky/pageship/internal/watch"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -34,6 +35,8 @@ func init() {
 
 	serveCmd.PersistentFlags().String("default-site", config.DefaultSite, "default site")
 	serveCmd.PersistentFlags().String("host-pattern", config.DefaultHostPattern, "host match pattern")
+	serveCmd.PersistentFlags().Bool("watch", false, "reload pages on file changes")
+	serveCmd.PersistentFlags().String("tarball", "", "serve deployment tarball instead of site directory")
 }
 
 func loadSitesConfig(fsys fs.FS) (*config.SitesConfig, error) {
@@ -47,10 +50,10 @@ func loadSitesConfig(fsys fs.FS) (*config.SitesConfig, error) {
 	return conf, nil
 }
 
-func makeHandler(prefix string, defaultSite string, hostPattern string) (*handler.Handler, error) {
+func makeResolvers(prefix string, defaultSite string) (site.Resolver, domain.Resolver, error) {
 	dir, err := filepath.Abs(prefix)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	fsys := os.DirFS(dir)
@@ -69,7 +72,7 @@ func makeHandler(prefix string, defaultSite string, hostPattern string) (*handle
 		if errors.Is(err, config.ErrConfigNotFound) {
 			// Treat as no sites
 		} else if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 
 		var sites map[string]config.SitesConfigEntry
@@ -79,19 +82,49 @@ func makeHandler(prefix string, defaultSite string, hostPattern string) (*handle
 		siteResolver = sitelocal.NewResolver(fsys, defaultSite, sites)
 		domainResolver, err = domainlocal.NewResolver(defaultSite, sites)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	} else if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	return siteResolver, domainResolver, nil
+}
+
+func makeTarballResolvers(path string) (site.Resolver, domain.Resolver, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
 	}
+	defer file.Close()
 
+	siteResolver, err := sitelocal.NewTarballResolver(file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return siteResolver, &domain.ResolverNull{}, nil
+}
+
+func makeHandler(
+	siteResolver site.Resolver,
+	domainResolver domain.Resolver,
+	hostPattern string,
+	liveReload *handler.LiveReload,
+) (*handler.Handler, error) {
 	Info("site resolution mode: %s", siteResolver.Kind())
 
+	middlewares := middleware.Default
+	if liveReload != nil {
+		middlewares = middleware.LiveReload
+	}
+
 	handler, err := handler.NewHandler(context.Background(), zapLogger,
 		domainResolver, siteResolver,
 		handler.HandlerConfig{
 			HostPattern: hostPattern,
-			Middlewares: middleware.Default,
+			Middlewares: middlewares,
+			LiveReload:  liveReload,
 		})
 	if err != nil {
 		return nil, err
@@ -101,7 +134,7 @@ func makeHandler(prefix string, defaultSite string, hostPattern string) (*handle
 }
 
 var serveCmd = &cobra.Command{
-	Use:   "serve [site directory]",
+	Use:   "serve [site directory | --tarball file]",
 	Short: "Start local server",
 	Args:  cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -114,17 +147,58 @@ var serveCmd = &cobra.Command{
 
 		defaultSite := viper.GetString("default-site")
 		hostPattern := viper.GetString("host-pattern")
+		watchFiles := viper.GetBool("watch")
+		tarball := viper.GetString("tarball")
 
 		dir := "."
 		if len(args) > 0 {
 			dir = args[0]
 		}
 
-		handler, err := makeHandler(dir, defaultSite, hostPattern)
+		var siteResolver site.Resolver
+		var domainResolver domain.Resolver
+		var err error
+		if tarball != "" {
+			if len(args) > 0 || watchFiles {
+				return fmt.Errorf("site directory and --watch cannot be used with --tarball")
+			}
+			siteResolver, domainResolver, err = makeTarballResolvers(tarball)
+		} else {
+			siteResolver, domainResolver, err = makeResolvers(dir, defaultSite)
+		}
 		if err != nil {
 			return fmt.Errorf("failed to setup server: %w", err)
 		}
 
+		var liveReload *handler.LiveReload
+		if watchFiles {
+			liveReload = handler.NewLiveReload()
+		}
+
+		handler, err := makeHandler(siteResolver, domainResolver, hostPattern, liveReload)
+		if err != nil {
+			return fmt.Errorf("failed to setup server: %w", err)
+		}
+
+		works := []command.WorkFunc{}
+		if watchFiles {
+			watcher, err := watch.NewDir(zapLogger, dir, func(paths []string) {
+				// Site configs may be changed; resolve sites again.
+				handler.Invalidate()
+				liveReload.Reload()
+			})
+			if err != nil {
+				return fmt.Errorf("failed to watch files: %w", err)
+			}
+
+			works = append(works, func(ctx context.Context) error {
+				<-ctx.Done()
+				watcher.Close()
+				liveReload.Close()
+				return nil
+			})
+		}
+
 		var tls *httputil.ServerTLSConfig
 		if useTLS {
 			tls = &httputil.ServerTLSConfig{
@@ -148,7 +222,8 @@ var serveCmd = &cobra.Command{
 			Handler: handler,
 			TLS:     tls,
 		}
-		command.Run([]command.WorkFunc{server.Run})
+		works = append(works, server.Run)
+		command.Run(works)
 		return nil
 	},
 }
@@ -3,8 +3,13 @@ package app
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/dustin/go-humanize"
+	"github.com/oursky/pageship/internal/api"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -12,6 +17,15 @@ import (
 func init() {
 	rootCmd.AddCommand(sitesCmd)
 	sitesCmd.PersistentFlags().String("app", "", "app ID")
+
+	sitesCmd.AddCommand(sitesRollbackCmd)
+	sitesCmd.AddCommand(sitesHistoryCmd)
+	sitesCmd.AddCommand(sitesDeleteCmd)
+	sitesCmd.AddCommand(sitesStatsCmd)
+	sitesDeleteCmd.PersistentFlags().BoolP("yes", "y", false, "skip confirmation")
+	sitesHistoryCmd.PersistentFlags().Int("limit", 20, "number of entries to show")
+	sitesRollbackCmd.PersistentFlags().String("to", "", "deployment name; defaults to previously active deployment")
+	sitesStatsCmd.PersistentFlags().Int("hours", 24, "number of recent hours to show")
 }
 
 var sitesCmd = &cobra.Command{
@@ -44,3 +58,206 @@ var sitesCmd = &cobra.Command{
 		return nil
 	},
 }
+
+var sitesRollbackCmd = &cobra.Command{
+	Use:   "rollback <site> [--to deployment name]",
+	Short: "Rollback site to previous deployment",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		siteName := args[0]
+		to := viper.GetString("to")
+
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		request := &api.SiteRollbackRequest{}
+		if to != "" {
+			request.DeploymentName = &to
+		}
+
+		site, err := API().RollbackSite(cmd.Context(), appID, siteName, request)
+		if err != nil {
+			return fmt.Errorf("failed to rollback site: %w", err)
+		}
+
+		deployment := "-"
+		if site.DeploymentName != nil {
+			deployment = *site.DeploymentName
+		}
+		Info("Site %q is now serving deployment %q.", siteName, deployment)
+		return nil
+	},
+}
+
+var sitesHistoryCmd = &cobra.Command{
+	Use:   "history <site> [--limit number of entries]",
+	Short: "Show activation history of site",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		siteName := args[0]
+		limit, err := cmd.Flags().GetInt("limit")
+		if err != nil {
+			return err
+		}
+
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		history, err := API().ListSiteHistory(cmd.Context(), appID, siteName, limit)
+		if err != nil {
+			return fmt.Errorf("failed to get site history: %w", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+		fmt.Fprintln(w, "TIME\tDEPLOYMENT\tPREVIOUS\tSUBJECT\tRULE")
+		for _, a := range history {
+			deployment := "-"
+			if a.DeploymentName != nil {
+				deployment = *a.DeploymentName
+			}
+			previous := "-"
+			if a.PreviousDeploymentName != nil {
+				previous = *a.PreviousDeploymentName
+			}
+			subject := "-"
+			if a.Subject != "" {
+				subject = a.Subject
+			}
+			rule := "-"
+			if a.CredentialRule != "" {
+				rule = a.CredentialRule
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+				a.CreatedAt.Local().Format(time.DateTime),
+				deployment,
+				previous,
+				subject,
+				rule,
+			)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+var sitesDeleteCmd = &cobra.Command{
+	Use:   "delete <site> [--yes]",
+	Short: "Delete site",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		siteName := args[0]
+
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		if !viper.GetBool("yes") {
+			if err := Confirm(fmt.Sprintf("Delete site %q of app %q", siteName, appID)); err != nil {
+				return err
+			}
+		}
+
+		err := API().DeleteSite(cmd.Context(), appID, siteName)
+		if err != nil {
+			return fmt.Errorf("failed to delete site: %w", err)
+		}
+
+		Info("Site %q deleted.", siteName)
+		return nil
+	},
+}
+
+var sitesStatsCmd = &cobra.Command{
+	Use:   "stats <site> [--hours number of hours]",
+	Short: "Show traffic analytics of site",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		siteName := args[0]
+		hours, err := cmd.Flags().GetInt("hours")
+		if err != nil {
+			return err
+		}
+
+		appID := viper.GetString("app")
+		if appID == "" {
+			appID = tryLoadAppID()
+		}
+		if appID == "" {
+			return fmt.Errorf("app ID is not set")
+		}
+
+		stats, err := API().GetSiteAnalytics(cmd.Context(), appID, siteName, hours)
+		if err != nil {
+			return fmt.Errorf("failed to get site analytics: %w", err)
+		}
+
+		fmt.Printf("%s - %s: %d requests, %s\n\n",
+			stats.From.Local().Format(time.DateTime),
+			stats.To.Local().Format(time.DateTime),
+			stats.Requests,
+			humanize.Bytes(uint64(stats.Bytes)),
+		)
+
+		w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+		fmt.Fprintln(w, "HOUR\tREQUESTS\tBANDWIDTH\tSTATUS")
+		for _, h := range stats.Hours {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\n",
+				h.Hour.Local().Format(time.DateTime),
+				h.Requests,
+				humanize.Bytes(uint64(h.Bytes)),
+				formatStatuses(h.Statuses),
+			)
+		}
+		w.Flush()
+
+		printTopCounts("PATH", stats.TopPaths)
+		printTopCounts("REFERRER", stats.TopReferrers)
+		return nil
+	},
+}
+
+func formatStatuses(statuses map[string]int64) string {
+	codes := make([]string, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	var parts []string
+	for _, code := range codes {
+		parts = append(parts, fmt.Sprintf("%s:%d", code, statuses[code]))
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, " ")
+}
+
+func printTopCounts(title string, list []api.APISiteAnalyticsCounts) {
+	if len(list) == 0 {
+		return
+	}
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+	fmt.Fprintf(w, "%s\tREQUESTS\tBANDWIDTH\n", title)
+	for _, c := range list {
+		fmt.Fprintf(w, "%s\t%d\t%s\n", c.Value, c.Requests, humanize.Bytes(uint64(c.Bytes)))
+	}
+	w.Flush()
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func init() {
+	rootCmd.AddCommand(tokensCmd)
+
+	tokensCmd.AddCommand(tokensCreateCmd)
+	tokensCreateCmd.PersistentFlags().String("app", "", "limit token to app ID")
+	tokensCreateCmd.PersistentFlags().String("access", "", "limit token to access level")
+	tokensCreateCmd.PersistentFlags().Duration("expires-in", 0, "token expiry duration")
+
+	tokensCmd.AddCommand(tokensRevokeCmd)
+	tokensRevokeCmd.PersistentFlags().BoolP("yes", "y", false, "skip confirmation")
+}
+
+var tokensCmd = &cobra.Command{
+	Use:   "tokens",
+	Short: "Manage API tokens",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokens, err := API().ListAPITokens(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("failed to list tokens: %w", err)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 1, 4, 4, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tAPP\tACCESS\tEXPIRES\tLAST USED")
+		for _, t := range tokens {
+			app := "*"
+			if t.AppID != nil {
+				app = *t.AppID
+			}
+			access := "*"
+			if t.Access != nil {
+				access = string(*t.Access)
+			}
+			expires := "-"
+			if t.ExpiresAt != nil {
+				expires = t.ExpiresAt.Local().Format(time.DateTime)
+			}
+			lastUsed := "-"
+			if t.LastUsedAt != nil {
+				lastUsed = t.LastUsedAt.Local().Format(time.DateTime)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", t.ID, t.Name, app, access, expires, lastUsed)
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+var tokensCreateCmd = &cobra.Command{
+	Use:   "create <name> [--app <app-id>] [--access <level>] [--expires-in <duration>]",
+	Short: "Create API token",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		request := api.APITokenCreateRequest{Name: args[0]}
+
+		appID, _ := cmd.Flags().GetString("app")
+		if appID != "" {
+			request.AppID = &appID
+		}
+
+		access, _ := cmd.Flags().GetString("access")
+		if access != "" {
+			level := config.AccessLevel(access)
+			if !level.IsValid() {
+				return fmt.Errorf("invalid access level: %s", access)
+			}
+			request.Access = &level
+		}
+
+		expiresIn, _ := cmd.Flags().GetDuration("expires-in")
+		if expiresIn > 0 {
+			d := expiresIn.String()
+			request.ExpiresIn = &d
+		}
+
+		token, err := API().CreateAPIToken(cmd.Context(), request)
+		if err != nil {
+			return fmt.Errorf("failed to create token: %w", err)
+		}
+
+		Info("Token %q created. (id: %q)", token.Name, token.ID)
+		Warn("The token would not be shown again; store it securely:")
+		fmt.Println(token.Token)
+		return nil
+	},
+}
+
+var tokensRevokeCmd = &cobra.Command{
+	Use:   "revoke <token-id> [--yes]",
+	Short: "Revoke API token",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tokenID := args[0]
+
+		if !viper.GetBool("yes") {
+			if err := Confirm(fmt.Sprintf("Revoke token %q", tokenID)); err != nil {
+				return err
+			}
+		}
+
+		err := API().RevokeAPIToken(cmd.Context(), tokenID)
+		if err != nil {
+			return fmt.Errorf("failed to revoke token: %w", err)
+		}
+
+		Info("Token %q revoked.", tokenID)
+		return nil
+	},
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/oursky/pageship/internal/config"
@@ -17,13 +18,14 @@ type Client struct {
 	endpoint  string
 	client    *http.Client
 	TokenFunc func(r *http.Request) (string, error)
+	// RefreshFunc obtains a new token when server rejects the attached token.
+	RefreshFunc func(ctx context.Context) (string, error)
 }
 
 func NewClientWithTransport(endpoint string, transport http.RoundTripper) *Client {
-	return &Client{
-		endpoint: endpoint,
-		client:   &http.Client{Transport: transport},
-	}
+	c := &Client{endpoint: endpoint}
+	c.client = &http.Client{Transport: &refreshTransport{client: c, base: transport}}
+	return c
 }
 
 func NewClient(endpoint string) *Client {
@@ -163,7 +165,7 @@ func (c *Client) ListUsers(ctx context.Context, appID string) ([]APIUser, error)
 	return decodeJSONResponse[[]APIUser](resp)
 }
 
-func (c *Client) AddUser(ctx context.Context, appID string, userID string) error {
+func (c *Client) AddUser(ctx context.Context, appID string, userID string, access config.AccessLevel) error {
 	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "users")
 	if err != nil {
 		return err
@@ -171,6 +173,7 @@ func (c *Client) AddUser(ctx context.Context, appID string, userID string) error
 
 	req, err := newJSONRequest(ctx, "POST", endpoint, map[string]any{
 		"userID": userID,
+		"access": access,
 	})
 	if err != nil {
 		return err
@@ -320,6 +323,117 @@ func (c *Client) UpdateSite(
 	return decodeJSONResponse[*APISite](resp)
 }
 
+func (c *Client) RollbackSite(
+	ctx context.Context,
+	appID string,
+	siteName string,
+	request *SiteRollbackRequest,
+) (*APISite, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "sites", siteName, "rollback")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newJSONRequest(ctx, "POST", endpoint, request)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*APISite](resp)
+}
+
+func (c *Client) ListSiteHistory(ctx context.Context, appID string, siteName string, limit int) ([]APISiteActivation, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "sites", siteName, "history")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 {
+		req.URL.RawQuery = url.Values{"limit": []string{strconv.Itoa(limit)}}.Encode()
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[[]APISiteActivation](resp)
+}
+
+func (c *Client) GetSiteAnalytics(ctx context.Context, appID string, siteName string, hours int) (*APISiteAnalytics, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "sites", siteName, "analytics")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if hours > 0 {
+		req.URL.RawQuery = url.Values{"hours": []string{strconv.Itoa(hours)}}.Encode()
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*APISiteAnalytics](resp)
+}
+
+func (c *Client) ListAuditLogs(ctx context.Context, appID string, before string, limit int) ([]models.AuditLog, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "audit")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	if before != "" {
+		query.Set("before", before)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	req.URL.RawQuery = query.Encode()
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[[]models.AuditLog](resp)
+}
+
 func (c *Client) GetDeployment(ctx context.Context, appID string, deploymentName string) (*APIDeployment, error) {
 	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments", deploymentName)
 	if err != nil {
@@ -372,7 +486,7 @@ func (c *Client) SetupDeployment(
 	name string,
 	files []models.FileEntry,
 	siteConfig *config.SiteConfig,
-) (*models.Deployment, error) {
+) (*APIDeployment, error) {
 	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments")
 	if err != nil {
 		return nil, err
@@ -396,7 +510,7 @@ func (c *Client) SetupDeployment(
 	}
 	defer resp.Body.Close()
 
-	return decodeJSONResponse[*models.Deployment](resp)
+	return decodeJSONResponse[*APIDeployment](resp)
 }
 
 func (c *Client) UploadDeploymentTarball(
@@ -429,6 +543,40 @@ func (c *Client) UploadDeploymentTarball(
 	return decodeJSONResponse[*models.Deployment](resp)
 }
 
+func (c *Client) DownloadDeploymentTarball(
+	ctx context.Context,
+	appID string,
+	deploymentName string,
+	w io.Writer,
+) error {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments", deploymentName, "tarball")
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.attachToken(req); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		_, err := decodeJSONResponse[any](resp)
+		return err
+	}
+
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
 func (c *Client) ListDomains(ctx context.Context, appID string) ([]APIDomain, error) {
 	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "domains")
 	if err != nil {
@@ -503,6 +651,233 @@ func (c *Client) DeleteDomain(ctx context.Context, appID string, domainName stri
 	return decodeJSONResponse[*APIDomain](resp)
 }
 
+func (c *Client) DeleteApp(ctx context.Context, appID string) error {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.attachToken(req); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = decodeJSONResponse[struct{}](resp)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) DeleteSite(ctx context.Context, appID string, siteName string) error {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "sites", siteName)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.attachToken(req); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = decodeJSONResponse[struct{}](resp)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) DeleteDeployment(ctx context.Context, appID string, deploymentName string) error {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "apps", appID, "deployments", deploymentName)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.attachToken(req); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = decodeJSONResponse[struct{}](resp)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) ListAPITokens(ctx context.Context) ([]models.APIToken, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "tokens")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[[]models.APIToken](resp)
+}
+
+func (c *Client) CreateAPIToken(ctx context.Context, request APITokenCreateRequest) (*APITokenCreated, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "tokens")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newJSONRequest(ctx, "POST", endpoint, request)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*APITokenCreated](resp)
+}
+
+func (c *Client) RevokeAPIToken(ctx context.Context, tokenID string) error {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "tokens", tokenID)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.attachToken(req); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = decodeJSONResponse[struct{}](resp)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (c *Client) ListSSHKeys(ctx context.Context) ([]models.SSHKey, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "keys")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[[]models.SSHKey](resp)
+}
+
+func (c *Client) AddSSHKey(ctx context.Context, request SSHKeyAddRequest) (*models.SSHKey, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "keys")
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newJSONRequest(ctx, "POST", endpoint, request)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.attachToken(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*models.SSHKey](resp)
+}
+
+func (c *Client) RemoveSSHKey(ctx context.Context, keyID string) error {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "keys", keyID)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	if err := c.attachToken(req); err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = decodeJSONResponse[struct{}](resp)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func (c *Client) OpenAuthGitHubSSH(ctx context.Context) (*websocket.Conn, error) {
 	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "auth", "github-ssh")
 	if err != nil {
@@ -544,6 +919,79 @@ func (c *Client) AuthGitHubOIDC(ctx context.Context, oidcToken string) (string,
 	return decodeJSONResponse[string](resp)
 }
 
+func (c *Client) AuthOIDC(ctx context.Context, oidcToken string) (string, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "auth", "oidc")
+	if err != nil {
+		return "", err
+	}
+
+	var body struct {
+		Token string `json:"token"`
+	}
+	body.Token = oidcToken
+	req, err := newJSONRequest(ctx, "POST", endpoint, body)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[string](resp)
+}
+
+func (c *Client) RefreshAuthToken(ctx context.Context, refreshToken string) (*APIAuthTokens, error) {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "auth", "refresh")
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	body.RefreshToken = refreshToken
+	req, err := newJSONRequest(ctx, "POST", endpoint, body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONResponse[*APIAuthTokens](resp)
+}
+
+func (c *Client) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "auth", "refresh", "revoke")
+	if err != nil {
+		return err
+	}
+
+	var body struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	body.RefreshToken = refreshToken
+	req, err := newJSONRequest(ctx, "POST", endpoint, body)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	_, err = decodeJSONResponse[struct{}](resp)
+	return err
+}
+
 func (c *Client) GetMe(ctx context.Context) (*APIUser, error) {
 	endpoint, err := url.JoinPath(c.endpoint, "api", "v1", "auth", "me")
 	if err != nil {
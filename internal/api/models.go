@@ -1,6 +1,9 @@
 package api
 
 import (
+	"time"
+
+	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/models"
 )
 
@@ -20,10 +23,42 @@ type APISite struct {
 	DeploymentName *string `json:"deploymentName"`
 }
 
+type APISiteActivation struct {
+	*models.SiteActivation
+	DeploymentName         *string `json:"deploymentName"`
+	PreviousDeploymentName *string `json:"previousDeploymentName"`
+}
+
+type APISiteAnalytics struct {
+	From         time.Time                `json:"from"`
+	To           time.Time                `json:"to"`
+	Requests     int64                    `json:"requests"`
+	Bytes        int64                    `json:"bytes"`
+	Hours        []APISiteAnalyticsHour   `json:"hours"`
+	TopPaths     []APISiteAnalyticsCounts `json:"topPaths"`
+	TopReferrers []APISiteAnalyticsCounts `json:"topReferrers"`
+}
+
+type APISiteAnalyticsHour struct {
+	Hour     time.Time        `json:"hour"`
+	Requests int64            `json:"requests"`
+	Bytes    int64            `json:"bytes"`
+	Statuses map[string]int64 `json:"statuses"`
+}
+
+type APISiteAnalyticsCounts struct {
+	Value    string `json:"value"`
+	Requests int64  `json:"requests"`
+	Bytes    int64  `json:"bytes"`
+}
+
 type APIDeployment struct {
 	*models.Deployment
 	SiteName *string `json:"siteName"`
 	URL      *string `json:"url"`
+
+	// MissingHashes is the content hashes to be uploaded; nil if unknown.
+	MissingHashes *[]string `json:"missingHashes,omitempty"`
 }
 
 type APIDomain struct {
@@ -35,8 +70,38 @@ type APIUser struct {
 	ID          string                `json:"id"`
 	Name        string                `json:"name"`
 	Credentials []models.CredentialID `json:"credentials"`
+	Access      config.AccessLevel    `json:"access,omitempty"`
 }
 
 type SitePatchRequest struct {
 	DeploymentName *string `json:"deploymentName,omitempty"`
 }
+
+type SiteRollbackRequest struct {
+	DeploymentName *string `json:"deploymentName,omitempty"`
+}
+
+type APITokenCreateRequest struct {
+	Name      string              `json:"name"`
+	AppID     *string             `json:"appID,omitempty"`
+	Access    *config.AccessLevel `json:"access,omitempty"`
+	ExpiresIn *string             `json:"expiresIn,omitempty"`
+}
+
+type SSHKeyAddRequest struct {
+	Name      string `json:"name"`
+	PublicKey string `json:"publicKey"`
+	// Signature is base64-encoded SSH signature of
+	// models.SSHKeyProofData, signed with the key.
+	Signature string `json:"signature"`
+}
+
+type APIAuthTokens struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refreshToken"`
+}
+
+type APITokenCreated struct {
+	*models.APIToken
+	Token string `json:"token"`
+}
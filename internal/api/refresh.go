@@ -0,0 +1,49 @@
+package api
+
+import (
+	"io"
+	"net/http"
+)
+
+// refreshTransport retries a request once with a refreshed token, if the
+// token attached by attachToken is rejected by server.
+type refreshTransport struct {
+	client *Client
+	base   http.RoundTripper
+}
+
+func (t *refreshTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(r)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	if t.client.RefreshFunc == nil || r.Header.Get("Authorization") == "" {
+		return resp, nil
+	}
+
+	var body io.ReadCloser
+	if r.Body != nil && r.Body != http.NoBody {
+		if r.GetBody == nil {
+			// Streamed body cannot be replayed.
+			return resp, nil
+		}
+		body, err = r.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+	}
+
+	token, err := t.client.RefreshFunc(r.Context())
+	if err != nil {
+		if body != nil {
+			body.Close()
+		}
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	retry := r.Clone(r.Context())
+	retry.Body = body
+	retry.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(retry)
+}
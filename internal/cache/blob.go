@@ -0,0 +1,343 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+	"github.com/oursky/pageship/internal/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	blobCacheMemory = "blob-memory"
+	blobCacheDisk   = "blob-disk"
+)
+
+// blobFetchTimeout bounds the fetch shared by coalesced requests, since it
+// is not cancelled with the requests.
+const blobFetchTimeout = 1 * time.Minute
+
+var ErrUnexpectedObjectSize = errors.New("unexpected object size")
+
+type BlobCacheConfig struct {
+	// MemorySize is the max total size of objects cached in memory.
+	MemorySize int64
+	// DiskDir is the directory of on-disk cache; disabled if empty.
+	DiskDir string
+	// DiskSize is the max total size of objects cached on disk.
+	DiskSize int64
+	// MaxObjectSize is the max size of single cached object; larger objects
+	// are always read from storage.
+	MaxObjectSize int64
+}
+
+// BlobCache caches content of immutable objects in storage, in memory and
+// optionally on disk. Concurrent fetches of same object are coalesced.
+type BlobCache struct {
+	conf   BlobCacheConfig
+	memory *sizedLRU[[]byte]
+	disk   *sizedLRU[struct{}]
+	group  singleflight.Group
+}
+
+func NewBlobCache(conf BlobCacheConfig) (*BlobCache, error) {
+	c := &BlobCache{conf: conf}
+
+	memory, err := newSizedLRU[[]byte](blobCacheMemory, conf.MemorySize, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.memory = memory
+
+	if conf.DiskDir != "" {
+		disk, err := newSizedLRU[struct{}](blobCacheDisk, conf.DiskSize, func(key string) {
+			os.Remove(c.diskPath(key))
+		})
+		if err != nil {
+			return nil, err
+		}
+		c.disk = disk
+
+		if err := c.indexDisk(); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// indexDisk indexes objects cached on disk by previous runs, and removes
+// partially written files.
+func (c *BlobCache) indexDisk() error {
+	if err := os.MkdirAll(c.conf.DiskDir, 0750); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(c.conf.DiskDir)
+	if err != nil {
+		return err
+	}
+
+	var infos []fs.FileInfo
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if strings.HasPrefix(entry.Name(), ".") {
+			os.Remove(filepath.Join(c.conf.DiskDir, entry.Name()))
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		infos = append(infos, info)
+	}
+
+	// Add in order of access time approximated by modification time, so that
+	// least recently used objects are evicted first.
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].ModTime().Before(infos[j].ModTime())
+	})
+	for _, info := range infos {
+		if !c.disk.add(info.Name(), struct{}{}, info.Size()) {
+			os.Remove(c.diskPath(info.Name()))
+		}
+	}
+	return nil
+}
+
+func (c *BlobCache) diskPath(name string) string {
+	return filepath.Join(c.conf.DiskDir, name)
+}
+
+func diskName(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// Open returns the content of object with key, fetching from storage if not
+// cached. The key must identify immutable content, e.g. content hash.
+func (c *BlobCache) Open(
+	ctx context.Context,
+	key string,
+	size int64,
+	fetch func(ctx context.Context) (io.ReadSeekCloser, error),
+) (io.ReadSeekCloser, error) {
+	if size > c.conf.MaxObjectSize {
+		return fetch(ctx)
+	}
+
+	if data, ok := c.memory.get(key); ok {
+		return bytesReader(data), nil
+	}
+
+	// Stop waiting when the request is cancelled; the shared fetch continues
+	// for other requests.
+	ch := c.group.DoChan(key, func() (any, error) {
+		return c.load(ctx, key, size, fetch)
+	})
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-ch:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return bytesReader(result.Val.([]byte)), nil
+	}
+}
+
+func (c *BlobCache) load(
+	ctx context.Context,
+	key string,
+	size int64,
+	fetch func(ctx context.Context) (io.ReadSeekCloser, error),
+) ([]byte, error) {
+	// May be loaded by coalesced requests just finished.
+	if data, ok := c.memory.peek(key); ok {
+		return data, nil
+	}
+
+	if data, ok := c.loadDisk(key); ok {
+		c.memory.add(key, data, int64(len(data)))
+		return data, nil
+	}
+
+	// Fetch is shared by coalesced requests; do not abort it when the
+	// request initiating it is cancelled.
+	ctx, cancel := context.WithTimeout(detachedContext{ctx}, blobFetchTimeout)
+	defer cancel()
+
+	reader, err := fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(io.LimitReader(reader, size+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != size {
+		return nil, ErrUnexpectedObjectSize
+	}
+
+	c.memory.add(key, data, int64(len(data)))
+	c.storeDisk(key, data)
+	return data, nil
+}
+
+func (c *BlobCache) loadDisk(key string) ([]byte, bool) {
+	if c.disk == nil {
+		return nil, false
+	}
+
+	name := diskName(key)
+	if _, ok := c.disk.get(name); !ok {
+		return nil, false
+	}
+
+	data, err := os.ReadFile(c.diskPath(name))
+	if err != nil {
+		c.disk.remove(name)
+		return nil, false
+	}
+
+	now := time.Now()
+	os.Chtimes(c.diskPath(name), now, now)
+	return data, true
+}
+
+func (c *BlobCache) storeDisk(key string, data []byte) {
+	if c.disk == nil || int64(len(data)) > c.conf.DiskSize {
+		return
+	}
+
+	name := diskName(key)
+	file, err := os.CreateTemp(c.conf.DiskDir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	_, err = file.Write(data)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), c.diskPath(name))
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return
+	}
+
+	c.disk.add(name, struct{}{}, int64(len(data)))
+}
+
+// sizedLRU is a LRU cache bounded by total size of values.
+type sizedLRU[T any] struct {
+	name  string
+	limit int64
+
+	m     sync.Mutex
+	size  int64
+	sizes map[string]int64
+	lru   *simplelru.LRU[string, T]
+}
+
+func newSizedLRU[T any](name string, limit int64, onEvict func(key string)) (*sizedLRU[T], error) {
+	c := &sizedLRU[T]{name: name, limit: limit, sizes: make(map[string]int64)}
+
+	lru, err := simplelru.NewLRU(math.MaxInt, func(key string, value T) {
+		c.size -= c.sizes[key]
+		delete(c.sizes, key)
+		if onEvict != nil {
+			onEvict(key)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.lru = lru
+
+	return c, nil
+}
+
+func (c *sizedLRU[T]) peek(key string) (value T, ok bool) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.lru.Get(key)
+}
+
+func (c *sizedLRU[T]) get(key string) (value T, ok bool) {
+	if c.limit <= 0 {
+		return
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	value, ok = c.lru.Get(key)
+	if ok {
+		metrics.CacheRequests.WithLabelValues(c.name, "hit").Inc()
+	} else {
+		metrics.CacheRequests.WithLabelValues(c.name, "miss").Inc()
+	}
+	return
+}
+
+func (c *sizedLRU[T]) add(key string, value T, size int64) bool {
+	if size > c.limit {
+		return false
+	}
+
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.size += size - c.sizes[key]
+	c.sizes[key] = size
+	c.lru.Add(key, value)
+	for c.size > c.limit {
+		c.lru.RemoveOldest()
+	}
+	metrics.CacheSize.WithLabelValues(c.name).Set(float64(c.size))
+	return true
+}
+
+func (c *sizedLRU[T]) remove(key string) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.lru.Remove(key)
+	metrics.CacheSize.WithLabelValues(c.name).Set(float64(c.size))
+}
+
+type bytesFile struct{ *bytes.Reader }
+
+func (bytesFile) Close() error { return nil }
+
+func bytesReader(data []byte) io.ReadSeekCloser {
+	return bytesFile{Reader: bytes.NewReader(data)}
+}
+
+// detachedContext keeps values of parent context, without its cancellation.
+type detachedContext struct{ parent context.Context }
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any         { return c.parent.Value(key) }
@@ -0,0 +1,185 @@
+package cache_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/cache"
+	"github.com/stretchr/testify/assert"
+)
+
+type blobFetcher struct {
+	count atomic.Int32
+	delay time.Duration
+}
+
+func (f *blobFetcher) fetch(data string) func(ctx context.Context) (io.ReadSeekCloser, error) {
+	return func(ctx context.Context) (io.ReadSeekCloser, error) {
+		f.count.Add(1)
+		time.Sleep(f.delay)
+		return nopCloser{bytes.NewReader([]byte(data))}, nil
+	}
+}
+
+type nopCloser struct{ io.ReadSeeker }
+
+func (nopCloser) Close() error { return nil }
+
+func readBlob(t *testing.T, c *cache.BlobCache, key string, data string, fetcher *blobFetcher) string {
+	reader, err := c.Open(context.Background(), key, int64(len(data)), fetcher.fetch(data))
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	defer reader.Close()
+
+	content, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	return string(content)
+}
+
+func TestBlobCacheMemory(t *testing.T) {
+	c, err := cache.NewBlobCache(cache.BlobCacheConfig{MemorySize: 10, MaxObjectSize: 8})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	f := &blobFetcher{}
+	assert.Equal(t, "aaaa", readBlob(t, c, "a", "aaaa", f))
+	assert.Equal(t, "aaaa", readBlob(t, c, "a", "aaaa", f))
+	assert.Equal(t, int32(1), f.count.Load())
+
+	// Evicts least recently used object when full
+	assert.Equal(t, "bbbb", readBlob(t, c, "b", "bbbb", f))
+	assert.Equal(t, "cccc", readBlob(t, c, "c", "cccc", f))
+	assert.Equal(t, int32(3), f.count.Load())
+	assert.Equal(t, "cccc", readBlob(t, c, "c", "cccc", f))
+	assert.Equal(t, "bbbb", readBlob(t, c, "b", "bbbb", f))
+	assert.Equal(t, int32(3), f.count.Load())
+	assert.Equal(t, "aaaa", readBlob(t, c, "a", "aaaa", f))
+	assert.Equal(t, int32(4), f.count.Load())
+
+	// Large objects are not cached
+	assert.Equal(t, "ddddddddd", readBlob(t, c, "d", "ddddddddd", f))
+	assert.Equal(t, "ddddddddd", readBlob(t, c, "d", "ddddddddd", f))
+	assert.Equal(t, int32(6), f.count.Load())
+}
+
+func TestBlobCacheCoalescing(t *testing.T) {
+	c, err := cache.NewBlobCache(cache.BlobCacheConfig{MemorySize: 100, MaxObjectSize: 100})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	f := &blobFetcher{delay: 50 * time.Millisecond}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "hello", readBlob(t, c, "hello", "hello", f))
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), f.count.Load())
+}
+
+func TestBlobCacheDisk(t *testing.T) {
+	dir := t.TempDir()
+	conf := cache.BlobCacheConfig{DiskDir: dir, DiskSize: 10, MaxObjectSize: 8}
+
+	c, err := cache.NewBlobCache(conf)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	f := &blobFetcher{}
+	assert.Equal(t, "aaaa", readBlob(t, c, "a", "aaaa", f))
+	assert.Equal(t, "bbbb", readBlob(t, c, "b", "bbbb", f))
+	assert.Equal(t, "aaaa", readBlob(t, c, "a", "aaaa", f))
+	assert.Equal(t, int32(2), f.count.Load())
+
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 2)
+
+	// Reuses cached objects after restart
+	c, err = cache.NewBlobCache(conf)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "aaaa", readBlob(t, c, "a", "aaaa", f))
+	assert.Equal(t, "bbbb", readBlob(t, c, "b", "bbbb", f))
+	assert.Equal(t, int32(2), f.count.Load())
+
+	// Evicted objects are removed from disk
+	assert.Equal(t, "cccc", readBlob(t, c, "c", "cccc", f))
+	assert.Equal(t, int32(3), f.count.Load())
+	entries, _ = os.ReadDir(dir)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "bbbb", readBlob(t, c, "b", "bbbb", f))
+	assert.Equal(t, int32(3), f.count.Load())
+}
+
+func TestBlobCacheSize(t *testing.T) {
+	c, err := cache.NewBlobCache(cache.BlobCacheConfig{MemorySize: 100, MaxObjectSize: 100})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	f := &blobFetcher{}
+	_, err = c.Open(context.Background(), "a", 2, f.fetch("aaaa"))
+	assert.ErrorIs(t, err, cache.ErrUnexpectedObjectSize)
+	_, err = c.Open(context.Background(), "b", 8, f.fetch("bbbb"))
+	assert.ErrorIs(t, err, cache.ErrUnexpectedObjectSize)
+
+	// Objects with unexpected size are not cached
+	assert.Equal(t, "aaaa", readBlob(t, c, "a", "aaaa", f))
+	assert.Equal(t, int32(3), f.count.Load())
+}
+
+func TestBlobCacheCancel(t *testing.T) {
+	c, err := cache.NewBlobCache(cache.BlobCacheConfig{MemorySize: 100, MaxObjectSize: 100})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	var count atomic.Int32
+	release := make(chan struct{})
+	fetch := func(ctx context.Context) (io.ReadSeekCloser, error) {
+		count.Add(1)
+		<-release
+		return nopCloser{bytes.NewReader([]byte("hello"))}, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := c.Open(ctx, "hello", 5, fetch)
+		done <- err
+	}()
+
+	// Cancelled request stops waiting for stalled fetch
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	select {
+	case err := <-done:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("request not cancelled")
+	}
+
+	close(release)
+	reader, err := c.Open(context.Background(), "hello", 5, fetch)
+	if assert.NoError(t, err) {
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, "hello", string(content))
+	}
+	assert.Equal(t, int32(1), count.Load())
+}
@@ -1,10 +1,12 @@
 package cache
 
 import (
+	"context"
 	"sync"
 	"time"
 
 	"github.com/hashicorp/golang-lru/v2/simplelru"
+	"github.com/oursky/pageship/internal/metrics"
 )
 
 const (
@@ -13,19 +15,20 @@ const (
 )
 
 type Cache[T any] struct {
+	name  string
 	m     sync.Mutex
 	ttl   time.Duration
 	cache *simplelru.LRU[string, *TTLCell[T]]
-	load  func(id string) (T, error)
+	load  func(ctx context.Context, id string) (T, error)
 }
 
-func NewCache[T any](size int, ttl time.Duration, load func(id string) (T, error)) (*Cache[T], error) {
+func NewCache[T any](name string, size int, ttl time.Duration, load func(ctx context.Context, id string) (T, error)) (*Cache[T], error) {
 	cache, err := simplelru.NewLRU[string, *TTLCell[T]](cacheSize, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Cache[T]{cache: cache, ttl: ttl, load: load}, nil
+	return &Cache[T]{name: name, cache: cache, ttl: ttl, load: load}, nil
 }
 
 func (c *Cache[T]) getCell(id string) *TTLCell[T] {
@@ -42,7 +45,19 @@ func (c *Cache[T]) getCell(id string) *TTLCell[T] {
 	return ce
 }
 
-func (c *Cache[T]) Load(id string) (T, error) {
+func (c *Cache[T]) Purge() {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.cache.Purge()
+}
+
+func (c *Cache[T]) Load(ctx context.Context, id string) (T, error) {
 	cell := c.getCell(id)
-	return cell.Load()
+	if value, err, ok := cell.loadCached(); ok {
+		metrics.CacheRequests.WithLabelValues(c.name, "hit").Inc()
+		return value, err
+	}
+	metrics.CacheRequests.WithLabelValues(c.name, "miss").Inc()
+	return cell.loadNew(ctx)
 }
@@ -2,7 +2,9 @@ package command
 
 import (
 	"context"
+	"time"
 
+	"github.com/oursky/pageship/internal/metrics"
 	"github.com/robfig/cron/v3"
 	"go.uber.org/zap"
 )
@@ -33,16 +35,23 @@ func (s *CronRunner) Run(ctx context.Context) error {
 		jobLogger := s.Logger.Named(jobName)
 		finalJob := job
 		c.AddFunc(schedule, func() {
+			start := time.Now()
+			result := "panic"
 			defer func() {
 				if err := recover(); err != nil {
 					if err != nil {
 						jobLogger.Error("job panic", zap.Any("error", err))
 					}
 				}
+				metrics.CronJobDuration.WithLabelValues(jobName).Observe(time.Since(start).Seconds())
+				metrics.CronJobRuns.WithLabelValues(jobName, result).Inc()
 			}()
 			err := finalJob.Run(ctx, jobLogger)
 			if err != nil {
 				jobLogger.Error("job failed", zap.Error(err))
+				result = "failure"
+			} else {
+				result = "success"
 			}
 		})
 	}
@@ -33,8 +33,13 @@ func LoadACL(r io.Reader) (ACL, error) {
 type ACLSubjectRule struct {
 	PageshipUser            string `json:"pageshipUser,omitempty" pageship:"max=100"`
 	GitHubUser              string `json:"githubUser,omitempty" pageship:"max=100"`
+	GitHubOrg               string `json:"githubOrg,omitempty" pageship:"max=100"`
 	GitHubRepositoryActions string `json:"gitHubRepositoryActions,omitempty" pageship:"max=100"`
 	IpRange                 string `json:"ipRange,omitempty" pageship:"omitempty,max=100,cidr"`
+	BasicAuth               string `json:"basicAuth,omitempty" pageship:"max=100"`
+	GitLabProject           string `json:"gitlabProject,omitempty" pageship:"max=200"`
+	OIDC                    string `json:"oidc,omitempty" pageship:"max=200"`
+	SSHPrincipal            string `json:"sshPrincipal,omitempty" pageship:"max=100"`
 }
 
 func (c *ACLSubjectRule) String() string {
@@ -43,10 +48,20 @@ func (c *ACLSubjectRule) String() string {
 		return fmt.Sprintf("pageshipUser:%s", c.PageshipUser)
 	case c.GitHubUser != "":
 		return fmt.Sprintf("githubUser:%s", c.GitHubUser)
+	case c.GitHubOrg != "":
+		return fmt.Sprintf("githubOrg:%s", c.GitHubOrg)
 	case c.GitHubRepositoryActions != "":
 		return fmt.Sprintf("gitHubRepositoryActions:%s", c.GitHubRepositoryActions)
 	case c.IpRange != "":
 		return fmt.Sprintf("ipRange:%s", c.IpRange)
+	case c.BasicAuth != "":
+		return fmt.Sprintf("basicAuth:%s", c.BasicAuth)
+	case c.GitLabProject != "":
+		return fmt.Sprintf("gitlabProject:%s", c.GitLabProject)
+	case c.OIDC != "":
+		return fmt.Sprintf("oidc:%s", c.OIDC)
+	case c.SSHPrincipal != "":
+		return fmt.Sprintf("sshPrincipal:%s", c.SSHPrincipal)
 	}
 	return "<unknown>"
 }
@@ -13,6 +13,8 @@ type AppConfig struct {
 	Deployments AppDeploymentsConfig `json:"deployments"`
 	Team        []*AccessRule        `json:"team" pageship:"max=100,dive,required"`
 	Domains     []AppDomainConfig    `json:"domains" pageship:"max=10,unique=Domain,unique=Site,dive,required"`
+	BasicAuth   []AppBasicAuthConfig `json:"basicAuth,omitempty" pageship:"max=100,unique=Username,dive,required"`
+	SSO         *AppSSOConfig        `json:"sso,omitempty" pageship:"omitempty"`
 }
 
 func DefaultAppConfig() AppConfig {
@@ -35,6 +35,9 @@ func (l AccessLevel) CanAccess(a AccessLevel) bool {
 type AccessRule struct {
 	ACLSubjectRule `mapstructure:",squash"`
 	Access         AccessLevel `json:"access" pageship:"omitempty,accessLevel"`
+	// Managed indicates the rule is added through team user management
+	// API, and is kept when app config is replaced.
+	Managed bool `json:"managed,omitempty" mapstructure:"-"`
 }
 
 func (r *AccessRule) SetDefaults() {
@@ -0,0 +1,13 @@
+package config
+
+import "golang.org/x/crypto/bcrypt"
+
+type AppBasicAuthConfig struct {
+	Username     string `json:"username" pageship:"required,max=100,excludesall=:"`
+	PasswordHash string `json:"passwordHash" pageship:"required,max=100,bcryptHash"`
+}
+
+func (c *AppBasicAuthConfig) CheckPassword(password string) bool {
+	err := bcrypt.CompareHashAndPassword([]byte(c.PasswordHash), []byte(password))
+	return err == nil
+}
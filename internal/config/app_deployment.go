@@ -1,12 +1,29 @@
 package config
 
+const defaultKeepPrevious = 3
+
 type AppDeploymentsConfig struct {
 	Access ACL    `json:"access" pageship:"omitempty"`
 	TTL    string `json:"ttl" pageship:"omitempty,duration"`
+
+	KeepPrevious *int `json:"keepPrevious,omitempty" pageship:"omitempty,min=0,max=100"`
 }
 
 func (c *AppDeploymentsConfig) SetDefaults() {
 	if c.TTL == "" {
 		c.TTL = "24h"
 	}
+	if c.KeepPrevious == nil {
+		keepPrevious := defaultKeepPrevious
+		c.KeepPrevious = &keepPrevious
+	}
+}
+
+// KeepPreviousCount returns number of previously active deployments to keep;
+// zero keeps none.
+func (c *AppDeploymentsConfig) KeepPreviousCount() int {
+	if c.KeepPrevious == nil {
+		return defaultKeepPrevious
+	}
+	return *c.KeepPrevious
 }
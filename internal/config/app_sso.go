@@ -0,0 +1,9 @@
+package config
+
+type AppSSOConfig struct {
+	Issuer          string   `json:"issuer" pageship:"required,url,max=200"`
+	ClientID        string   `json:"clientID" pageship:"required,max=200"`
+	Scopes          []string `json:"scopes,omitempty" pageship:"max=10,dive,required,max=100"`
+	GitHubUserClaim string   `json:"githubUserClaim,omitempty" pageship:"max=100"`
+	GitHubOrgsClaim string   `json:"githubOrgsClaim,omitempty" pageship:"max=100"`
+}
@@ -23,6 +23,7 @@ type ClientConfig struct {
 	GitHubUsername string `json:"githubUsername,omitempty"`
 	SSHKeyFile     string `json:"sshKeyFile,omitempty"`
 	AuthToken      string `json:"authToken,omitempty"`
+	RefreshToken   string `json:"refreshToken,omitempty"`
 }
 
 func LoadClientConfig() (*ClientConfig, error) {
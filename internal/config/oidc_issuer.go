@@ -0,0 +1,43 @@
+package config
+
+import (
+	"io"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// OIDCIssuerConfig specifies a trusted OIDC issuer of CI workload identity
+// tokens, and how token claims are mapped to credentials.
+type OIDCIssuerConfig struct {
+	Name        string                 `json:"name" pageship:"required,dnsLabel"`
+	Issuer      string                 `json:"issuer" pageship:"required,url"`
+	JWKSURL     string                 `json:"jwksURL,omitempty" pageship:"omitempty,url"`
+	Audience    string                 `json:"audience,omitempty"`
+	Credentials []OIDCCredentialConfig `json:"credentials" pageship:"min=1,max=10,dive,required"`
+}
+
+type OIDCCredentialConfig struct {
+	Kind  string `json:"kind" pageship:"required,oneof=gitlab-project oidc"`
+	Claim string `json:"claim" pageship:"required,max=100"`
+}
+
+func LoadOIDCIssuers(r io.Reader) ([]OIDCIssuerConfig, error) {
+	var m map[string]any
+	if err := toml.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Issuers []OIDCIssuerConfig `json:"issuers" pageship:"unique=Name,unique=Issuer,dive,required"`
+	}
+	if err := mapstructure.Decode(m, &file); err != nil {
+		return nil, err
+	}
+
+	if err := validate.Struct(file); err != nil {
+		return nil, err
+	}
+
+	return file.Issuers, nil
+}
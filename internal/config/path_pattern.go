@@ -0,0 +1,55 @@
+package config
+
+import (
+	"path"
+	"strings"
+)
+
+// ValidatePathPattern checks the path pattern is absolute and well-formed.
+func ValidatePathPattern(pattern string) bool {
+	if !strings.HasPrefix(pattern, "/") {
+		return false
+	}
+	for _, seg := range splitPath(pattern) {
+		if seg == "**" {
+			continue
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchPathPattern matches URL path against a glob pattern. In addition to
+// path.Match syntax, a `**` segment matches zero or more path segments.
+func MatchPathPattern(pattern string, urlpath string) bool {
+	return matchSegments(splitPath(pattern), splitPath(urlpath))
+}
+
+func splitPath(p string) []string {
+	return strings.Split(strings.TrimPrefix(p, "/"), "/")
+}
+
+func matchSegments(pattern []string, segs []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(pattern[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segs[0]); !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		segs = segs[1:]
+	}
+	return len(segs) == 0
+}
@@ -0,0 +1,81 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchPathPattern(t *testing.T) {
+	cases := []struct {
+		pattern string
+		path    string
+		match   bool
+	}{
+		{"/", "/", true},
+		{"/", "/index.html", false},
+		{"/*", "/index.html", true},
+		{"/*", "/assets/main.js", false},
+		{"/*.html", "/index.html", true},
+		{"/*.html", "/index.htm", false},
+		{"/assets/**", "/assets/main.js", true},
+		{"/assets/**", "/assets/js/main.js", true},
+		{"/assets/**", "/assets/", true},
+		{"/assets/**", "/static/main.js", false},
+		{"/**/*.js", "/main.js", true},
+		{"/**/*.js", "/assets/js/main.js", true},
+		{"/**/*.js", "/assets/js/main.css", false},
+		{"/**", "/", true},
+		{"/**", "/a/b/c", true},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.match, config.MatchPathPattern(c.pattern, c.path), "%s ~ %s", c.pattern, c.path)
+	}
+}
+
+func TestValidatePathPattern(t *testing.T) {
+	assert.True(t, config.ValidatePathPattern("/"))
+	assert.True(t, config.ValidatePathPattern("/assets/**"))
+	assert.True(t, config.ValidatePathPattern("/[a-z]*.html"))
+	assert.False(t, config.ValidatePathPattern("assets/**"))
+	assert.False(t, config.ValidatePathPattern("/[a-z.html"))
+}
+
+func TestValidateSiteHeaders(t *testing.T) {
+	conf := config.DefaultSiteConfig()
+	conf.Headers = []config.SiteHeadersConfig{{
+		Path:   "/**",
+		Set:    map[string]string{"X-Frame-Options": "DENY"},
+		Append: map[string]string{"Vary": "Origin"},
+		Remove: []string{"ETag"},
+	}}
+	assert.NoError(t, config.ValidateSiteConfig(&conf))
+
+	conf.Headers[0].Set = map[string]string{"X Frame Options": "DENY"}
+	assert.Error(t, config.ValidateSiteConfig(&conf))
+
+	conf.Headers[0].Set = nil
+	conf.Headers[0].Path = "*.html"
+	assert.Error(t, config.ValidateSiteConfig(&conf))
+}
+
+func TestValidateSiteRedirects(t *testing.T) {
+	conf := config.DefaultSiteConfig()
+	conf.Redirects = []config.SiteRedirectConfig{
+		{From: "/blog/:slug", To: "/posts/:slug"},
+		{From: "/docs/*", To: "https://docs.example.com/:splat", Status: 302},
+		{From: "/app/*", To: "/index.html", Status: 200},
+	}
+	assert.NoError(t, config.ValidateSiteConfig(&conf))
+
+	conf.Redirects = []config.SiteRedirectConfig{{From: "/docs/*/intro", To: "/"}}
+	assert.Error(t, config.ValidateSiteConfig(&conf))
+
+	conf.Redirects = []config.SiteRedirectConfig{{From: "/docs", To: "/", Status: 404}}
+	assert.Error(t, config.ValidateSiteConfig(&conf))
+
+	conf.Redirects = []config.SiteRedirectConfig{{From: "/docs", To: "https://example.com", Status: 200}}
+	assert.Error(t, config.ValidateSiteConfig(&conf))
+}
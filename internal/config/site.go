@@ -5,8 +5,13 @@ const SiteConfigName = "pageship"
 const DefaultSite = "main"
 
 type SiteConfig struct {
-	Public string `json:"public" pageship:"required"`
-	Access ACL    `json:"access" pageship:"omitempty"`
+	Public    string               `json:"public" pageship:"required"`
+	Access    ACL                  `json:"access" pageship:"omitempty"`
+	Headers   []SiteHeadersConfig  `json:"headers,omitempty" pageship:"max=50,dive"`
+	Redirects []SiteRedirectConfig `json:"redirects,omitempty" pageship:"max=500,dive"`
+	Cache     []SiteCacheConfig    `json:"cache,omitempty" pageship:"max=50,dive"`
+	NotFound  string               `json:"notFound,omitempty" pageship:"omitempty,max=200"`
+	NoSPA     bool                 `json:"noSPA,omitempty"`
 }
 
 func DefaultSiteConfig() SiteConfig {
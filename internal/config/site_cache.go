@@ -0,0 +1,30 @@
+package config
+
+const (
+	CacheControlImmutable = "immutable"
+
+	cacheControlImmutableValue = "public, max-age=31536000, immutable"
+)
+
+type SiteCacheConfig struct {
+	Path    string `json:"path" pageship:"required,max=200,pathPattern"`
+	Control string `json:"control" pageship:"required,max=200"`
+}
+
+func (c *SiteCacheConfig) Value() string {
+	if c.Control == CacheControlImmutable {
+		return cacheControlImmutableValue
+	}
+	return c.Control
+}
+
+// ResolveCacheControl returns the Cache-Control value of first matching cache
+// rule of the path.
+func (c *SiteConfig) ResolveCacheControl(urlpath string) (string, bool) {
+	for _, rule := range c.Cache {
+		if MatchPathPattern(rule.Path, urlpath) {
+			return rule.Value(), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,8 @@
+package config
+
+type SiteHeadersConfig struct {
+	Path   string            `json:"path" pageship:"required,max=200,pathPattern"`
+	Set    map[string]string `json:"set,omitempty" pageship:"max=50,dive,keys,headerName,endkeys,max=1000"`
+	Append map[string]string `json:"append,omitempty" pageship:"max=50,dive,keys,headerName,endkeys,max=1000"`
+	Remove []string          `json:"remove,omitempty" pageship:"max=50,dive,headerName"`
+}
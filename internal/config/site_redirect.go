@@ -0,0 +1,53 @@
+package config
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type SiteRedirectConfig struct {
+	From   string            `json:"from" pageship:"required,max=200,redirectPath"`
+	To     string            `json:"to" pageship:"required,max=1000"`
+	Status int               `json:"status,omitempty" pageship:"omitempty,oneof=200 301 302 307 308"`
+	Query  map[string]string `json:"query,omitempty" pageship:"max=20"`
+	Force  bool              `json:"force,omitempty"`
+}
+
+func (c *SiteRedirectConfig) StatusCode() int {
+	if c.Status == 0 {
+		return http.StatusMovedPermanently
+	}
+	return c.Status
+}
+
+func (c *SiteRedirectConfig) IsRewrite() bool {
+	return c.StatusCode() == http.StatusOK
+}
+
+// ValidateRedirectPath checks the redirect source path is absolute, and
+// splat (`*`) is only used as the last segment.
+func ValidateRedirectPath(value string) bool {
+	if !strings.HasPrefix(value, "/") {
+		return false
+	}
+	segs := splitPath(value)
+	for i, seg := range segs {
+		if strings.Contains(seg, "*") && (seg != "*" || i != len(segs)-1) {
+			return false
+		}
+		if strings.HasPrefix(seg, ":") && len(seg) == 1 {
+			return false
+		}
+	}
+	return true
+}
+
+func validateSiteRedirect(sl validator.StructLevel) {
+	conf := sl.Current().Interface().(SiteRedirectConfig)
+	// Rewrites are served internally, so target must be a local path.
+	if conf.IsRewrite() && !strings.HasPrefix(conf.To, "/") {
+		sl.ReportError(conf.To, "To", "to", "rewritePath", "")
+	}
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"io"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pelletier/go-toml/v2"
+)
+
+const (
+	SSOIssuerKindOIDC   = "oidc"
+	SSOIssuerKindGitHub = "github"
+)
+
+// SSOIssuerConfig specifies an identity provider trusted for site visitor
+// single sign-on, and the secrets of clients registered at it.
+type SSOIssuerConfig struct {
+	Issuer        string            `json:"issuer" pageship:"required,url"`
+	Kind          string            `json:"kind,omitempty" pageship:"omitempty,oneof=oidc github"`
+	GitHubAPIURL  string            `json:"githubAPIURL,omitempty" pageship:"omitempty,url"`
+	ClientSecrets map[string]string `json:"clientSecrets,omitempty"`
+}
+
+func LoadSSOIssuers(r io.Reader) ([]SSOIssuerConfig, error) {
+	var m map[string]any
+	if err := toml.NewDecoder(r).Decode(&m); err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Issuers []SSOIssuerConfig `json:"issuers" pageship:"unique=Issuer,dive,required"`
+	}
+	if err := mapstructure.Decode(m, &file); err != nil {
+		return nil, err
+	}
+
+	if err := validate.Struct(file); err != nil {
+		return nil, err
+	}
+
+	for i := range file.Issuers {
+		issuer := &file.Issuers[i]
+		if issuer.Kind == "" {
+			issuer.Kind = SSOIssuerKindOIDC
+		}
+		if issuer.Kind == SSOIssuerKindGitHub && issuer.GitHubAPIURL == "" {
+			issuer.GitHubAPIURL = "https://api.github.com"
+		}
+	}
+
+	return file.Issuers, nil
+}
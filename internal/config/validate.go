@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/go-playground/validator/v10"
+	"golang.org/x/crypto/bcrypt"
 )
 
 var validate = validator.New()
@@ -28,10 +29,32 @@ func init() {
 		return ValidateDuration(value)
 	})
 
+	validate.RegisterValidation("pathPattern", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		return ValidatePathPattern(value)
+	})
+
+	validate.RegisterValidation("redirectPath", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		return ValidateRedirectPath(value)
+	})
+
+	validate.RegisterValidation("headerName", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		return ValidateHeaderName(value)
+	})
+
 	validate.RegisterValidation("accessLevel", func(fl validator.FieldLevel) bool {
 		value := fl.Field().String()
 		return AccessLevel(value).IsValid()
 	})
+
+	validate.RegisterValidation("bcryptHash", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		return ValidateBcryptHash(value)
+	})
+
+	validate.RegisterStructValidation(validateSiteRedirect, SiteRedirectConfig{})
 }
 
 // ref: RFC1123
@@ -49,6 +72,13 @@ func ValidateDNSLabel(value string) bool {
 	return true
 }
 
+// ref: RFC7230 token
+var headerName = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+func ValidateHeaderName(value string) bool {
+	return headerName.MatchString(value)
+}
+
 func ValidateDuration(value string) bool {
 	d, err := time.ParseDuration(value)
 	if err != nil {
@@ -60,6 +90,11 @@ func ValidateDuration(value string) bool {
 	return true
 }
 
+func ValidateBcryptHash(value string) bool {
+	_, err := bcrypt.Cost([]byte(value))
+	return err == nil
+}
+
 func ValidateAppConfig(conf *AppConfig) error {
 	return validate.Struct(conf)
 }
@@ -4,15 +4,22 @@ import (
 	"context"
 
 	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/storage"
 	"github.com/oursky/pageship/internal/time"
 	"go.uber.org/zap"
 )
 
+const cleanupBlobsBatchSize = 100
+
 type CleanupExpired struct {
 	Clock            time.Clock
 	Schedule         string
 	KeepAfterExpired time.Duration
-	DB               db.DB
+	// SiteAnalyticsRetention is the retention of site analytics; kept
+	// forever if zero.
+	SiteAnalyticsRetention time.Duration
+	DB                     db.DB
+	Storage                *storage.Storage
 }
 
 func (c *CleanupExpired) Name() string { return "cleanup-expired" }
@@ -27,13 +34,75 @@ func (c *CleanupExpired) Run(ctx context.Context, logger *zap.Logger) error {
 	now := clock.Now().UTC()
 	expireBefore := now.Add(-c.KeepAfterExpired)
 
-	return db.WithTx(ctx, c.DB, func(c db.Tx) error {
-		n, err := c.DeleteExpiredDeployments(ctx, now, expireBefore)
+	err := db.WithTx(ctx, c.DB, func(tx db.Tx) error {
+		n, err := tx.DeleteExpiredDeployments(ctx, now, expireBefore)
 		if err != nil {
 			return err
 		}
 
 		logger.Info("deleted expired deployment", zap.Int64("n", n))
+
+		// Used refresh tokens are kept until expiry to detect reuse.
+		n, err = tx.DeleteExpiredRefreshTokens(ctx, expireBefore)
+		if err != nil {
+			return err
+		}
+
+		logger.Info("deleted expired refresh token", zap.Int64("n", n))
+
+		if c.SiteAnalyticsRetention > 0 {
+			n, err = tx.DeleteSiteAnalytics(ctx, now.Add(-c.SiteAnalyticsRetention))
+			if err != nil {
+				return err
+			}
+
+			logger.Info("deleted expired site analytics", zap.Int64("n", n))
+		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	return c.cleanupBlobs(ctx, logger, expireBefore)
+}
+
+// cleanupBlobs deletes blobs no longer referenced by any deployment.
+func (c *CleanupExpired) cleanupBlobs(ctx context.Context, logger *zap.Logger, usedBefore time.Time) error {
+	var count int
+	var size int64
+	for {
+		blobs, err := c.DB.ListUnusedBlobs(ctx, usedBefore, cleanupBlobsBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, blob := range blobs {
+			// Delete DB record first, so that blob cannot be reused after
+			// object is deleted.
+			var deleted bool
+			err := db.WithTx(ctx, c.DB, func(tx db.Tx) (err error) {
+				deleted, err = tx.DeleteUnusedBlob(ctx, blob, usedBefore)
+				return
+			})
+			if err != nil {
+				return err
+			} else if !deleted {
+				continue
+			}
+
+			if err := c.Storage.Delete(ctx, blob.StorageKey); err != nil {
+				return err
+			}
+			count++
+			size += blob.Size
+		}
+
+		if len(blobs) < cleanupBlobsBatchSize {
+			break
+		}
+	}
+
+	logger.Info("deleted unused blobs", zap.Int("n", count), zap.Int64("size", size))
+	return nil
 }
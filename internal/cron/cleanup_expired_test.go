@@ -0,0 +1,189 @@
+package cron_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/cron"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/storage"
+	"github.com/oursky/pageship/testutil"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCleanupExpiredBlobs(t *testing.T) {
+	testutil.LoadTestEnvs()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := zap.NewNop()
+	now := time.Now().UTC()
+
+	store, err := storage.New(ctx, viper.GetString("storage-url"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.WithTestDB(func(database db.DB) {
+		setupDB(now, ctx, database)
+
+		createDeployment := func(name string, expireAt time.Time, hashes ...string) {
+			var files []models.FileEntry
+			for _, hash := range hashes {
+				files = append(files, models.FileEntry{Path: "/" + hash, Hash: hash, Size: 4})
+			}
+			d := models.NewDeployment(now.Add(-time.Hour*48), name, "test", "", &models.DeploymentMetadata{Files: files})
+			d.ExpireAt = &expireAt
+			err := db.WithTx(ctx, database, func(tx db.Tx) error {
+				if err := tx.CreateDeployment(ctx, d); err != nil {
+					return err
+				}
+				return tx.AddDeploymentBlobs(ctx, d, d.BlobHashes())
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		createBlob := func(hash string) *models.Blob {
+			blob := models.NewBlob(now.Add(-time.Hour*48), "test", hash, 4, "test-cleanup/")
+			if err := store.Upload(ctx, blob.StorageKey, bytes.NewBufferString(hash)); err != nil {
+				t.Fatal(err)
+			}
+			if err := database.CreateBlob(ctx, blob); err != nil {
+				t.Fatal(err)
+			}
+			return blob
+		}
+
+		shared := createBlob("shared")
+		expired := createBlob("expired")
+		createDeployment("old", now.Add(-time.Hour*36), "shared", "expired")
+		createDeployment("new", now.Add(time.Hour), "shared")
+
+		job := &cron.CleanupExpired{
+			KeepAfterExpired: time.Hour * 24,
+			DB:               database,
+			Storage:          store,
+		}
+		err := job.Run(ctx, logger)
+		assert.NoError(t, err)
+
+		_, err = store.OpenRead(ctx, expired.StorageKey)
+		assert.Error(t, err)
+		used, err := database.UseBlob(ctx, "test", "expired", now)
+		assert.NoError(t, err)
+		assert.False(t, used)
+
+		reader, err := store.OpenRead(ctx, shared.StorageKey)
+		if assert.NoError(t, err) {
+			reader.Close()
+		}
+		used, err = database.UseBlob(ctx, "test", "shared", now)
+		assert.NoError(t, err)
+		assert.True(t, used)
+	})
+}
+
+func TestCleanupExpiredRefreshTokens(t *testing.T) {
+	testutil.LoadTestEnvs()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := zap.NewNop()
+	now := time.Now().UTC()
+
+	store, err := storage.New(ctx, viper.GetString("storage-url"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.WithTestDB(func(database db.DB) {
+		data := setupDB(now, ctx, database)
+
+		createToken := func(expiresAt time.Time) (*models.RefreshToken, string) {
+			token, value := models.NewRefreshToken(now.Add(-time.Hour*72), data.userId, "", expiresAt)
+			if err := database.CreateRefreshToken(ctx, token); err != nil {
+				t.Fatal(err)
+			}
+			return token, value
+		}
+
+		_, expired := createToken(now.Add(-time.Hour * 36))
+		revokedToken, revoked := createToken(now.Add(time.Hour))
+		if err := database.RevokeRefreshTokenFamily(ctx, revokedToken.FamilyID, now.Add(-time.Hour*36)); err != nil {
+			t.Fatal(err)
+		}
+		_, recent := createToken(now.Add(-time.Hour))
+		_, alive := createToken(now.Add(time.Hour))
+
+		job := &cron.CleanupExpired{
+			KeepAfterExpired: time.Hour * 24,
+			DB:               database,
+			Storage:          store,
+		}
+		assert.NoError(t, job.Run(ctx, logger))
+
+		_, err := database.GetRefreshTokenByHash(ctx, models.HashAPIToken(expired))
+		assert.ErrorIs(t, err, models.ErrRefreshTokenNotFound)
+		_, err = database.GetRefreshTokenByHash(ctx, models.HashAPIToken(revoked))
+		assert.ErrorIs(t, err, models.ErrRefreshTokenNotFound)
+		_, err = database.GetRefreshTokenByHash(ctx, models.HashAPIToken(recent))
+		assert.NoError(t, err)
+		_, err = database.GetRefreshTokenByHash(ctx, models.HashAPIToken(alive))
+		assert.NoError(t, err)
+	})
+}
+
+func TestCleanupExpiredSiteAnalytics(t *testing.T) {
+	testutil.LoadTestEnvs()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := zap.NewNop()
+	now := time.Now().UTC()
+	hour := now.Truncate(time.Hour)
+
+	store, err := storage.New(ctx, viper.GetString("storage-url"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.WithTestDB(func(database db.DB) {
+		setupDB(now, ctx, database)
+
+		entry := func(hour time.Time) *models.SiteAnalytics {
+			return &models.SiteAnalytics{
+				AppID:     "test",
+				SiteName:  "main",
+				Hour:      hour,
+				Dimension: models.SiteAnalyticsTotal,
+				Requests:  1,
+			}
+		}
+		err := database.AddSiteAnalytics(ctx, []*models.SiteAnalytics{
+			entry(hour.Add(-time.Hour * 24 * 10)),
+			entry(hour.Add(-time.Hour)),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		job := &cron.CleanupExpired{
+			KeepAfterExpired:       time.Hour * 24,
+			SiteAnalyticsRetention: time.Hour * 24 * 7,
+			DB:                     database,
+			Storage:                store,
+		}
+		assert.NoError(t, job.Run(ctx, logger))
+
+		entries, err := database.ListSiteAnalytics(ctx, "test", "main", hour.Add(-time.Hour*24*30), hour.Add(time.Hour))
+		if assert.NoError(t, err) && assert.Len(t, entries, 1) {
+			assert.Equal(t, hour.Add(-time.Hour), entries[0].Hour.UTC())
+		}
+	})
+}
@@ -0,0 +1,99 @@
+package cron
+
+import (
+	"context"
+
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/storage"
+	"github.com/oursky/pageship/internal/time"
+	"go.uber.org/zap"
+)
+
+const cleanupStorageBatchSize = 100
+
+// CleanupStorage deletes stored objects of deleted deployments. Deployments
+// are marked after their objects are deleted, so interrupted runs would
+// resume from the unmarked deployments.
+type CleanupStorage struct {
+	Clock    time.Clock
+	Schedule string
+	DB       db.DB
+	Storage  *storage.Storage
+}
+
+func (c *CleanupStorage) Name() string { return "cleanup-storage" }
+
+func (c *CleanupStorage) CronSchedule() string { return c.Schedule }
+
+func (c *CleanupStorage) Run(ctx context.Context, logger *zap.Logger) error {
+	clock := c.Clock
+	if clock == nil {
+		clock = time.SystemClock
+	}
+
+	var deploymentCount, objectCount int
+	var size int64
+	defer func() {
+		logger.Info("deleted storage of deleted deployments",
+			zap.Int("deployments", deploymentCount),
+			zap.Int("objects", objectCount),
+			zap.Int64("size", size),
+		)
+	}()
+
+	for {
+		deployments, err := c.DB.ListDeploymentsPendingStorageCleanup(ctx, cleanupStorageBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, deployment := range deployments {
+			n, s, err := c.deleteObjects(ctx, deployment)
+			objectCount += n
+			size += s
+			if err != nil {
+				return err
+			}
+
+			err = c.DB.MarkDeploymentStorageDeleted(ctx, clock.Now().UTC(), deployment)
+			if err != nil {
+				return err
+			}
+			deploymentCount++
+		}
+
+		if len(deployments) < cleanupStorageBatchSize {
+			break
+		}
+	}
+
+	return nil
+}
+
+func (c *CleanupStorage) deleteObjects(ctx context.Context, deployment *models.Deployment) (count int, size int64, err error) {
+	prefix := deployment.StorageKeyPrefix + "/"
+	// Page through listing, instead of relisting from start, so that stale
+	// entries of deleted objects would not hide remaining objects.
+	var pageToken []byte
+	for {
+		objects, nextPageToken, err := c.Storage.ListPage(ctx, prefix, pageToken, cleanupStorageBatchSize)
+		if err != nil {
+			return count, size, err
+		}
+
+		for _, obj := range objects {
+			// Objects not found are treated as deleted by storage.
+			if err := c.Storage.Delete(ctx, obj.Key); err != nil {
+				return count, size, err
+			}
+			count++
+			size += obj.Size
+		}
+
+		if len(nextPageToken) == 0 {
+			return count, size, nil
+		}
+		pageToken = nextPageToken
+	}
+}
@@ -0,0 +1,74 @@
+package cron_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/cron"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/storage"
+	"github.com/oursky/pageship/testutil"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCleanupStorage(t *testing.T) {
+	testutil.LoadTestEnvs()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	logger := zap.NewNop()
+	now := time.Now().UTC()
+
+	store, err := storage.New(ctx, viper.GetString("storage-url"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.WithTestDB(func(database db.DB) {
+		setupDB(now, ctx, database)
+
+		createDeployment := func(name string, expireAt time.Time, files int) *models.Deployment {
+			d := models.NewDeployment(now.Add(-time.Hour*48), name, "test", "test-cleanup-storage/", &models.DeploymentMetadata{})
+			d.ExpireAt = &expireAt
+			if err := database.CreateDeployment(ctx, d); err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < files; i++ {
+				key := fmt.Sprintf("%s/%d.html", d.StorageKeyPrefix, i)
+				if err := store.Upload(ctx, key, bytes.NewBufferString(name)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			return d
+		}
+
+		// Objects of expired deployment span multiple listing pages.
+		expired := createDeployment("expired", now.Add(-time.Hour), 250)
+		alive := createDeployment("alive", now.Add(time.Hour), 1)
+
+		_, err := database.DeleteExpiredDeployments(ctx, now, now)
+		assert.NoError(t, err)
+
+		job := &cron.CleanupStorage{DB: database, Storage: store}
+		err = job.Run(ctx, logger)
+		assert.NoError(t, err)
+
+		objects, err := store.List(ctx, expired.StorageKeyPrefix+"/", 10)
+		assert.NoError(t, err)
+		assert.Empty(t, objects)
+
+		objects, err = store.List(ctx, alive.StorageKeyPrefix+"/", 10)
+		assert.NoError(t, err)
+		assert.Len(t, objects, 1)
+
+		pending, err := database.ListDeploymentsPendingStorageCleanup(ctx, 10)
+		assert.NoError(t, err)
+		assert.Empty(t, pending)
+	})
+}
@@ -42,18 +42,28 @@ type Tx interface {
 type DBQuery interface {
 	AppsDB
 	SitesDB
+	SiteActivationsDB
 	DeploymentsDB
+	BlobsDB
 	DomainsDB
 	DomainVerificationDB
 	UserDB
 	CertificateDB
+	AuditDB
+	APITokensDB
+	RefreshTokensDB
+	SSHKeysDB
+	SiteAnalyticsDB
 }
 
 type AppsDB interface {
 	CreateApp(ctx context.Context, app *models.App) error
 	GetApp(ctx context.Context, id string) (*models.App, error)
+	// GetAppForUpdate gets app, locking it until end of transaction.
+	GetAppForUpdate(ctx context.Context, id string) (*models.App, error)
 	ListApps(ctx context.Context, credentialIDs []models.CredentialID) ([]*models.App, error)
 	UpdateAppConfig(ctx context.Context, app *models.App) error
+	DeleteApp(ctx context.Context, id string, now time.Time) error
 }
 
 type SitesDB interface {
@@ -62,6 +72,16 @@ type SitesDB interface {
 	GetSiteInfo(ctx context.Context, appID string, id string) (*SiteInfo, error)
 	ListSitesInfo(ctx context.Context, appID string) ([]SiteInfo, error)
 	SetSiteDeployment(ctx context.Context, site *models.Site) error
+	DeleteSite(ctx context.Context, id string, now time.Time) error
+	DeleteAppSites(ctx context.Context, appID string, now time.Time) error
+}
+
+type SiteActivationsDB interface {
+	CreateSiteActivation(ctx context.Context, activation *models.SiteActivation) error
+	ListRecentSiteDeploymentIDs(ctx context.Context, siteID string, limit uint) ([]string, error)
+	IsDeploymentRecentlyActive(ctx context.Context, deployment *models.Deployment, limit uint) (bool, error)
+	ListSiteActivations(ctx context.Context, siteID string, limit uint) ([]SiteActivationInfo, error)
+	DeleteAppSiteActivations(ctx context.Context, appID string) error
 }
 
 type DeploymentsDB interface {
@@ -74,6 +94,31 @@ type DeploymentsDB interface {
 	GetDeploymentSiteNames(ctx context.Context, deployment *models.Deployment) ([]string, error)
 	SetDeploymentExpiry(ctx context.Context, deployment *models.Deployment) error
 	DeleteExpiredDeployments(ctx context.Context, now time.Time, expireBefore time.Time) (int64, error)
+	SetDeploymentBlobKeyPrefix(ctx context.Context, deployment *models.Deployment) error
+	ListDeploymentsWithoutBlobs(ctx context.Context, afterID string, limit uint) ([]*models.Deployment, error)
+	ListDeploymentsPendingStorageCleanup(ctx context.Context, limit uint) ([]*models.Deployment, error)
+	MarkDeploymentStorageDeleted(ctx context.Context, now time.Time, deployment *models.Deployment) error
+	DeleteDeployment(ctx context.Context, id string, now time.Time) error
+	DeleteAppDeployments(ctx context.Context, appID string, now time.Time) error
+}
+
+type BlobsDB interface {
+	CreateBlob(ctx context.Context, blob *models.Blob) error
+	UseBlob(ctx context.Context, appID string, hash string, now time.Time) (bool, error)
+	// UseBlobs marks the stored blobs as used, and returns their sizes by hash.
+	UseBlobs(ctx context.Context, appID string, hashes []string, now time.Time) (map[string]int64, error)
+	AddDeploymentBlobs(ctx context.Context, deployment *models.Deployment, hashes []string) error
+	ListUnusedBlobs(ctx context.Context, usedBefore time.Time, limit uint) ([]*models.Blob, error)
+	DeleteUnusedBlob(ctx context.Context, blob *models.Blob, usedBefore time.Time) (bool, error)
+	// DeleteAppBlobs marks blobs of app as deleted; the stored objects are
+	// deleted by cron jobs once unused.
+	DeleteAppBlobs(ctx context.Context, appID string, now time.Time) error
+}
+
+type AuditDB interface {
+	CreateAuditLog(ctx context.Context, log *models.AuditLog) error
+	ListAuditLogs(ctx context.Context, appID string, before string, limit uint) ([]*models.AuditLog, error)
+	DeleteAppAuditLogs(ctx context.Context, appID string, now time.Time) error
 }
 
 type DomainsDB interface {
@@ -104,6 +149,39 @@ type UserDB interface {
 	ListCredentialIDs(ctx context.Context, userID string) ([]models.CredentialID, error)
 }
 
+type APITokensDB interface {
+	CreateAPIToken(ctx context.Context, token *models.APIToken) error
+	GetAPITokenByHash(ctx context.Context, hash string) (*models.APIToken, error)
+	ListAPITokens(ctx context.Context, userID string) ([]*models.APIToken, error)
+	MarkAPITokenUsed(ctx context.Context, id string, now time.Time) error
+	RevokeAPIToken(ctx context.Context, userID string, id string, now time.Time) error
+}
+
+type RefreshTokensDB interface {
+	CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error
+	GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, id string, now time.Time) error
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string, now time.Time) error
+	DeleteExpiredRefreshTokens(ctx context.Context, expireBefore time.Time) (int64, error)
+}
+
+type SSHKeysDB interface {
+	CreateSSHKey(ctx context.Context, key *models.SSHKey) error
+	GetSSHKeyByFingerprint(ctx context.Context, fingerprint string) (*models.SSHKey, error)
+	ListSSHKeys(ctx context.Context, userID string) ([]*models.SSHKey, error)
+	MarkSSHKeyUsed(ctx context.Context, id string, now time.Time) error
+	DeleteSSHKey(ctx context.Context, userID string, id string, now time.Time) error
+}
+
+type SiteAnalyticsDB interface {
+	AddSiteAnalytics(ctx context.Context, entries []*models.SiteAnalytics) error
+	ListSiteAnalytics(ctx context.Context, appID string, siteName string, from time.Time, to time.Time) ([]*models.SiteAnalytics, error)
+	ListSiteAnalyticsValues(ctx context.Context, appID string, siteName string, hour time.Time, dimension models.SiteAnalyticsDimension) ([]string, error)
+	DeleteSiteAnalytics(ctx context.Context, before time.Time) (int64, error)
+	DeleteAppSiteAnalytics(ctx context.Context, appID string) error
+	DeleteSiteAnalyticsBySite(ctx context.Context, appID string, siteName string) error
+}
+
 type CertificateDB interface {
 	GetCertDataEntry(ctx context.Context, key string) (*models.CertDataEntry, error)
 	SetCertDataEntry(ctx context.Context, entry *models.CertDataEntry) error
@@ -126,3 +204,9 @@ type SiteInfo struct {
 	*models.Site
 	DeploymentName *string `db:"deployment_name"`
 }
+
+type SiteActivationInfo struct {
+	*models.SiteActivation
+	DeploymentName         *string `db:"deployment_name"`
+	PreviousDeploymentName *string `db:"previous_deployment_name"`
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oursky/pageship/internal/models"
+)
+
+func (q query[T]) CreateAPIToken(ctx context.Context, token *models.APIToken) error {
+	_, err := sqlx.NamedExecContext(ctx, q.ext, `
+		INSERT INTO api_token (id, created_at, updated_at, deleted_at, user_id, name, token_hash, app_id, access, expires_at, last_used_at)
+			VALUES (:id, :created_at, :updated_at, :deleted_at, :user_id, :name, :token_hash, :app_id, :access, :expires_at, :last_used_at)
+	`, token)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) GetAPITokenByHash(ctx context.Context, hash string) (*models.APIToken, error) {
+	var token models.APIToken
+	err := sqlx.GetContext(ctx, q.ext, &token, `
+		SELECT t.id, t.created_at, t.updated_at, t.deleted_at, t.user_id, t.name, t.token_hash, t.app_id, t.access, t.expires_at, t.last_used_at FROM api_token t
+			WHERE t.token_hash = $1 AND t.deleted_at IS NULL
+	`, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrAPITokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (q query[T]) ListAPITokens(ctx context.Context, userID string) ([]*models.APIToken, error) {
+	tokens := []*models.APIToken{}
+	err := sqlx.SelectContext(ctx, q.ext, &tokens, `
+		SELECT t.id, t.created_at, t.updated_at, t.deleted_at, t.user_id, t.name, t.token_hash, t.app_id, t.access, t.expires_at, t.last_used_at FROM api_token t
+			WHERE t.user_id = $1 AND t.deleted_at IS NULL
+			ORDER BY t.created_at, t.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (q query[T]) MarkAPITokenUsed(ctx context.Context, id string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE api_token SET last_used_at = $1 WHERE id = $2
+	`, now, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) RevokeAPIToken(ctx context.Context, userID string, id string, now time.Time) error {
+	result, err := q.ext.ExecContext(ctx, `
+		UPDATE api_token SET deleted_at = $1, updated_at = $1 WHERE user_id = $2 AND id = $3 AND deleted_at IS NULL
+	`, now, userID, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return models.ErrAPITokenNotFound
+	}
+
+	return nil
+}
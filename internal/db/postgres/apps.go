@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/oursky/pageship/internal/models"
@@ -27,7 +28,14 @@ func (q query[T]) CreateApp(ctx context.Context, app *models.App) error {
 	result, err := sqlx.NamedExecContext(ctx, q.ext, `
 		INSERT INTO app (id, created_at, updated_at, deleted_at, config, owner_user_id, credential_index)
 			VALUES (:id, :created_at, :updated_at, :deleted_at, :config, :owner_user_id, :credential_index)
-			ON CONFLICT (id) DO NOTHING
+			ON CONFLICT (id) DO UPDATE SET
+				created_at = excluded.created_at,
+				updated_at = excluded.updated_at,
+				deleted_at = excluded.deleted_at,
+				config = excluded.config,
+				owner_user_id = excluded.owner_user_id,
+				credential_index = excluded.credential_index
+				WHERE app.deleted_at IS NOT NULL
 	`, a)
 	if err != nil {
 		return err
@@ -87,6 +95,22 @@ func (q query[T]) GetApp(ctx context.Context, id string) (*models.App, error) {
 	return &app, nil
 }
 
+func (q query[T]) GetAppForUpdate(ctx context.Context, id string) (*models.App, error) {
+	var app models.App
+	err := sqlx.GetContext(ctx, q.ext, &app, `
+		SELECT id, created_at, updated_at, deleted_at, config, owner_user_id FROM app
+			WHERE id = $1 AND deleted_at IS NULL
+			FOR UPDATE
+	`, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrAppNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &app, nil
+}
+
 func (q query[T]) UpdateAppConfig(ctx context.Context, app *models.App) error {
 	indexKeys := app.CredentialIndexKeys()
 	index, err := json.Marshal(indexKeys)
@@ -103,3 +127,14 @@ func (q query[T]) UpdateAppConfig(ctx context.Context, app *models.App) error {
 
 	return nil
 }
+
+func (q query[T]) DeleteApp(ctx context.Context, id string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE app SET deleted_at = $1, updated_at = $2 WHERE id = $3
+	`, now, now, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
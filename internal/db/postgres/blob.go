@@ -0,0 +1,180 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oursky/pageship/internal/models"
+)
+
+const deploymentBlobBatchSize = 1000
+
+func (q query[T]) CreateBlob(ctx context.Context, blob *models.Blob) error {
+	_, err := sqlx.NamedExecContext(ctx, q.ext, `
+		INSERT INTO blob (app_id, hash, created_at, last_used_at, size, storage_key)
+			VALUES (:app_id, :hash, :created_at, :last_used_at, :size, :storage_key)
+			ON CONFLICT (app_id, hash) DO UPDATE SET last_used_at = excluded.last_used_at, deleted_at = NULL
+	`, blob)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) UseBlob(ctx context.Context, appID string, hash string, now time.Time) (bool, error) {
+	result, err := q.ext.ExecContext(ctx, `
+		UPDATE blob SET last_used_at = $1 WHERE app_id = $2 AND hash = $3 AND deleted_at IS NULL
+	`, now, appID, hash)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return n == 1, nil
+}
+
+func (q query[T]) UseBlobs(ctx context.Context, appID string, hashes []string, now time.Time) (map[string]int64, error) {
+	type usedBlob struct {
+		Hash string `db:"hash"`
+		Size int64  `db:"size"`
+	}
+
+	used := make(map[string]int64)
+	for len(hashes) > 0 {
+		n := len(hashes)
+		if n > deploymentBlobBatchSize {
+			n = deploymentBlobBatchSize
+		}
+
+		query, args, err := sqlx.In(`
+			UPDATE blob SET last_used_at = ? WHERE app_id = ? AND hash IN (?) AND deleted_at IS NULL
+				RETURNING hash, size
+		`, now, appID, hashes[:n])
+		if err != nil {
+			return nil, err
+		}
+
+		query = q.ext.Rebind(query)
+
+		var batch []usedBlob
+		err = sqlx.SelectContext(ctx, q.ext, &batch, query, args...)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range batch {
+			used[b.Hash] = b.Size
+		}
+
+		hashes = hashes[n:]
+	}
+
+	return used, nil
+}
+
+func (q query[T]) AddDeploymentBlobs(ctx context.Context, deployment *models.Deployment, hashes []string) error {
+	type deploymentBlob struct {
+		DeploymentID string `db:"deployment_id"`
+		AppID        string `db:"app_id"`
+		Hash         string `db:"hash"`
+	}
+
+	for len(hashes) > 0 {
+		n := len(hashes)
+		if n > deploymentBlobBatchSize {
+			n = deploymentBlobBatchSize
+		}
+
+		rows := make([]deploymentBlob, n)
+		for i, hash := range hashes[:n] {
+			rows[i] = deploymentBlob{DeploymentID: deployment.ID, AppID: deployment.AppID, Hash: hash}
+		}
+
+		_, err := sqlx.NamedExecContext(ctx, q.ext, `
+			INSERT INTO deployment_blob (deployment_id, app_id, hash)
+				VALUES (:deployment_id, :app_id, :hash)
+				ON CONFLICT DO NOTHING
+		`, rows)
+		if err != nil {
+			return err
+		}
+
+		hashes = hashes[n:]
+	}
+
+	return nil
+}
+
+func (q query[T]) ListUnusedBlobs(ctx context.Context, usedBefore time.Time, limit uint) ([]*models.Blob, error) {
+	var blobs []*models.Blob
+	err := sqlx.SelectContext(ctx, q.ext, &blobs, `
+		SELECT b.app_id, b.hash, b.created_at, b.last_used_at, b.size, b.storage_key FROM blob b
+			WHERE b.last_used_at < $1 AND NOT EXISTS (
+				SELECT 1 FROM deployment_blob r
+					JOIN deployment d ON (d.id = r.deployment_id AND d.deleted_at IS NULL)
+					WHERE r.app_id = b.app_id AND r.hash = b.hash
+			)
+			ORDER BY b.last_used_at
+			LIMIT $2
+	`, usedBefore, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return blobs, nil
+}
+
+func (q query[T]) DeleteUnusedBlob(ctx context.Context, blob *models.Blob, usedBefore time.Time) (bool, error) {
+	result, err := q.ext.ExecContext(ctx, `
+		DELETE FROM blob
+			WHERE app_id = $1 AND hash = $2 AND last_used_at < $3 AND NOT EXISTS (
+				SELECT 1 FROM deployment_blob r
+					JOIN deployment d ON (d.id = r.deployment_id AND d.deleted_at IS NULL)
+					WHERE r.app_id = blob.app_id AND r.hash = blob.hash
+			)
+	`, blob.AppID, blob.Hash, usedBefore)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if n != 1 {
+		return false, nil
+	}
+
+	// Remove references from deleted deployments
+	_, err = q.ext.ExecContext(ctx, `
+		DELETE FROM deployment_blob WHERE app_id = $1 AND hash = $2
+	`, blob.AppID, blob.Hash)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (q query[T]) DeleteAppBlobs(ctx context.Context, appID string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE blob SET deleted_at = $1 WHERE app_id = $2 AND deleted_at IS NULL
+	`, now, appID)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.ext.ExecContext(ctx, `
+		DELETE FROM deployment_blob WHERE app_id = $1
+	`, appID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -8,6 +8,7 @@ import (
 	_ "github.com/jackc/pgx/v4/stdlib"
 	"github.com/jmoiron/sqlx"
 	"github.com/oursky/pageship/internal/db"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 )
 
 func init() {
@@ -28,7 +29,7 @@ func (t Tx) Rollback() error { return t.ext.Rollback() }
 func (t Tx) Commit() error   { return t.ext.Commit() }
 
 func NewPostgres(url url.URL) (db.DB, error) {
-	db, err := sqlx.Open("pgx", url.String())
+	db, err := db.OpenTraced("pgx", url.String(), semconv.DBSystemPostgreSQL)
 	if err != nil {
 		return nil, fmt.Errorf("open postgres DB: %w", err)
 	}
@@ -13,8 +13,8 @@ import (
 
 func (q query[T]) CreateDeployment(ctx context.Context, deployment *models.Deployment) error {
 	result, err := sqlx.NamedExecContext(ctx, q.ext, `
-		INSERT INTO deployment (id, created_at, updated_at, deleted_at, name, app_id, storage_key_prefix, metadata, uploaded_at, expire_at)
-			VALUES (:id, :created_at, :updated_at, :deleted_at, :name, :app_id, :storage_key_prefix, :metadata, :uploaded_at, :expire_at)
+		INSERT INTO deployment (id, created_at, updated_at, deleted_at, name, app_id, storage_key_prefix, blob_key_prefix, metadata, uploaded_at, expire_at)
+			VALUES (:id, :created_at, :updated_at, :deleted_at, :name, :app_id, :storage_key_prefix, :blob_key_prefix, :metadata, :uploaded_at, :expire_at)
 			ON CONFLICT (app_id, name) WHERE deleted_at IS NULL DO NOTHING
 	`, deployment)
 	if err != nil {
@@ -36,7 +36,7 @@ func (q query[T]) GetDeployment(ctx context.Context, appID string, id string) (*
 	var deployment models.Deployment
 
 	err := sqlx.GetContext(ctx, q.ext, &deployment, `
-		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.name, d.app_id, d.storage_key_prefix, d.metadata, d.uploaded_at, d.expire_at FROM deployment d
+		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.name, d.app_id, d.storage_key_prefix, d.blob_key_prefix, d.metadata, d.uploaded_at, d.expire_at FROM deployment d
 			JOIN app a ON (a.id = d.app_id AND a.deleted_at IS NULL)
 			WHERE d.app_id = $1 AND d.id = $2 AND d.deleted_at IS NULL
 	`, appID, id)
@@ -53,7 +53,7 @@ func (q query[T]) GetDeploymentByName(ctx context.Context, appID string, name st
 	var deployment models.Deployment
 
 	err := sqlx.GetContext(ctx, q.ext, &deployment, `
-		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.name, d.app_id, d.storage_key_prefix, d.metadata, d.uploaded_at, d.expire_at FROM deployment d
+		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.name, d.app_id, d.storage_key_prefix, d.blob_key_prefix, d.metadata, d.uploaded_at, d.expire_at FROM deployment d
 			JOIN app a ON (a.id = d.app_id AND a.deleted_at IS NULL)
 			WHERE d.app_id = $1 AND d.name = $2 AND d.deleted_at IS NULL
 	`, appID, name)
@@ -69,7 +69,7 @@ func (q query[T]) GetDeploymentByName(ctx context.Context, appID string, name st
 func (q query[T]) ListDeployments(ctx context.Context, appID string) ([]db.DeploymentInfo, error) {
 	var deployments []db.DeploymentInfo
 	err := sqlx.SelectContext(ctx, q.ext, &deployments, `
-		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.name, d.app_id, d.storage_key_prefix, d.metadata, d.uploaded_at, d.expire_at, min(s.name) as site_name FROM deployment d
+		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.name, d.app_id, d.storage_key_prefix, d.blob_key_prefix, d.metadata, d.uploaded_at, d.expire_at, min(s.name) as site_name FROM deployment d
 			LEFT JOIN site s ON (s.deployment_id = d.id AND s.deleted_at IS NULL)
 			WHERE d.app_id = $1 AND d.deleted_at IS NULL
 			GROUP BY d.id
@@ -97,7 +97,7 @@ func (q query[T]) GetSiteDeployment(ctx context.Context, appID string, siteName
 	var deployment models.Deployment
 
 	err := sqlx.GetContext(ctx, q.ext, &deployment, `
-		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.name, d.app_id, d.storage_key_prefix, d.metadata, d.uploaded_at, d.expire_at FROM site s
+		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.name, d.app_id, d.storage_key_prefix, d.blob_key_prefix, d.metadata, d.uploaded_at, d.expire_at FROM site s
 			JOIN app a ON (a.id = s.app_id AND a.deleted_at IS NULL)
 			JOIN deployment d ON (d.id = s.deployment_id AND d.deleted_at IS NULL)
 			WHERE d.app_id = $1 AND s.name = $2 AND s.deleted_at IS NULL
@@ -153,3 +153,77 @@ func (q query[T]) DeleteExpiredDeployments(ctx context.Context, now time.Time, e
 
 	return n, nil
 }
+
+func (q query[T]) SetDeploymentBlobKeyPrefix(ctx context.Context, deployment *models.Deployment) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE deployment SET blob_key_prefix = $1, updated_at = $2 WHERE id = $3
+	`, deployment.BlobKeyPrefix, deployment.UpdatedAt, deployment.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) ListDeploymentsWithoutBlobs(ctx context.Context, afterID string, limit uint) ([]*models.Deployment, error) {
+	var deployments []*models.Deployment
+	err := sqlx.SelectContext(ctx, q.ext, &deployments, `
+		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.name, d.app_id, d.storage_key_prefix, d.blob_key_prefix, d.metadata, d.uploaded_at, d.expire_at FROM deployment d
+			WHERE d.id > $1 AND d.deleted_at IS NULL AND d.uploaded_at IS NOT NULL AND d.blob_key_prefix IS NULL
+			ORDER BY d.id
+			LIMIT $2
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return deployments, nil
+}
+
+func (q query[T]) ListDeploymentsPendingStorageCleanup(ctx context.Context, limit uint) ([]*models.Deployment, error) {
+	var deployments []*models.Deployment
+	err := sqlx.SelectContext(ctx, q.ext, &deployments, `
+		SELECT d.id, d.created_at, d.updated_at, d.deleted_at, d.name, d.app_id, d.storage_key_prefix, d.blob_key_prefix, d.metadata, d.uploaded_at, d.expire_at FROM deployment d
+			WHERE d.deleted_at IS NOT NULL AND d.storage_deleted_at IS NULL
+			ORDER BY d.deleted_at, d.id
+			LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return deployments, nil
+}
+
+func (q query[T]) MarkDeploymentStorageDeleted(ctx context.Context, now time.Time, deployment *models.Deployment) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE deployment SET storage_deleted_at = $1 WHERE id = $2
+	`, now, deployment.ID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) DeleteDeployment(ctx context.Context, id string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE deployment SET deleted_at = $1, updated_at = $2 WHERE id = $3
+	`, now, now, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) DeleteAppDeployments(ctx context.Context, appID string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE deployment SET deleted_at = $1, updated_at = $2 WHERE app_id = $3 AND deleted_at IS NULL
+	`, now, now, appID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+)
+
+func (q query[T]) CreateSiteActivation(ctx context.Context, activation *models.SiteActivation) error {
+	_, err := sqlx.NamedExecContext(ctx, q.ext, `
+		INSERT INTO site_activation (id, created_at, app_id, site_id, deployment_id, previous_deployment_id, subject, credential_id, credential_rule)
+			VALUES (:id, :created_at, :app_id, :site_id, :deployment_id, :previous_deployment_id, :subject, :credential_id, :credential_rule)
+	`, activation)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) ListRecentSiteDeploymentIDs(ctx context.Context, siteID string, limit uint) ([]string, error) {
+	var ids []string
+	err := sqlx.SelectContext(ctx, q.ext, &ids, `
+		SELECT a.deployment_id FROM site_activation a
+			JOIN deployment d ON (d.id = a.deployment_id AND d.deleted_at IS NULL)
+			WHERE a.site_id = $1
+			GROUP BY a.deployment_id
+			ORDER BY max(a.created_at) DESC
+			LIMIT $2
+	`, siteID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+func (q query[T]) IsDeploymentRecentlyActive(ctx context.Context, deployment *models.Deployment, limit uint) (bool, error) {
+	var active bool
+	err := sqlx.GetContext(ctx, q.ext, &active, `
+		SELECT EXISTS (
+			SELECT 1 FROM site s
+				WHERE s.app_id = $1 AND s.deleted_at IS NULL AND $2 IN (
+					SELECT a.deployment_id FROM site_activation a
+						JOIN deployment d ON (d.id = a.deployment_id AND d.deleted_at IS NULL)
+						WHERE a.site_id = s.id
+						GROUP BY a.deployment_id
+						ORDER BY max(a.created_at) DESC
+						LIMIT $3
+				)
+		)
+	`, deployment.AppID, deployment.ID, limit)
+	if err != nil {
+		return false, err
+	}
+
+	return active, nil
+}
+
+func (q query[T]) ListSiteActivations(ctx context.Context, siteID string, limit uint) ([]db.SiteActivationInfo, error) {
+	info := []db.SiteActivationInfo{}
+	err := sqlx.SelectContext(ctx, q.ext, &info, `
+		SELECT a.id, a.created_at, a.app_id, a.site_id, a.deployment_id, a.previous_deployment_id, a.subject, a.credential_id, a.credential_rule,
+				d.name AS deployment_name, pd.name AS previous_deployment_name FROM site_activation a
+			LEFT JOIN deployment d ON (d.id = a.deployment_id)
+			LEFT JOIN deployment pd ON (pd.id = a.previous_deployment_id)
+			WHERE a.site_id = $1
+			ORDER BY a.created_at DESC, a.id
+			LIMIT $2
+	`, siteID, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+func (q query[T]) DeleteAppSiteActivations(ctx context.Context, appID string) error {
+	_, err := q.ext.ExecContext(ctx, `
+		DELETE FROM site_activation WHERE app_id = $1
+	`, appID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oursky/pageship/internal/models"
+)
+
+func (q query[T]) AddSiteAnalytics(ctx context.Context, entries []*models.SiteAnalytics) error {
+	for _, e := range entries {
+		_, err := sqlx.NamedExecContext(ctx, q.ext, `
+			INSERT INTO site_analytics (app_id, site_name, hour, dimension, value, requests, bytes)
+				VALUES (:app_id, :site_name, :hour, :dimension, :value, :requests, :bytes)
+				ON CONFLICT (app_id, site_name, hour, dimension, value) DO UPDATE SET
+					requests = site_analytics.requests + excluded.requests,
+					bytes = site_analytics.bytes + excluded.bytes
+		`, e)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (q query[T]) ListSiteAnalytics(ctx context.Context, appID string, siteName string, from time.Time, to time.Time) ([]*models.SiteAnalytics, error) {
+	entries := []*models.SiteAnalytics{}
+	err := sqlx.SelectContext(ctx, q.ext, &entries, `
+		SELECT a.app_id, a.site_name, a.hour, a.dimension, a.value, a.requests, a.bytes FROM site_analytics a
+			WHERE a.app_id = $1 AND a.site_name = $2 AND a.hour >= $3 AND a.hour < $4
+			ORDER BY a.hour, a.dimension, a.value
+	`, appID, siteName, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (q query[T]) ListSiteAnalyticsValues(ctx context.Context, appID string, siteName string, hour time.Time, dimension models.SiteAnalyticsDimension) ([]string, error) {
+	values := []string{}
+	err := sqlx.SelectContext(ctx, q.ext, &values, `
+		SELECT a.value FROM site_analytics a
+			WHERE a.app_id = $1 AND a.site_name = $2 AND a.hour = $3 AND a.dimension = $4
+	`, appID, siteName, hour, dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+func (q query[T]) DeleteSiteAnalytics(ctx context.Context, before time.Time) (int64, error) {
+	result, err := q.ext.ExecContext(ctx, `
+		DELETE FROM site_analytics WHERE hour < $1
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+func (q query[T]) DeleteAppSiteAnalytics(ctx context.Context, appID string) error {
+	_, err := q.ext.ExecContext(ctx, `
+		DELETE FROM site_analytics WHERE app_id = $1
+	`, appID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) DeleteSiteAnalyticsBySite(ctx context.Context, appID string, siteName string) error {
+	_, err := q.ext.ExecContext(ctx, `
+		DELETE FROM site_analytics WHERE app_id = $1 AND site_name = $2
+	`, appID, siteName)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
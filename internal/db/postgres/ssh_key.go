@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oursky/pageship/internal/models"
+)
+
+func (q query[T]) CreateSSHKey(ctx context.Context, key *models.SSHKey) error {
+	result, err := sqlx.NamedExecContext(ctx, q.ext, `
+		INSERT INTO ssh_key (id, created_at, updated_at, deleted_at, user_id, name, fingerprint, public_key, last_used_at)
+			VALUES (:id, :created_at, :updated_at, :deleted_at, :user_id, :name, :fingerprint, :public_key, :last_used_at)
+			ON CONFLICT (fingerprint) WHERE deleted_at IS NULL DO NOTHING
+	`, key)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return models.ErrSSHKeyUsed
+	}
+
+	return nil
+}
+
+func (q query[T]) GetSSHKeyByFingerprint(ctx context.Context, fingerprint string) (*models.SSHKey, error) {
+	var key models.SSHKey
+	err := sqlx.GetContext(ctx, q.ext, &key, `
+		SELECT k.id, k.created_at, k.updated_at, k.deleted_at, k.user_id, k.name, k.fingerprint, k.public_key, k.last_used_at FROM ssh_key k
+			WHERE k.fingerprint = $1 AND k.deleted_at IS NULL
+	`, fingerprint)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrSSHKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+func (q query[T]) ListSSHKeys(ctx context.Context, userID string) ([]*models.SSHKey, error) {
+	keys := []*models.SSHKey{}
+	err := sqlx.SelectContext(ctx, q.ext, &keys, `
+		SELECT k.id, k.created_at, k.updated_at, k.deleted_at, k.user_id, k.name, k.fingerprint, k.public_key, k.last_used_at FROM ssh_key k
+			WHERE k.user_id = $1 AND k.deleted_at IS NULL
+			ORDER BY k.created_at, k.id
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (q query[T]) MarkSSHKeyUsed(ctx context.Context, id string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE ssh_key SET last_used_at = $1 WHERE id = $2
+	`, now, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) DeleteSSHKey(ctx context.Context, userID string, id string, now time.Time) error {
+	result, err := q.ext.ExecContext(ctx, `
+		UPDATE ssh_key SET deleted_at = $1, updated_at = $2 WHERE user_id = $3 AND id = $4 AND deleted_at IS NULL
+	`, now, now, userID, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return models.ErrSSHKeyNotFound
+	}
+
+	return nil
+}
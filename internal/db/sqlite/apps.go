@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/oursky/pageship/internal/models"
@@ -25,7 +26,14 @@ func (q query[T]) CreateApp(ctx context.Context, app *models.App) error {
 	result, err := sqlx.NamedExecContext(ctx, q.ext, `
 		INSERT INTO app (id, created_at, updated_at, deleted_at, config, owner_user_id, credential_index)
 			VALUES (:id, :created_at, :updated_at, :deleted_at, :config, :owner_user_id, :credential_index)
-			ON CONFLICT (id) DO NOTHING
+			ON CONFLICT (id) DO UPDATE SET
+				created_at = excluded.created_at,
+				updated_at = excluded.updated_at,
+				deleted_at = excluded.deleted_at,
+				config = excluded.config,
+				owner_user_id = excluded.owner_user_id,
+				credential_index = excluded.credential_index
+				WHERE app.deleted_at IS NOT NULL
 	`, a)
 	if err != nil {
 		return err
@@ -83,6 +91,18 @@ func (q query[T]) GetApp(ctx context.Context, id string) (*models.App, error) {
 	return &app, nil
 }
 
+func (q query[T]) GetAppForUpdate(ctx context.Context, id string) (*models.App, error) {
+	// SQLite has no row lock; acquire write lock of database instead.
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE app SET id = id WHERE id = ?
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.GetApp(ctx, id)
+}
+
 func (q query[T]) UpdateAppConfig(ctx context.Context, app *models.App) error {
 	indexKeys := app.CredentialIndexKeys()
 	index, err := json.Marshal(indexKeys)
@@ -99,3 +119,14 @@ func (q query[T]) UpdateAppConfig(ctx context.Context, app *models.App) error {
 
 	return nil
 }
+
+func (q query[T]) DeleteApp(ctx context.Context, id string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE app SET deleted_at = ?, updated_at = ? WHERE id = ?
+	`, now, now, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oursky/pageship/internal/models"
+)
+
+func (q query[T]) CreateAuditLog(ctx context.Context, log *models.AuditLog) error {
+	_, err := sqlx.NamedExecContext(ctx, q.ext, `
+		INSERT INTO audit_log (id, created_at, app_id, action, target, details, subject, credential_id, credential_rule, request_id, source_ip)
+			VALUES (:id, :created_at, :app_id, :action, :target, :details, :subject, :credential_id, :credential_rule, :request_id, :source_ip)
+	`, log)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) ListAuditLogs(ctx context.Context, appID string, before string, limit uint) ([]*models.AuditLog, error) {
+	logs := []*models.AuditLog{}
+	err := sqlx.SelectContext(ctx, q.ext, &logs, `
+		SELECT l.id, l.created_at, l.app_id, l.action, l.target, l.details, l.subject, l.credential_id, l.credential_rule, l.request_id, l.source_ip FROM audit_log l
+			WHERE l.app_id = ? AND l.deleted_at IS NULL AND (? = '' OR (l.created_at, l.id) < (SELECT b.created_at, b.id FROM audit_log b WHERE b.id = ?))
+			ORDER BY l.created_at DESC, l.id DESC
+			LIMIT ?
+	`, appID, before, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+func (q query[T]) DeleteAppAuditLogs(ctx context.Context, appID string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE audit_log SET deleted_at = ? WHERE app_id = ? AND deleted_at IS NULL
+	`, now, appID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
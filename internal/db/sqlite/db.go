@@ -8,6 +8,7 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"github.com/oursky/pageship/internal/db"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
 	_ "modernc.org/sqlite"
 )
 
@@ -37,7 +38,7 @@ func NewSqlite(url url.URL) (db.DB, error) {
 	url.RawQuery = q.Encode()
 
 	dsn := strings.TrimPrefix(url.String(), "sqlite://")
-	db, err := sqlx.Open("sqlite", dsn)
+	db, err := db.OpenTraced("sqlite", dsn, semconv.DBSystemSqlite)
 	if err != nil {
 		return nil, fmt.Errorf("open sqlite DB: %w", err)
 	}
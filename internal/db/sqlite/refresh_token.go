@@ -0,0 +1,84 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/oursky/pageship/internal/models"
+)
+
+func (q query[T]) CreateRefreshToken(ctx context.Context, token *models.RefreshToken) error {
+	_, err := sqlx.NamedExecContext(ctx, q.ext, `
+		INSERT INTO refresh_token (id, created_at, family_id, user_id, token_hash, expires_at, used_at, revoked_at)
+			VALUES (:id, :created_at, :family_id, :user_id, :token_hash, :expires_at, :used_at, :revoked_at)
+	`, token)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) GetRefreshTokenByHash(ctx context.Context, hash string) (*models.RefreshToken, error) {
+	var token models.RefreshToken
+	err := sqlx.GetContext(ctx, q.ext, &token, `
+		SELECT t.id, t.created_at, t.family_id, t.user_id, t.token_hash, t.expires_at, t.used_at, t.revoked_at FROM refresh_token t
+			WHERE t.token_hash = ?
+	`, hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, models.ErrRefreshTokenNotFound
+	} else if err != nil {
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+func (q query[T]) MarkRefreshTokenUsed(ctx context.Context, id string, now time.Time) error {
+	result, err := q.ext.ExecContext(ctx, `
+		UPDATE refresh_token SET used_at = ? WHERE id = ? AND used_at IS NULL
+	`, now, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return models.ErrRefreshTokenUsed
+	}
+
+	return nil
+}
+
+func (q query[T]) RevokeRefreshTokenFamily(ctx context.Context, familyID string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE refresh_token SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL
+	`, now, familyID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) DeleteExpiredRefreshTokens(ctx context.Context, expireBefore time.Time) (int64, error) {
+	result, err := q.ext.ExecContext(ctx, `
+		DELETE FROM refresh_token WHERE expires_at < ? OR revoked_at < ?
+	`, expireBefore, expireBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
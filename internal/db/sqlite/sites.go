@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/oursky/pageship/internal/db"
@@ -91,3 +92,25 @@ func (q query[T]) SetSiteDeployment(ctx context.Context, site *models.Site) erro
 
 	return nil
 }
+
+func (q query[T]) DeleteSite(ctx context.Context, id string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE site SET deleted_at = ?, updated_at = ? WHERE id = ?
+	`, now, now, id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (q query[T]) DeleteAppSites(ctx context.Context, appID string, now time.Time) error {
+	_, err := q.ext.ExecContext(ctx, `
+		UPDATE site SET deleted_at = ?, updated_at = ? WHERE app_id = ? AND deleted_at IS NULL
+	`, now, now, appID)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,51 @@
+package db_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/oursky/pageship/internal/db"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	_ "modernc.org/sqlite"
+)
+
+func TestOpenTraced(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	ctx := context.Background()
+	database, err := db.OpenTraced("sqlite", ":memory:", semconv.DBSystemSqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	rows, err := database.QueryxContext(ctx, "SELECT 1")
+	if !assert.NoError(t, err) {
+		return
+	}
+	// Span of query ends only after rows are read.
+	assert.Len(t, recorder.Ended(), 0)
+
+	assert.True(t, rows.Next())
+	assert.False(t, rows.Next())
+	assert.NoError(t, rows.Err())
+	assert.NoError(t, rows.Close())
+
+	spans := recorder.Ended()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "db.query", spans[0].Name())
+	}
+
+	var value int
+	assert.NoError(t, database.GetContext(ctx, &value, "SELECT 2"))
+	assert.Equal(t, 2, value)
+	_, err = database.ExecContext(ctx, "CREATE TABLE test (id INTEGER)")
+	assert.NoError(t, err)
+	assert.Len(t, recorder.Ended(), 3)
+}
@@ -3,9 +3,9 @@ package deploy
 import (
 	"archive/tar"
 	"bytes"
+	"fmt"
 	"io"
 	"io/fs"
-	"os"
 	"path"
 	"path/filepath"
 	"time"
@@ -15,51 +15,35 @@ import (
 )
 
 var ErrTooManyFiles error = Error("too many files collected")
+var ErrReservedPath error = Error("reserved file path")
 
+type fileSource struct {
+	fsys fs.FS
+	path string
+	data []byte
+}
+
+func (s fileSource) open() (io.ReadCloser, error) {
+	if s.fsys == nil {
+		return io.NopCloser(bytes.NewReader(s.data)), nil
+	}
+	return s.fsys.Open(s.path)
+}
+
+// Collector collects files to deploy. Files are hashed when collected, and
+// their content is read again when packed into tarball.
 type Collector struct {
 	files   []models.FileEntry
+	sources map[string]fileSource
 	modTime time.Time
-
-	closed bool
-	comp   *zstd.Encoder
-	writer *tar.Writer
 }
 
-func NewCollector(modTime time.Time, tarfile *os.File) (coll *Collector, err error) {
-	coll = &Collector{
+func NewCollector(modTime time.Time) *Collector {
+	return &Collector{
 		files:   nil,
+		sources: make(map[string]fileSource),
 		modTime: modTime,
-		closed:  false,
-	}
-	defer func() {
-		if err != nil {
-			coll.Close()
-			coll = nil
-		}
-	}()
-
-	coll.comp, err = zstd.NewWriter(tarfile, zstd.WithWindowSize(zstdWindowSize))
-	if err != nil {
-		return
-	}
-
-	coll.writer = tar.NewWriter(coll.comp)
-
-	return
-}
-
-func (c *Collector) Close() {
-	if c.closed {
-		return
-	}
-
-	if c.writer != nil {
-		c.writer.Close()
 	}
-	if c.comp != nil {
-		c.comp.Close()
-	}
-	c.closed = true
 }
 
 func (c *Collector) Files() []models.FileEntry {
@@ -67,23 +51,19 @@ func (c *Collector) Files() []models.FileEntry {
 }
 
 func (c *Collector) AddDir(path string) {
-	header := &tar.Header{
-		Typeflag: tar.TypeDir,
-		Name:     path,
-		ModTime:  c.modTime,
-		Size:     0,
-	}
-	c.writer.WriteHeader(header)
-
 	c.files = append(c.files, models.FileEntry{
-		Path:        header.Name,
-		Size:        header.Size,
+		Path:        path,
+		Size:        0,
 		Hash:        "",
 		ContentType: "",
 	})
 }
 
 func (c *Collector) AddFile(path string, data []byte) error {
+	if models.IsReservedPath(path) {
+		return fmt.Errorf("%w: %s", ErrReservedPath, path)
+	}
+
 	h := NewFileHash()
 	_, err := io.Copy(h, bytes.NewBuffer(data))
 	if err != nil {
@@ -91,21 +71,29 @@ func (c *Collector) AddFile(path string, data []byte) error {
 	}
 	hash := h.Sum()
 
-	header := &tar.Header{
-		Typeflag: tar.TypeReg,
-		Name:     path,
-		ModTime:  c.modTime,
-		Size:     int64(len(data)),
+	entry := models.FileEntry{
+		Path:        path,
+		Size:        int64(len(data)),
+		Hash:        hash,
+		ContentType: models.DetectContentType(path, data),
+	}
+	if err := c.addEncodings(&entry, data); err != nil {
+		return err
 	}
-	c.writer.WriteHeader(header)
-	c.writer.Write(data)
 
-	c.files = append(c.files, models.FileEntry{
-		Path:        header.Name,
-		Size:        header.Size,
-		Hash:        hash,
-		ContentType: models.DetectContentType(header.Name, data),
-	})
+	c.files = append(c.files, entry)
+	c.sources[path] = fileSource{data: data}
+	return nil
+}
+
+func (c *Collector) addEncodings(entry *models.FileEntry, data []byte) error {
+	variants, err := encodeFile(entry, data)
+	if err != nil {
+		return err
+	}
+	for path, data := range variants {
+		c.sources[path] = fileSource{data: data}
+	}
 	return nil
 }
 
@@ -120,12 +108,28 @@ func (c *Collector) Collect(fsys fs.FS, dir string) error {
 			return fs.WalkDir(fsys, p, walker)
 		}
 
-		entry, err := addFile(fsys, p, d, dir, c.modTime, c.writer)
+		entry, err := hashFile(fsys, p, d, dir)
 		if err != nil {
 			return err
 		}
+		if models.IsReservedPath(entry.Path) {
+			return fmt.Errorf("%w: %s", ErrReservedPath, entry.Path)
+		}
+
+		if entry.Hash != "" && entry.Size >= minEncodeSize && isCompressible(entry.ContentType) {
+			data, err := fs.ReadFile(fsys, p)
+			if err != nil {
+				return err
+			}
+			if err := c.addEncodings(&entry, data); err != nil {
+				return err
+			}
+		}
 
 		c.files = append(c.files, entry)
+		if entry.Hash != "" {
+			c.sources[entry.Path] = fileSource{fsys: fsys, path: p}
+		}
 		if len(c.files) > models.MaxFiles {
 			return ErrTooManyFiles
 		}
@@ -135,53 +139,137 @@ func (c *Collector) Collect(fsys fs.FS, dir string) error {
 	return fs.WalkDir(fsys, ".", walker)
 }
 
-func addFile(fsys fs.FS, filePath string, d fs.DirEntry, dir string, modTime time.Time, writer *tar.Writer) (models.FileEntry, error) {
+// Pack writes the collected files accepted by include to w as compressed
+// tarball.
+func (c *Collector) Pack(w io.Writer, include func(models.FileEntry) bool) error {
+	var files []models.FileEntry
+	for _, entry := range TarballEntries(c.files) {
+		if include(entry) {
+			files = append(files, entry)
+		}
+	}
+
+	return PackFiles(w, files, c.modTime, func(entry models.FileEntry) (io.ReadCloser, error) {
+		return c.sources[entry.Path].open()
+	})
+}
+
+// PackFiles writes the files to w as compressed tarball, with file content
+// read from open.
+func PackFiles(
+	w io.Writer,
+	files []models.FileEntry,
+	modTime time.Time,
+	open func(models.FileEntry) (io.ReadCloser, error),
+) error {
+	comp, err := zstd.NewWriter(w, zstd.WithWindowSize(zstdWindowSize))
+	if err != nil {
+		return err
+	}
+	defer comp.Close()
+
+	writer := tar.NewWriter(comp)
+	defer writer.Close()
+
+	for _, entry := range files {
+		if entry.Hash == "" {
+			err := writer.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     entry.Path,
+				ModTime:  modTime,
+				Size:     0,
+			})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := packFile(writer, entry, modTime, open); err != nil {
+			return fmt.Errorf("%s: %w", entry.Path, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return comp.Close()
+}
+
+func packFile(
+	writer *tar.Writer,
+	entry models.FileEntry,
+	modTime time.Time,
+	open func(models.FileEntry) (io.ReadCloser, error),
+) error {
+	file, err := open(entry)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = writer.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     entry.Path,
+		ModTime:  modTime,
+		Size:     entry.Size,
+	})
+	if err != nil {
+		return err
+	}
+
+	h := NewFileHash()
+	n, err := io.Copy(writer, io.TeeReader(io.LimitReader(file, entry.Size), h))
+	if err != nil {
+		return err
+	}
+	if n != entry.Size {
+		return ErrUnexpectedFileSize
+	}
+	if h.Sum() != entry.Hash {
+		return ErrUnexpectedFileHash
+	}
+	return nil
+}
+
+func hashFile(fsys fs.FS, filePath string, d fs.DirEntry, dir string) (models.FileEntry, error) {
 	info, err := d.Info()
 	if err != nil {
 		return models.FileEntry{}, err
 	}
 
 	path := path.Join(dir, filepath.ToSlash(filePath))
-	header := tar.Header{
-		Name:    path,
-		ModTime: modTime,
-		Size:    info.Size(),
-	}
 	if info.IsDir() {
-		header.Typeflag = tar.TypeDir
-		header.Size = 0
-		header.Name += "/"
-	} else {
-		header.Typeflag = tar.TypeReg
-	}
-	writer.WriteHeader(&header)
-
-	hash := ""
-	contentType := ""
-	if !info.IsDir() {
-		file, err := fsys.Open(filePath)
-		if err != nil {
-			return models.FileEntry{}, err
-		}
+		return models.FileEntry{
+			Path:        path + "/",
+			Size:        0,
+			Hash:        "",
+			ContentType: "",
+		}, nil
+	}
 
-		initialBytes := make([]byte, 512)
-		n, _ := io.ReadFull(file, initialBytes)
-		initialBytes = initialBytes[:n]
-		contentType = models.DetectContentType(header.Name, initialBytes)
+	file, err := fsys.Open(filePath)
+	if err != nil {
+		return models.FileEntry{}, err
+	}
+	defer file.Close()
 
-		fileData := io.MultiReader(bytes.NewBuffer(initialBytes), file)
-		h := NewFileHash()
-		_, err = io.Copy(writer, io.TeeReader(fileData, h))
-		if err != nil {
-			return models.FileEntry{}, err
-		}
-		hash = h.Sum()
+	initialBytes := make([]byte, 512)
+	n, _ := io.ReadFull(file, initialBytes)
+	initialBytes = initialBytes[:n]
+	contentType := models.DetectContentType(path, initialBytes)
+
+	fileData := io.MultiReader(bytes.NewBuffer(initialBytes), file)
+	h := NewFileHash()
+	size, err := io.Copy(h, fileData)
+	if err != nil {
+		return models.FileEntry{}, err
 	}
 
 	return models.FileEntry{
-		Path:        header.Name,
-		Size:        header.Size,
-		Hash:        hash,
+		Path:        path,
+		Size:        size,
+		Hash:        h.Sum(),
 		ContentType: contentType,
 	}, nil
 }
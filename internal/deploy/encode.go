@@ -0,0 +1,139 @@
+package deploy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/oursky/pageship/internal/models"
+)
+
+// encodedPathPrefix is the tarball path prefix of pre-compressed file
+// content; it is reserved so collected files are never placed in it.
+const encodedPathPrefix = models.ReservedPathPrefix + "encoded/"
+
+// minEncodeSize is the minimum size of file worth compressing.
+const minEncodeSize = 256
+
+var compressibleContentTypes = []string{
+	"text/",
+	"application/javascript",
+	"application/json",
+	"application/manifest+json",
+	"application/wasm",
+	"application/xml",
+	"application/x-javascript",
+	"image/svg+xml",
+	"image/x-icon",
+	"font/otf",
+	"font/ttf",
+}
+
+type encoder struct {
+	encoding string
+	new      func(w io.Writer) io.WriteCloser
+}
+
+var encoders = []encoder{
+	{
+		encoding: "br",
+		new: func(w io.Writer) io.WriteCloser {
+			return brotli.NewWriterLevel(w, brotli.BestCompression)
+		},
+	},
+	{
+		encoding: "gzip",
+		new: func(w io.Writer) io.WriteCloser {
+			gw, _ := gzip.NewWriterLevel(w, gzip.BestCompression)
+			return gw
+		},
+	},
+}
+
+func isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, t := range compressibleContentTypes {
+		if (strings.HasSuffix(t, "/") && strings.HasPrefix(mediaType, t)) || mediaType == t {
+			return true
+		}
+	}
+	return false
+}
+
+// EncodedPath returns the tarball path of pre-compressed file content.
+func EncodedPath(path string, encoding string) string {
+	return encodedPathPrefix + encoding + path
+}
+
+// ParseEncodedPath returns the file path and encoding of pre-compressed
+// file content in tarball.
+func ParseEncodedPath(tarPath string) (path string, encoding string, ok bool) {
+	rest, ok := strings.CutPrefix(tarPath, encodedPathPrefix)
+	if !ok {
+		return "", "", false
+	}
+	encoding, path, ok = strings.Cut(rest, "/")
+	if !ok {
+		return "", "", false
+	}
+	return "/" + path, encoding, true
+}
+
+// TarballEntries returns the entries packed in tarball for the files,
+// including pre-compressed content.
+func TarballEntries(files []models.FileEntry) []models.FileEntry {
+	entries := make([]models.FileEntry, 0, len(files))
+	for _, file := range files {
+		entries = append(entries, file)
+		for _, enc := range file.Encodings {
+			entries = append(entries, models.FileEntry{
+				Path:        EncodedPath(file.Path, enc.Encoding),
+				Size:        enc.Size,
+				Hash:        enc.Hash,
+				ContentType: file.ContentType,
+			})
+		}
+	}
+	return entries
+}
+
+// encodeFile generates pre-compressed variants of compressible file content,
+// with best compression level. Variants without meaningful size reduction
+// are discarded.
+func encodeFile(entry *models.FileEntry, data []byte) (map[string][]byte, error) {
+	if entry.Size < minEncodeSize || !isCompressible(entry.ContentType) {
+		return nil, nil
+	}
+
+	variants := make(map[string][]byte)
+	for _, e := range encoders {
+		var buf bytes.Buffer
+		w := e.new(&buf)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+
+		if buf.Len() > len(data)*9/10 {
+			continue
+		}
+
+		h := NewFileHash()
+		h.Write(buf.Bytes())
+		entry.Encodings = append(entry.Encodings, models.FileEncoding{
+			Encoding: e.encoding,
+			Size:     int64(buf.Len()),
+			Hash:     h.Sum(),
+		})
+		variants[EncodedPath(entry.Path, e.encoding)] = buf.Bytes()
+	}
+	return variants, nil
+}
@@ -12,14 +12,23 @@ import (
 
 var ErrUnexpectedFile error = Error("unexpected file")
 var ErrUnexpectedFileSize error = Error("unexpected file size")
+var ErrUnexpectedFileHash error = Error("unexpected file hash")
 var ErrMissingFile error = Error("missing file")
 
 const zstdWindowSize = 1024 * 1024 * 1 // 1MB
 const zstdMaxMemory = 1024 * 1024 * 1  // 1MB
 
-func ExtractFiles(r io.Reader, files []models.FileEntry, handle func(models.FileEntry, io.Reader) error) error {
+// ExtractFiles extracts the files in the tarball, including pre-compressed
+// content. Files with known content (as reported by isKnown) and directories
+// may be omitted from the tarball.
+func ExtractFiles(
+	r io.Reader,
+	files []models.FileEntry,
+	isKnown func(models.FileEntry) bool,
+	handle func(models.FileEntry, io.Reader) error,
+) error {
 	pending := make(map[string]models.FileEntry)
-	for _, entry := range files {
+	for _, entry := range TarballEntries(files) {
 		pending[entry.Path] = entry
 	}
 
@@ -54,13 +63,41 @@ func ExtractFiles(r io.Reader, files []models.FileEntry, handle func(models.File
 		delete(pending, hdr.Name)
 	}
 
-	if len(pending) > 0 {
-		missing := ""
-		for path := range pending {
-			missing = path
+	for path, file := range pending {
+		if file.Hash == "" || isKnown(file) {
+			continue
 		}
-		return fmt.Errorf("%w: %s", ErrMissingFile, missing)
+		return fmt.Errorf("%w: %s", ErrMissingFile, path)
 	}
 
 	return nil
 }
+
+// ReadFiles reads all entries in the tarball, without checking against a
+// file list. Directories are reported with empty content.
+func ReadFiles(r io.Reader, handle func(hdr *tar.Header, reader io.Reader) error) error {
+	decomp, err := zstd.NewReader(r, zstd.WithDecoderMaxMemory(zstdMaxMemory))
+	if err != nil {
+		return err
+	}
+	defer decomp.Close()
+
+	tr := tar.NewReader(decomp)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil // End of archive
+		} else if err != nil {
+			return err
+		}
+
+		if hdr.Typeflag != tar.TypeReg && hdr.Typeflag != tar.TypeDir {
+			return fmt.Errorf("%w: %s", ErrUnexpectedFile, hdr.Name)
+		}
+
+		if err := handle(hdr, tr); err != nil {
+			return fmt.Errorf("%s: %w", hdr.Name, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/models"
+	"go.uber.org/zap"
+)
+
+const apiTokenUsageInterval time.Duration = time.Minute
+
+type apiAPITokenCreated struct {
+	*models.APIToken
+	Token string `json:"token"`
+}
+
+func (c *Controller) handleAPIToken(r *http.Request, value string) (*authnInfo, error) {
+	token, err := c.DB.GetAPITokenByHash(r.Context(), models.HashAPIToken(value))
+	if errors.Is(err, models.ErrAPITokenNotFound) {
+		return nil, models.ErrInvalidCredentials
+	} else if err != nil {
+		return nil, err
+	}
+
+	now := c.Clock.Now().UTC()
+	if err := token.CheckAlive(now); err != nil {
+		return nil, err
+	}
+
+	info, err := c.handleTokenUser(r, token.UserID)
+	if err != nil {
+		return nil, err
+	}
+	info.APIToken = token
+
+	// Revalidate server ACL, since API tokens skip login.
+	if err := c.checkACL(r, info.CredentialIDs); err != nil {
+		return nil, models.ErrInvalidCredentials
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenUsageInterval {
+		if err := c.DB.MarkAPITokenUsed(r.Context(), token.ID, now); err != nil {
+			return nil, err
+		}
+	}
+
+	return info, nil
+}
+
+func (c *Controller) handleAPITokenList(w http.ResponseWriter, r *http.Request) {
+	respond(w, func() (any, error) {
+		return c.DB.ListAPITokens(r.Context(), getSubject(r))
+	})
+}
+
+func (c *Controller) handleAPITokenCreate(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Name      string              `json:"name" binding:"required,max=100"`
+		AppID     *string             `json:"appID" binding:"omitempty,dnsLabel"`
+		Access    *config.AccessLevel `json:"access" binding:"omitempty,accessLevel"`
+		ExpiresIn *string             `json:"expiresIn" binding:"omitempty,duration"`
+	}
+	if !bindJSON(w, r, &request) {
+		return
+	}
+
+	now := c.Clock.Now().UTC()
+	var expiresAt *time.Time
+	if request.ExpiresIn != nil {
+		d, _ := time.ParseDuration(*request.ExpiresIn)
+		t := now.Add(d)
+		expiresAt = &t
+	}
+
+	token, value := models.NewAPIToken(now, getSubject(r), request.Name, request.AppID, request.Access, expiresAt)
+	err := c.DB.CreateAPIToken(r.Context(), token)
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	log(r).Info("created API token", zap.String("token", token.ID))
+	writeResponse(w, &apiAPITokenCreated{APIToken: token, Token: value}, nil)
+}
+
+func (c *Controller) handleAPITokenRevoke(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "token-id")
+
+	err := c.DB.RevokeAPIToken(r.Context(), getSubject(r), id, c.Clock.Now().UTC())
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	log(r).Info("revoked API token", zap.String("token", id))
+	writeResponse(w, struct{}{}, nil)
+}
@@ -0,0 +1,112 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func createAPIToken(t *testing.T, c *testutil.TestController, token string, request api.APITokenCreateRequest) *api.APITokenCreated {
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest("POST", "http://localtest.me/api/v1/tokens", bytes.NewReader(body))
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	created, err := testutil.DecodeJSONResponse[*api.APITokenCreated](w.Result())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return created
+}
+
+func callAPIResponse(c *testutil.TestController, token string, method string, path string) *http.Response {
+	req := httptest.NewRequest(method, "http://localtest.me/api/v1/"+path, nil)
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	return w.Result()
+}
+
+func callAPI(c *testutil.TestController, token string, method string, path string) error {
+	_, err := testutil.DecodeJSONResponse[any](callAPIResponse(c, token, method, path))
+	return err
+}
+
+func errorCode(err error) int {
+	if e, ok := err.(api.ServerError); ok {
+		return e.Code
+	}
+	return 0
+}
+
+func TestAPIToken(t *testing.T) {
+	t.Run("Should authenticate as token owner", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			created := createAPIToken(t, c, token, api.APITokenCreateRequest{Name: "ci"})
+			assert.True(t, models.IsAPIToken(created.Token))
+			assert.Empty(t, created.TokenHash)
+
+			assert.NoError(t, callAPI(c, created.Token, "GET", "apps/test"))
+			assert.NoError(t, callAPI(c, created.Token, "GET", "auth/me"))
+
+			tokens, err := c.DB.ListAPITokens(c.Context, user.ID)
+			if assert.NoError(t, err) && assert.Len(t, tokens, 1) {
+				assert.NotNil(t, tokens[0].LastUsedAt)
+			}
+
+			// API tokens cannot manage API tokens.
+			assert.Equal(t, 403, errorCode(callAPI(c, created.Token, "GET", "tokens")))
+
+			assert.NoError(t, callAPI(c, token, "DELETE", "tokens/"+created.ID))
+			assert.Equal(t, 401, errorCode(callAPI(c, created.Token, "GET", "apps/test")))
+			assert.Equal(t, 404, errorCode(callAPI(c, token, "DELETE", "tokens/"+created.ID)))
+		})
+	})
+
+	t.Run("Should limit access by token scope", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			otherApp := "other"
+			appToken := createAPIToken(t, c, token, api.APITokenCreateRequest{Name: "other", AppID: &otherApp})
+			assert.Equal(t, 403, errorCode(callAPI(c, appToken.Token, "GET", "apps/test")))
+			assert.Equal(t, 403, errorCode(callAPI(c, appToken.Token, "POST", "apps")))
+
+			reader := config.AccessLevelReader
+			readerToken := createAPIToken(t, c, token, api.APITokenCreateRequest{Name: "reader", Access: &reader})
+			assert.NoError(t, callAPI(c, readerToken.Token, "GET", "apps/test"))
+			assert.Equal(t, 403, errorCode(callAPI(c, readerToken.Token, "GET", "apps/test/audit")))
+			assert.Equal(t, 403, errorCode(callAPI(c, readerToken.Token, "DELETE", "apps/test")))
+		})
+	})
+
+	t.Run("Should reject expired token", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			expiresIn := "1ns"
+			created := createAPIToken(t, c, token, api.APITokenCreateRequest{Name: "ci", ExpiresIn: &expiresIn})
+			assert.Equal(t, 401, errorCode(callAPI(c, created.Token, "GET", "apps/test")))
+		})
+	})
+
+	t.Run("Should reject unknown token", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			c.SigninUser("mock user")
+			assert.Equal(t, 401, errorCode(callAPI(c, models.APITokenPrefix+"unknown", "GET", "apps")))
+		})
+	})
+}
@@ -79,7 +79,7 @@ func (c *Controller) handleAppList(w http.ResponseWriter, r *http.Request) {
 
 		n := 0
 		for _, a := range apps {
-			if _, err := a.CheckAuthz(config.AccessLevelReader, authn.UserID(), authn.CredentialIDs); err == nil {
+			if _, err := authn.CheckAuthz(a, config.AccessLevelReader); err == nil {
 				apps[n] = a
 				n++
 			}
@@ -89,3 +89,65 @@ func (c *Controller) handleAppList(w http.ResponseWriter, r *http.Request) {
 		return mapModels(apps, c.makeAPIApp), nil
 	})
 }
+
+func (c *Controller) handleAppDelete(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+
+	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+		now := c.Clock.Now().UTC()
+
+		domains, err := tx.ListDomains(r.Context(), app.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range domains {
+			if err := tx.DeleteDomain(r.Context(), d.ID, now); err != nil {
+				return nil, err
+			}
+		}
+
+		domainVerifications, err := tx.ListDomainVerifications(r.Context(), app.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range domainVerifications {
+			if err := tx.DeleteDomainVerification(r.Context(), d.ID, now); err != nil {
+				return nil, err
+			}
+		}
+
+		// Deleted deployments are removed from storage by cron jobs.
+		if err := tx.DeleteAppSites(r.Context(), app.ID, now); err != nil {
+			return nil, err
+		}
+		if err := tx.DeleteAppDeployments(r.Context(), app.ID, now); err != nil {
+			return nil, err
+		}
+
+		// App ID may be reused; new app must not inherit data of deleted app.
+		if err := tx.DeleteAppBlobs(r.Context(), app.ID, now); err != nil {
+			return nil, err
+		}
+		if err := tx.DeleteAppSiteActivations(r.Context(), app.ID); err != nil {
+			return nil, err
+		}
+		if err := tx.DeleteAppSiteAnalytics(r.Context(), app.ID); err != nil {
+			return nil, err
+		}
+
+		if err := c.audit(r, tx, models.AuditActionAppDelete, app.ID, nil); err != nil {
+			return nil, err
+		}
+		if err := tx.DeleteAppAuditLogs(r.Context(), app.ID, now); err != nil {
+			return nil, err
+		}
+
+		if err := tx.DeleteApp(r.Context(), app.ID, now); err != nil {
+			return nil, err
+		}
+
+		log(r).Info("deleting app", zap.String("app", app.ID))
+
+		return struct{}{}, nil
+	}))
+}
@@ -15,8 +15,6 @@ func (c *Controller) handleAppConfigGet(w http.ResponseWriter, r *http.Request)
 }
 
 func (c *Controller) handleAppConfigSet(w http.ResponseWriter, r *http.Request) {
-	app := get[*models.App](r)
-
 	var request struct {
 		Config *config.AppConfig `json:"config" binding:"required"`
 	}
@@ -30,17 +28,32 @@ func (c *Controller) handleAppConfigSet(w http.ResponseWriter, r *http.Request)
 	}
 
 	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+		app, err := tx.GetAppForUpdate(r.Context(), get[*models.App](r).ID)
+		if err != nil {
+			return nil, err
+		}
+
+		request.Config.Team = keepManagedTeamUsers(request.Config.Team, app.Config.Team)
+		if err := config.ValidateAppConfig(request.Config); err != nil {
+			return nil, fmt.Errorf("%w: %s", models.ErrInvalidAppConfig, err)
+		}
+
 		app.Config = request.Config
 		now := c.Clock.Now().UTC()
 		app.UpdatedAt = now
 
-		err := tx.UpdateAppConfig(r.Context(), app)
+		err = tx.UpdateAppConfig(r.Context(), app)
 		if err != nil {
 			return nil, err
 		}
 
 		log(r).Info("updating config")
 
+		err = c.audit(r, tx, models.AuditActionAppConfigUpdate, app.ID, nil)
+		if err != nil {
+			return nil, err
+		}
+
 		// Deactivated removed domains; added domains need manual activation.
 		domains, err := tx.ListDomains(r.Context(), app.ID)
 		if err != nil {
@@ -57,6 +70,13 @@ func (c *Controller) handleAppConfigSet(w http.ResponseWriter, r *http.Request)
 			}
 
 			log(r).Info("deleting domain", zap.String("domain", d.Domain))
+
+			err = c.audit(r, tx, models.AuditActionDomainDeactivate, d.Domain, models.AuditLogDetails{
+				"site": d.SiteName,
+			})
+			if err != nil {
+				return nil, err
+			}
 		}
 		domainVerifications, err := tx.ListDomainVerifications(r.Context(), app.ID)
 		if err != nil {
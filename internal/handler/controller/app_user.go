@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+	"go.uber.org/zap"
+)
+
+type apiAppUser struct {
+	ID          string                `json:"id"`
+	Name        string                `json:"name"`
+	Credentials []models.CredentialID `json:"credentials"`
+	Access      config.AccessLevel    `json:"access,omitempty"`
+}
+
+func (c *Controller) handleAppUserList(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+
+	respond(w, func() (any, error) {
+		users := []apiAppUser{}
+		for _, rule := range app.Config.Team {
+			if rule.PageshipUser == "" {
+				continue
+			}
+
+			user := apiAppUser{ID: rule.PageshipUser, Access: rule.Access}
+			u, err := c.DB.GetUser(r.Context(), rule.PageshipUser)
+			if errors.Is(err, models.ErrUserNotFound) {
+				users = append(users, user)
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+			user.Name = u.Name
+
+			user.Credentials, err = c.DB.ListCredentialIDs(r.Context(), u.ID)
+			if err != nil {
+				return nil, err
+			}
+
+			users = append(users, user)
+		}
+		return users, nil
+	})
+}
+
+func (c *Controller) handleAppUserAdd(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		UserID string             `json:"userID" binding:"required"`
+		Access config.AccessLevel `json:"access"`
+	}
+	if !bindJSON(w, r, &request) {
+		return
+	}
+
+	if request.Access == "" {
+		request.Access = config.AccessLevelDefault
+	}
+	if !request.Access.IsValid() {
+		writeJSON(w, http.StatusBadRequest, response{
+			Error: fmt.Errorf("invalid access level: %s", request.Access),
+		})
+		return
+	}
+
+	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+		app, err := tx.GetAppForUpdate(r.Context(), get[*models.App](r).ID)
+		if err != nil {
+			return nil, err
+		}
+
+		user, err := tx.GetUser(r.Context(), request.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		found := false
+		for _, rule := range app.Config.Team {
+			if rule.PageshipUser == user.ID {
+				rule.Access = request.Access
+				found = true
+			}
+		}
+		if !found {
+			app.Config.Team = append(app.Config.Team, &config.AccessRule{
+				ACLSubjectRule: config.ACLSubjectRule{PageshipUser: user.ID},
+				Access:         request.Access,
+				Managed:        true,
+			})
+		}
+
+		if err := config.ValidateAppConfig(app.Config); err != nil {
+			return nil, fmt.Errorf("%w: %s", models.ErrInvalidAppConfig, err)
+		}
+
+		app.UpdatedAt = c.Clock.Now().UTC()
+		if err := tx.UpdateAppConfig(r.Context(), app); err != nil {
+			return nil, err
+		}
+
+		log(r).Info("adding user",
+			zap.String("user_id", user.ID),
+			zap.String("access", string(request.Access)),
+		)
+
+		err = c.audit(r, tx, models.AuditActionAppUserAdd, user.ID, models.AuditLogDetails{
+			"access": string(request.Access),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return struct{}{}, nil
+	}))
+}
+
+func (c *Controller) handleAppUserDelete(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "user-id")
+
+	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+		app, err := tx.GetAppForUpdate(r.Context(), get[*models.App](r).ID)
+		if err != nil {
+			return nil, err
+		}
+
+		team := make([]*config.AccessRule, 0, len(app.Config.Team))
+		for _, rule := range app.Config.Team {
+			if rule.PageshipUser != userID {
+				team = append(team, rule)
+			}
+		}
+		if len(team) == len(app.Config.Team) {
+			return nil, models.ErrUserNotFound
+		}
+		app.Config.Team = team
+
+		app.UpdatedAt = c.Clock.Now().UTC()
+		if err := tx.UpdateAppConfig(r.Context(), app); err != nil {
+			return nil, err
+		}
+
+		log(r).Info("removing user", zap.String("user_id", userID))
+
+		err = c.audit(r, tx, models.AuditActionAppUserRemove, userID, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		return struct{}{}, nil
+	}))
+}
+
+// keepManagedTeamUsers appends users added by team user management API in
+// current team to the new team, unless the users are specified explicitly.
+func keepManagedTeamUsers(team []*config.AccessRule, current []*config.AccessRule) []*config.AccessRule {
+	users := make(map[string]struct{})
+	for _, rule := range team {
+		if rule.PageshipUser != "" {
+			users[rule.PageshipUser] = struct{}{}
+		}
+	}
+
+	for _, rule := range current {
+		if !rule.Managed {
+			continue
+		}
+		if _, ok := users[rule.PageshipUser]; ok {
+			continue
+		}
+		team = append(team, rule)
+	}
+	return team
+}
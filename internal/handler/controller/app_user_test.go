@@ -0,0 +1,174 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func addUserCredential(t *testing.T, c *testutil.TestController, user *models.User) {
+	cred := models.NewUserCredential(time.Now(), user.ID, models.CredentialUserID(user.ID), &models.UserCredentialData{})
+	err := db.WithTx(c.Context, c.DB, func(tx db.Tx) error {
+		return tx.AddCredential(c.Context, cred)
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+}
+
+func addAppUser(c *testutil.TestController, token string, userID string, access config.AccessLevel) error {
+	body, _ := json.Marshal(map[string]any{"userID": userID, "access": access})
+	req := httptest.NewRequest("POST", "http://localtest.me/api/v1/apps/test/users", bytes.NewReader(body))
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	_, err := testutil.DecodeJSONResponse[struct{}](w.Result())
+	return err
+}
+
+func configureApp(c *testutil.TestController, token string, conf *config.AppConfig) error {
+	body, _ := json.Marshal(map[string]any{"config": conf})
+	req := httptest.NewRequest("PUT", "http://localtest.me/api/v1/apps/test/config", bytes.NewReader(body))
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	_, err := testutil.DecodeJSONResponse[any](w.Result())
+	return err
+}
+
+func listAppUsers(c *testutil.TestController, token string) ([]api.APIUser, error) {
+	return testutil.DecodeJSONResponse[[]api.APIUser](callAPIResponse(c, token, "GET", "apps/test/users"))
+}
+
+func TestAppUsers(t *testing.T) {
+	t.Run("Should manage app team users", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			owner, token := c.SigninUser("mock user")
+			member, memberToken := c.SigninUser("member")
+			addUserCredential(t, c, member)
+			c.NewApp("test", owner, nil)
+
+			err := callAPI(c, memberToken, "GET", "apps/test")
+			assert.Equal(t, 403, errorCode(err))
+
+			assert.NoError(t, addAppUser(c, token, member.ID, config.AccessLevelDeployer))
+
+			users, err := listAppUsers(c, token)
+			if assert.NoError(t, err) && assert.Len(t, users, 1) {
+				assert.Equal(t, member.ID, users[0].ID)
+				assert.Equal(t, "member", users[0].Name)
+				assert.Equal(t, config.AccessLevelDeployer, users[0].Access)
+			}
+
+			assert.NoError(t, callAPI(c, memberToken, "GET", "apps/test"))
+			err = callAPI(c, memberToken, "DELETE", "apps/test/users/"+member.ID)
+			assert.Equal(t, 403, errorCode(err))
+
+			assert.NoError(t, addAppUser(c, token, member.ID, config.AccessLevelAdmin))
+			users, err = listAppUsers(c, token)
+			if assert.NoError(t, err) && assert.Len(t, users, 1) {
+				assert.Equal(t, config.AccessLevelAdmin, users[0].Access)
+			}
+
+			assert.NoError(t, callAPI(c, token, "DELETE", "apps/test/users/"+member.ID))
+			err = callAPI(c, token, "DELETE", "apps/test/users/"+member.ID)
+			assert.Equal(t, 404, errorCode(err))
+
+			err = callAPI(c, memberToken, "GET", "apps/test")
+			assert.Equal(t, 403, errorCode(err))
+
+			users, err = listAppUsers(c, token)
+			if assert.NoError(t, err) {
+				assert.Empty(t, users)
+			}
+		})
+	})
+
+	t.Run("Should reject invalid requests", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			owner, token := c.SigninUser("mock user")
+			member, _ := c.SigninUser("member")
+			c.NewApp("test", owner, nil)
+
+			err := addAppUser(c, token, member.ID, "superuser")
+			assert.Equal(t, 400, errorCode(err))
+
+			err = addAppUser(c, token, "unknown", config.AccessLevelReader)
+			assert.Equal(t, 404, errorCode(err))
+		})
+	})
+	t.Run("Should not lose concurrent updates", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			owner, token := c.SigninUser("mock user")
+			c.NewApp("test", owner, nil)
+
+			var members []*models.User
+			for i := 0; i < 5; i++ {
+				member, _ := c.SigninUser(fmt.Sprintf("member-%d", i))
+				members = append(members, member)
+			}
+
+			var wg sync.WaitGroup
+			for _, member := range members {
+				wg.Add(1)
+				go func(member *models.User) {
+					defer wg.Done()
+					assert.NoError(t, addAppUser(c, token, member.ID, config.AccessLevelReader))
+				}(member)
+			}
+			wg.Wait()
+
+			users, err := listAppUsers(c, token)
+			if assert.NoError(t, err) {
+				assert.Len(t, users, len(members))
+			}
+		})
+	})
+	t.Run("Should keep managed users when configuring app", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			owner, token := c.SigninUser("mock user")
+			member, _ := c.SigninUser("member")
+			other, _ := c.SigninUser("other")
+			c.NewApp("test", owner, nil)
+
+			assert.NoError(t, addAppUser(c, token, member.ID, config.AccessLevelDeployer))
+			assert.NoError(t, addAppUser(c, token, other.ID, config.AccessLevelDeployer))
+
+			conf := config.DefaultAppConfig()
+			conf.ID = "test"
+			conf.Team = []*config.AccessRule{{
+				ACLSubjectRule: config.ACLSubjectRule{PageshipUser: other.ID},
+				Access:         config.AccessLevelReader,
+			}}
+			conf.SetDefaults()
+			assert.NoError(t, configureApp(c, token, &conf))
+
+			users, err := listAppUsers(c, token)
+			if assert.NoError(t, err) && assert.Len(t, users, 2) {
+				assert.Equal(t, other.ID, users[0].ID)
+				assert.Equal(t, config.AccessLevelReader, users[0].Access)
+				assert.Equal(t, member.ID, users[1].ID)
+				assert.Equal(t, config.AccessLevelDeployer, users[1].Access)
+			}
+
+			// Users removed through API are not restored.
+			assert.NoError(t, callAPI(c, token, "DELETE", "apps/test/users/"+member.ID))
+			assert.NoError(t, configureApp(c, token, &conf))
+			users, err = listAppUsers(c, token)
+			if assert.NoError(t, err) && assert.Len(t, users, 1) {
+				assert.Equal(t, other.ID, users[0].ID)
+			}
+		})
+	})
+}
@@ -0,0 +1,54 @@
+package controller
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+)
+
+const (
+	auditLogDefaultLimit = 50
+	auditLogMaxLimit     = 100
+)
+
+// audit records the mutation made by the request in the audit log of the app.
+func (c *Controller) audit(
+	r *http.Request,
+	tx db.Tx,
+	action string,
+	target string,
+	details models.AuditLogDetails,
+) error {
+	app := get[*models.App](r)
+	authz := get[*models.AppAuthzResult](r)
+
+	sourceIP, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		sourceIP = r.RemoteAddr
+	}
+
+	log := models.NewAuditLog(c.Clock.Now().UTC(), app.ID, action, target, details)
+	log.Subject = getSubject(r)
+	log.CredentialID = string(authz.CredentialID)
+	log.CredentialRule = authz.MatchedRule()
+	log.RequestID = requestID(r)
+	log.SourceIP = sourceIP
+
+	return tx.CreateAuditLog(r.Context(), log)
+}
+
+func (c *Controller) handleAuditLogList(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+
+	limit, ok := parseLimit(w, r, auditLogDefaultLimit, auditLogMaxLimit)
+	if !ok {
+		return
+	}
+	before := r.URL.Query().Get("before")
+
+	respond(w, func() (any, error) {
+		return c.DB.ListAuditLogs(r.Context(), app.ID, before, uint(limit))
+	})
+}
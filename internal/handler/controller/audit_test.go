@@ -0,0 +1,52 @@
+package controller_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func listAuditLogs(c *testutil.TestController, token string, query string) ([]models.AuditLog, error) {
+	req := httptest.NewRequest("GET", "http://localtest.me/api/v1/apps/test/audit"+query, nil)
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	return testutil.DecodeJSONResponse[[]models.AuditLog](w.Result())
+}
+
+func TestAuditLog(t *testing.T) {
+	testutil.WithTestController(func(c *testutil.TestController) {
+		user, token := c.SigninUser("mock user")
+		setupDeploymentApp(c, user)
+		setupSite(t, c, token, "v1")
+
+		_, err := activateSite(c, token, "v1")
+		assert.NoError(t, err)
+
+		logs, err := listAuditLogs(c, token, "")
+		if !assert.NoError(t, err) || !assert.Len(t, logs, 3) {
+			return
+		}
+
+		assert.Equal(t, models.AuditActionSiteUpdate, logs[0].Action)
+		assert.Equal(t, "main", logs[0].Target)
+		assert.Equal(t, "v1", logs[0].Details["deployment"])
+		assert.Equal(t, models.AuditActionDeploymentUpload, logs[1].Action)
+		assert.Equal(t, "v1", logs[1].Target)
+		assert.Equal(t, models.AuditActionDeploymentCreate, logs[2].Action)
+		assert.Equal(t, "v1", logs[2].Target)
+		for _, l := range logs {
+			assert.Equal(t, string(models.TokenSubjectUser(user.ID)), l.Subject)
+			assert.Equal(t, "<owner>", l.CredentialRule)
+			assert.NotEmpty(t, l.SourceIP)
+		}
+
+		page, err := listAuditLogs(c, token, "?limit=1&before="+logs[0].ID)
+		if assert.NoError(t, err) && assert.Len(t, page, 1) {
+			assert.Equal(t, logs[1].ID, page[0].ID)
+		}
+	})
+}
@@ -1,18 +1,32 @@
 package controller
 
 import (
+	"bytes"
+	"context"
 	"crypto/ed25519"
 	"crypto/rand"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/models"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/net/websocket"
 )
 
+const sshRefreshTokenPayload = "refresh-token"
+
+const (
+	sshAuthKindGitHub      = "github"
+	sshAuthKindKey         = "key"
+	sshAuthKindCertificate = "certificate"
+)
+
 var sshHostKey ssh.Signer
 
 func init() {
@@ -35,40 +49,16 @@ func (c *Controller) handleAuthGithubSSH(w http.ResponseWriter, r *http.Request)
 func (c *Controller) handleAuthGithubSSHConn(conn *websocket.Conn) {
 	config := &ssh.ServerConfig{
 		PublicKeyCallback: func(meta ssh.ConnMetadata, pubKey ssh.PublicKey) (*ssh.Permissions, error) {
-			fingerprint := ssh.FingerprintSHA256(pubKey)
-			pubKeys, err := c.githubKeys.PublicKey(meta.User())
-			if err != nil {
-				log(conn.Request()).Warn("cannot get GitHub public key",
-					zap.String("user", meta.User()),
-					zap.Error(err),
-				)
-				return nil, err
-			}
-
-			if _, ok := pubKeys[string(pubKey.Marshal())]; !ok {
-				log(conn.Request()).Debug(
-					"user authentication failed",
-					zap.String("user", meta.User()),
-					zap.String("fingerprint", fingerprint),
-				)
-
-				return nil, fmt.Errorf("unknown public key for %q", meta.User())
+			if cert, ok := pubKey.(*ssh.Certificate); ok {
+				return c.authSSHCertificate(conn.Request(), meta, cert)
 			}
 
-			creds := []models.CredentialID{models.CredentialGitHubUser(meta.User())}
-			if err := c.checkACL(conn.Request(), creds); err != nil {
-				return nil, fmt.Errorf("access denied")
+			perms, err := c.authSSHRegisteredKey(conn.Request(), meta, pubKey)
+			if perms != nil || err != nil {
+				return perms, err
 			}
 
-			log(conn.Request()).Info(
-				"user authenticated",
-				zap.String("github_user", meta.User()),
-				zap.String("ssh_fingerprint", fingerprint),
-			)
-
-			return &ssh.Permissions{
-				Extensions: map[string]string{"pubkey-fp": fingerprint},
-			}, nil
+			return c.authSSHGitHubKey(conn.Request(), meta, pubKey)
 		},
 	}
 	config.AddHostKey(sshHostKey)
@@ -87,22 +77,203 @@ func (c *Controller) handleAuthGithubSSHConn(conn *websocket.Conn) {
 			break
 		}
 
-		username := sshConn.User()
-		token, err := c.generateUserToken(
-			conn.Request().Context(),
-			username,
-			models.CredentialGitHubUser(username),
-			&models.UserCredentialData{
-				KeyFingerprint: sshConn.Permissions.Extensions["pubkey-fp"],
-			})
+		// Clients supporting refresh token would request it in payload;
+		// otherwise reply with access token only.
+		withRefreshToken := string(req.Payload) == sshRefreshTokenPayload
+
+		tokens, err := c.generateSSHUserToken(conn.Request().Context(), sshConn, withRefreshToken)
 		if err != nil {
 			log(conn.Request()).Warn("failed to generate token", zap.Error(err))
 			req.Reply(false, []byte("internal server error"))
 			sshConn.Close()
 			return
 		}
-		req.Reply(true, []byte(token))
+
+		reply := []byte(tokens.Token)
+		if withRefreshToken {
+			reply, _ = json.Marshal(tokens)
+		}
+		req.Reply(true, reply)
 	}
 
 	sshConn.Close()
 }
+
+func (c *Controller) authSSHGitHubKey(r *http.Request, meta ssh.ConnMetadata, pubKey ssh.PublicKey) (*ssh.Permissions, error) {
+	fingerprint := ssh.FingerprintSHA256(pubKey)
+	pubKeys, err := c.githubKeys.PublicKey(meta.User())
+	if err != nil {
+		log(r).Warn("cannot get GitHub public key",
+			zap.String("user", meta.User()),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	if _, ok := pubKeys[string(pubKey.Marshal())]; !ok {
+		log(r).Debug(
+			"user authentication failed",
+			zap.String("user", meta.User()),
+			zap.String("fingerprint", fingerprint),
+		)
+
+		return nil, fmt.Errorf("unknown public key for %q", meta.User())
+	}
+
+	creds := []models.CredentialID{models.CredentialGitHubUser(meta.User())}
+	if err := c.checkACL(r, creds); err != nil {
+		return nil, fmt.Errorf("access denied")
+	}
+
+	log(r).Info(
+		"user authenticated",
+		zap.String("github_user", meta.User()),
+		zap.String("ssh_fingerprint", fingerprint),
+	)
+
+	return &ssh.Permissions{
+		Extensions: map[string]string{
+			"auth-kind": sshAuthKindGitHub,
+			"pubkey-fp": fingerprint,
+		},
+	}, nil
+}
+
+// authSSHRegisteredKey authenticates public keys registered by users. It
+// returns nil permissions if the key is not registered by the user named by
+// SSH user name.
+func (c *Controller) authSSHRegisteredKey(r *http.Request, meta ssh.ConnMetadata, pubKey ssh.PublicKey) (*ssh.Permissions, error) {
+	fingerprint := ssh.FingerprintSHA256(pubKey)
+	key, err := c.DB.GetSSHKeyByFingerprint(r.Context(), fingerprint)
+	if errors.Is(err, models.ErrSSHKeyNotFound) {
+		return nil, nil
+	} else if err != nil {
+		log(r).Warn("cannot get registered SSH key", zap.Error(err))
+		return nil, err
+	}
+
+	creds, err := c.DB.ListCredentialIDs(r.Context(), key.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !isSSHKeyOwner(meta.User(), key.UserID, creds) {
+		log(r).Debug(
+			"registered SSH key not owned by user",
+			zap.String("user", meta.User()),
+			zap.String("ssh_key", key.ID),
+		)
+		return nil, nil
+	}
+	if err := c.checkACL(r, creds); err != nil {
+		return nil, fmt.Errorf("access denied")
+	}
+
+	log(r).Info(
+		"user authenticated",
+		zap.String("user", key.UserID),
+		zap.String("ssh_key", key.ID),
+		zap.String("ssh_fingerprint", fingerprint),
+	)
+
+	return &ssh.Permissions{
+		Extensions: map[string]string{
+			"auth-kind": sshAuthKindKey,
+			"pubkey-fp": fingerprint,
+			"user-id":   key.UserID,
+			"key-id":    key.ID,
+		},
+	}, nil
+}
+
+// isSSHKeyOwner checks the SSH user name identifies the user, by user ID,
+// GitHub user name or SSH principal.
+func isSSHKeyOwner(name string, userID string, creds []models.CredentialID) bool {
+	if name == userID {
+		return true
+	}
+
+	rule := &config.ACLSubjectRule{GitHubUser: name}
+	for _, cred := range creds {
+		if cred.Matches(rule) || cred == models.CredentialSSHPrincipal(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// authSSHCertificate authenticates SSH certificates signed by trusted CA, with
+// SSH user name as principal.
+func (c *Controller) authSSHCertificate(r *http.Request, meta ssh.ConnMetadata, cert *ssh.Certificate) (*ssh.Permissions, error) {
+	if len(c.Config.SSHUserCAKeys) == 0 {
+		return nil, fmt.Errorf("certificate is not accepted")
+	}
+
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			for _, ca := range c.Config.SSHUserCAKeys {
+				if bytes.Equal(ca.Marshal(), auth.Marshal()) {
+					return true
+				}
+			}
+			return false
+		},
+		Clock: c.Clock.Now,
+	}
+	if _, err := checker.Authenticate(meta, cert); err != nil {
+		log(r).Debug(
+			"user authentication failed",
+			zap.String("principal", meta.User()),
+			zap.String("key_id", cert.KeyId),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+
+	creds := []models.CredentialID{models.CredentialSSHPrincipal(meta.User())}
+	if err := c.checkACL(r, creds); err != nil {
+		return nil, fmt.Errorf("access denied")
+	}
+
+	fingerprint := ssh.FingerprintSHA256(cert.Key)
+	log(r).Info(
+		"user authenticated",
+		zap.String("principal", meta.User()),
+		zap.String("key_id", cert.KeyId),
+		zap.String("ssh_fingerprint", fingerprint),
+	)
+
+	return &ssh.Permissions{
+		Extensions: map[string]string{
+			"auth-kind": sshAuthKindCertificate,
+			"pubkey-fp": fingerprint,
+		},
+	}, nil
+}
+
+func (c *Controller) generateSSHUserToken(ctx context.Context, conn *ssh.ServerConn, withRefreshToken bool) (*api.APIAuthTokens, error) {
+	ext := conn.Permissions.Extensions
+	data := &models.UserCredentialData{KeyFingerprint: ext["pubkey-fp"]}
+
+	switch ext["auth-kind"] {
+	case sshAuthKindGitHub:
+		username := conn.User()
+		return c.generateUserToken(ctx, username, models.CredentialGitHubUser(username), data, withRefreshToken)
+
+	case sshAuthKindCertificate:
+		principal := conn.User()
+		return c.generateUserToken(ctx, principal, models.CredentialSSHPrincipal(principal), data, withRefreshToken)
+
+	case sshAuthKindKey:
+		user, err := c.DB.GetUser(ctx, ext["user-id"])
+		if err != nil {
+			return nil, fmt.Errorf("get user: %w", err)
+		}
+		if err := c.DB.MarkSSHKeyUsed(ctx, ext["key-id"], c.Clock.Now().UTC()); err != nil {
+			return nil, err
+		}
+		return c.issueUserTokens(ctx, user, withRefreshToken)
+
+	default:
+		panic("unexpected auth kind: " + ext["auth-kind"])
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/oidc"
+	"go.uber.org/zap"
+)
+
+func (c *Controller) handleAuthOIDC(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if !bindJSON(w, r, &request) {
+		return
+	}
+
+	unverified := &jwt.RegisteredClaims{}
+	_, _, err := jwt.NewParser().ParseUnverified(request.Token, unverified)
+	if err != nil {
+		writeResponse(w, nil, models.ErrInvalidCredentials)
+		return
+	}
+
+	var issuer *config.OIDCIssuerConfig
+	for i, iss := range c.Config.OIDCIssuers {
+		if iss.Issuer == unverified.Issuer {
+			issuer = &c.Config.OIDCIssuers[i]
+			break
+		}
+	}
+	if issuer == nil {
+		log(r).Debug("untrusted OIDC issuer", zap.String("issuer", unverified.Issuer))
+		writeResponse(w, nil, models.ErrInvalidCredentials)
+		return
+	}
+
+	var key *oidc.Key
+	if issuer.JWKSURL != "" {
+		key, err = c.oidcKeys.GetWithJWKS(issuer.Issuer, issuer.JWKSURL)
+	} else {
+		key, err = c.oidcKeys.Get(issuer.Issuer)
+	}
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	audience := issuer.Audience
+	if audience == "" {
+		audience = c.Config.TokenAuthority
+	}
+	if audience == "" {
+		// Tokens must be bound to this server; never skip audience check.
+		log(r).Warn("missing OIDC audience", zap.String("issuer", issuer.Name))
+		writeResponse(w, nil, models.ErrInvalidCredentials)
+		return
+	}
+
+	oidcClaims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(
+		request.Token,
+		oidcClaims,
+		key.JWKS.Keyfunc,
+		jwt.WithAudience(audience),
+		jwt.WithIssuer(key.Issuer),
+		jwt.WithTimeFunc(c.Clock.Now),
+	)
+	if err != nil {
+		log(r).Debug("invalid OIDC token",
+			zap.String("issuer", issuer.Name),
+			zap.Error(err))
+		writeResponse(w, nil, models.ErrInvalidCredentials)
+		return
+	}
+
+	subject, _ := oidcClaims["sub"].(string)
+	tokenID, _ := oidcClaims["jti"].(string)
+	if tokenID == "" {
+		log(r).Warn("missing OIDC token ID",
+			zap.String("issuer", issuer.Name),
+			zap.String("subject", subject),
+		)
+		writeResponse(w, nil, models.ErrInvalidCredentials)
+		return
+	}
+
+	credentials := mapOIDCCredentials(issuer, oidcClaims)
+	if len(credentials) == 0 {
+		writeResponse(w, nil, models.ErrInvalidCredentials)
+		return
+	}
+
+	if err := c.checkACL(r, credentials); err != nil {
+		writeResponse(w, nil, models.ErrInvalidCredentials)
+		return
+	}
+
+	log(r).Info("OIDC workload authenticated",
+		zap.String("issuer", issuer.Name),
+		zap.String("subject", subject),
+		zap.String("token_id", tokenID),
+		zap.Any("credentials", credentials),
+	)
+
+	claims := models.NewTokenClaims(models.TokenSubjectOIDC(issuer.Name, tokenID), subject)
+	claims.Credentials = credentials
+
+	token, err := c.issueToken(claims)
+	writeResponse(w, token, err)
+}
+
+func mapOIDCCredentials(issuer *config.OIDCIssuerConfig, claims jwt.MapClaims) []models.CredentialID {
+	var credentials []models.CredentialID
+	for _, m := range issuer.Credentials {
+		value, _ := claims[m.Claim].(string)
+		if value == "" {
+			continue
+		}
+
+		switch models.CredentialIDKind(m.Kind) {
+		case models.CredentialIDGitLabProject:
+			credentials = append(credentials, models.CredentialGitLabProject(issuer.Name, value))
+		case models.CredentialIDOIDC:
+			credentials = append(credentials, models.CredentialOIDC(issuer.Name, value))
+		}
+	}
+	return credentials
+}
@@ -0,0 +1,142 @@
+package controller_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/handler/controller"
+	"github.com/oursky/pageship/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestJWKS(t *testing.T) (*httptest.Server, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	}))
+	return server, key
+}
+
+func signOIDCToken(t *testing.T, key *rsa.PrivateKey, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	value, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return value
+}
+
+func authOIDC(c *testutil.TestController, token string) (string, error) {
+	body, _ := json.Marshal(map[string]string{"token": token})
+	req := httptest.NewRequest("POST", "http://localtest.me/api/v1/auth/oidc", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	return testutil.DecodeJSONResponse[string](w.Result())
+}
+
+func TestAuthOIDC(t *testing.T) {
+	jwks, key := newTestJWKS(t)
+	defer jwks.Close()
+
+	const issuer = "https://gitlab.example.com"
+	claims := func(project string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":          issuer,
+			"aud":          "pageship",
+			"sub":          "project_path:" + project + ":ref_type:branch:ref:main",
+			"jti":          "job-" + project,
+			"exp":          time.Now().Add(time.Minute).Unix(),
+			"project_path": project,
+		}
+	}
+
+	setup := func(c *testutil.TestController) {
+		user, _ := c.SigninUser("mock user")
+		c.UpdateConfig(func(conf *controller.Config) {
+			conf.OIDCIssuers = []config.OIDCIssuerConfig{{
+				Name:     "gitlab",
+				Issuer:   issuer,
+				JWKSURL:  jwks.URL,
+				Audience: "pageship",
+				Credentials: []config.OIDCCredentialConfig{
+					{Kind: "gitlab-project", Claim: "project_path"},
+				},
+			}}
+		})
+
+		conf := config.DefaultAppConfig()
+		conf.Team = append(conf.Team, &config.AccessRule{
+			ACLSubjectRule: config.ACLSubjectRule{GitLabProject: "gitlab:oursky/*"},
+			Access:         config.AccessLevelDeployer,
+		})
+		conf.SetDefaults()
+		c.NewApp("test", user, &conf)
+	}
+
+	t.Run("Should authenticate trusted workload", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			setup(c)
+
+			token, err := authOIDC(c, signOIDCToken(t, key, claims("oursky/pageship")))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NoError(t, callAPI(c, token, "GET", "apps/test"))
+
+			token, err = authOIDC(c, signOIDCToken(t, key, claims("other/pageship")))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, 403, errorCode(callAPI(c, token, "GET", "apps/test")))
+		})
+	})
+
+	t.Run("Should reject untrusted tokens", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			setup(c)
+
+			untrusted := claims("oursky/pageship")
+			untrusted["iss"] = "https://forge.example.com"
+			_, err := authOIDC(c, signOIDCToken(t, key, untrusted))
+			assert.Equal(t, 401, errorCode(err))
+
+			wrongAudience := claims("oursky/pageship")
+			wrongAudience["aud"] = "other"
+			_, err = authOIDC(c, signOIDCToken(t, key, wrongAudience))
+			assert.Equal(t, 401, errorCode(err))
+
+			noTokenID := claims("oursky/pageship")
+			delete(noTokenID, "jti")
+			_, err = authOIDC(c, signOIDCToken(t, key, noTokenID))
+			assert.Equal(t, 401, errorCode(err))
+
+			otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+			_, err = authOIDC(c, signOIDCToken(t, otherKey, claims("oursky/pageship")))
+			assert.Equal(t, 401, errorCode(err))
+		})
+	})
+}
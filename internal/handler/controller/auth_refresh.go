@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+	"go.uber.org/zap"
+)
+
+const refreshTokenValidDuration time.Duration = 30 * 24 * time.Hour
+
+// refreshTokenReuseGrace is the period a rotated token can still be used,
+// e.g. when client failed to receive the response of previous refresh, or
+// refreshed concurrently.
+const refreshTokenReuseGrace time.Duration = 30 * time.Second
+
+// generateRefreshToken creates a refresh token for the user. A new token
+// family is started if familyID is empty.
+func (c *Controller) generateRefreshToken(ctx context.Context, q db.RefreshTokensDB, userID string, familyID string) (string, error) {
+	now := c.Clock.Now().UTC()
+	token, value := models.NewRefreshToken(now, userID, familyID, now.Add(refreshTokenValidDuration))
+	if err := q.CreateRefreshToken(ctx, token); err != nil {
+		return "", err
+	}
+	return value, nil
+}
+
+func (c *Controller) handleAuthRefresh(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		RefreshToken string `json:"refreshToken" binding:"required"`
+	}
+	if !bindJSON(w, r, &request) {
+		return
+	}
+
+	respond(w, func() (any, error) {
+		now := c.Clock.Now().UTC()
+		token, err := c.DB.GetRefreshTokenByHash(r.Context(), models.HashAPIToken(request.RefreshToken))
+		if errors.Is(err, models.ErrRefreshTokenNotFound) {
+			return nil, models.ErrInvalidCredentials
+		} else if err != nil {
+			return nil, err
+		}
+
+		if err := token.CheckAlive(now); err != nil {
+			return nil, err
+		}
+
+		info, err := c.handleTokenUser(r, token.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		// Revalidate server ACL, since refresh skips login.
+		if err := c.checkACL(r, info.CredentialIDs); err != nil {
+			return nil, models.ErrInvalidCredentials
+		}
+
+		reused := false
+		refreshToken, err := withTx(r.Context(), c.DB, func(tx db.Tx) (string, error) {
+			err := tx.MarkRefreshTokenUsed(r.Context(), token.ID, now)
+			if errors.Is(err, models.ErrRefreshTokenUsed) {
+				// Allow reuse within grace period; token may also be
+				// used concurrently after we read it.
+				if token.UsedAt != nil && now.Sub(*token.UsedAt) > refreshTokenReuseGrace {
+					reused = true
+					return "", models.ErrInvalidCredentials
+				}
+			} else if err != nil {
+				return "", err
+			}
+
+			return c.generateRefreshToken(r.Context(), tx, token.UserID, token.FamilyID)
+		})()
+		if reused {
+			// Rotated token is reused: the token may be leaked, so revoke
+			// all tokens derived from the same login.
+			log(r).Warn("refresh token reused",
+				zap.String("user", token.UserID),
+				zap.String("family", token.FamilyID),
+			)
+			if err := c.DB.RevokeRefreshTokenFamily(r.Context(), token.FamilyID, now); err != nil {
+				return nil, err
+			}
+			return nil, models.ErrInvalidCredentials
+		} else if err != nil {
+			return nil, err
+		}
+
+		claims := models.NewTokenClaims(models.TokenSubjectUser(info.Subject), info.Name)
+		accessToken, err := c.issueToken(claims)
+		if err != nil {
+			return nil, err
+		}
+
+		return &api.APIAuthTokens{Token: accessToken, RefreshToken: refreshToken}, nil
+	})
+}
+
+func (c *Controller) handleAuthRefreshRevoke(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		RefreshToken string `json:"refreshToken" binding:"required"`
+	}
+	if !bindJSON(w, r, &request) {
+		return
+	}
+
+	respond(w, func() (any, error) {
+		token, err := c.DB.GetRefreshTokenByHash(r.Context(), models.HashAPIToken(request.RefreshToken))
+		if errors.Is(err, models.ErrRefreshTokenNotFound) {
+			return struct{}{}, nil
+		} else if err != nil {
+			return nil, err
+		}
+
+		err = c.DB.RevokeRefreshTokenFamily(r.Context(), token.FamilyID, c.Clock.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+		return struct{}{}, nil
+	})
+}
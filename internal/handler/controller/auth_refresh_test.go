@@ -0,0 +1,114 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRefreshToken(t *testing.T, c *testutil.TestController, user *models.User) string {
+	now := time.Now().UTC()
+	token, value := models.NewRefreshToken(now, user.ID, "", now.Add(time.Hour))
+	if !assert.NoError(t, c.DB.CreateRefreshToken(c.Context, token)) {
+		t.FailNow()
+	}
+	return value
+}
+
+func refreshAuth(c *testutil.TestController, path string, refreshToken string) (*api.APIAuthTokens, error) {
+	body, _ := json.Marshal(map[string]string{"refreshToken": refreshToken})
+	req := httptest.NewRequest("POST", "http://localtest.me/api/v1/"+path, bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	return testutil.DecodeJSONResponse[*api.APIAuthTokens](w.Result())
+}
+
+func TestAuthRefresh(t *testing.T) {
+	t.Run("Should rotate refresh token", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, _ := c.SigninUser("mock user")
+			refreshToken := newRefreshToken(t, c, user)
+
+			tokens, err := refreshAuth(c, "auth/refresh", refreshToken)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NotEqual(t, refreshToken, tokens.RefreshToken)
+			assert.NoError(t, callAPI(c, tokens.Token, "GET", "auth/me"))
+
+			tokens, err = refreshAuth(c, "auth/refresh", tokens.RefreshToken)
+			assert.NoError(t, err)
+			assert.True(t, models.IsRefreshToken(tokens.RefreshToken))
+		})
+	})
+
+	t.Run("Should allow reuse within grace period", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, _ := c.SigninUser("mock user")
+			refreshToken := newRefreshToken(t, c, user)
+
+			tokens1, err := refreshAuth(c, "auth/refresh", refreshToken)
+			if !assert.NoError(t, err) {
+				return
+			}
+			tokens2, err := refreshAuth(c, "auth/refresh", refreshToken)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.NotEqual(t, tokens1.RefreshToken, tokens2.RefreshToken)
+
+			_, err = refreshAuth(c, "auth/refresh", tokens1.RefreshToken)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("Should revoke token family on reuse", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, _ := c.SigninUser("mock user")
+			now := time.Now().UTC()
+			token, refreshToken := models.NewRefreshToken(now.Add(-time.Hour), user.ID, "", now.Add(time.Hour))
+			assert.NoError(t, c.DB.CreateRefreshToken(c.Context, token))
+			assert.NoError(t, c.DB.MarkRefreshTokenUsed(c.Context, token.ID, now.Add(-time.Minute)))
+			rotated, rotatedToken := models.NewRefreshToken(now.Add(-time.Minute), user.ID, token.FamilyID, now.Add(time.Hour))
+			assert.NoError(t, c.DB.CreateRefreshToken(c.Context, rotated))
+			otherToken := newRefreshToken(t, c, user)
+
+			_, err := refreshAuth(c, "auth/refresh", refreshToken)
+			assert.Equal(t, 401, errorCode(err))
+			_, err = refreshAuth(c, "auth/refresh", rotatedToken)
+			assert.Equal(t, 401, errorCode(err))
+
+			// Tokens of other logins are unaffected.
+			_, err = refreshAuth(c, "auth/refresh", otherToken)
+			assert.NoError(t, err)
+		})
+	})
+
+	t.Run("Should reject revoked and expired token", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, _ := c.SigninUser("mock user")
+			refreshToken := newRefreshToken(t, c, user)
+
+			_, err := refreshAuth(c, "auth/refresh/revoke", refreshToken)
+			assert.NoError(t, err)
+			_, err = refreshAuth(c, "auth/refresh", refreshToken)
+			assert.Equal(t, 401, errorCode(err))
+
+			now := time.Now().UTC()
+			expired, value := models.NewRefreshToken(now.Add(-time.Hour), user.ID, "", now.Add(-time.Minute))
+			assert.NoError(t, c.DB.CreateRefreshToken(c.Context, expired))
+			_, err = refreshAuth(c, "auth/refresh", value)
+			assert.Equal(t, 401, errorCode(err))
+
+			_, err = refreshAuth(c, "auth/refresh", "pageship-refresh_unknown")
+			assert.Equal(t, 401, errorCode(err))
+		})
+	})
+}
@@ -9,6 +9,8 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/db"
 	"github.com/oursky/pageship/internal/httputil"
 	"github.com/oursky/pageship/internal/models"
@@ -20,6 +22,7 @@ type authnInfo struct {
 	Name          string
 	IsBot         bool
 	CredentialIDs []models.CredentialID
+	APIToken      *models.APIToken
 }
 
 func (i *authnInfo) UserID() string {
@@ -29,6 +32,21 @@ func (i *authnInfo) UserID() string {
 	return i.Subject
 }
 
+func (i *authnInfo) CheckAuthz(app *models.App, level config.AccessLevel) (*models.AppAuthzResult, error) {
+	authz, err := app.CheckAuthz(level, i.UserID(), i.CredentialIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	if i.APIToken != nil {
+		if err := i.APIToken.CheckScope(app.ID, level); err != nil {
+			return nil, err
+		}
+	}
+
+	return authz, nil
+}
+
 func createUser(
 	ctx context.Context,
 	tx db.Tx,
@@ -87,7 +105,8 @@ func (c *Controller) generateUserToken(
 	name string,
 	credentialID models.CredentialID,
 	data *models.UserCredentialData,
-) (string, error) {
+	withRefreshToken bool,
+) (*api.APIAuthTokens, error) {
 	now := c.Clock.Now().UTC()
 
 	user, err := withTx(ctx, c.DB, func(tx db.Tx) (*models.User, error) {
@@ -107,11 +126,27 @@ func (c *Controller) generateUserToken(
 		return user, nil
 	})()
 	if err != nil {
-		return "", fmt.Errorf("get user: %w", err)
+		return nil, fmt.Errorf("get user: %w", err)
 	}
 
+	return c.issueUserTokens(ctx, user, withRefreshToken)
+}
+
+func (c *Controller) issueUserTokens(ctx context.Context, user *models.User, withRefreshToken bool) (*api.APIAuthTokens, error) {
 	claims := models.NewTokenClaims(models.TokenSubjectUser(user.ID), user.Name)
-	return c.issueToken(claims)
+	token, err := c.issueToken(claims)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := &api.APIAuthTokens{Token: token}
+	if withRefreshToken {
+		tokens.RefreshToken, err = c.generateRefreshToken(ctx, c.DB, user.ID, "")
+		if err != nil {
+			return nil, fmt.Errorf("generate refresh token: %w", err)
+		}
+	}
+	return tokens, nil
 }
 
 func (c *Controller) middlewareAuthn(next http.Handler) http.Handler {
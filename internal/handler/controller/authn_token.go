@@ -29,6 +29,10 @@ func (c *Controller) issueToken(claims *models.TokenClaims) (string, error) {
 }
 
 func (c *Controller) verifyToken(r *http.Request, token string) (*authnInfo, error) {
+	if models.IsAPIToken(token) {
+		return c.handleAPIToken(r, token)
+	}
+
 	claims := &models.TokenClaims{}
 	_, err := jwt.ParseWithClaims(
 		token,
@@ -50,7 +54,7 @@ func (c *Controller) verifyToken(r *http.Request, token string) (*authnInfo, err
 	switch kind {
 	case models.TokenSubjectKindUser:
 		return c.handleTokenUser(r, data)
-	case models.TokenSubjectKindGitHubActions:
+	case models.TokenSubjectKindGitHubActions, models.TokenSubjectKindOIDC:
 		return c.handleTokenGitHubActions(r, claims.Subject, claims.Name, claims.Credentials)
 	default:
 		panic("unexpected kind: " + kind)
@@ -18,7 +18,7 @@ func (c *Controller) requireAccess(level config.AccessLevel) func(next http.Hand
 			}
 
 			app := get[*models.App](r)
-			authz, err := app.CheckAuthz(level, info.UserID(), info.CredentialIDs)
+			authz, err := info.CheckAuthz(app, level)
 			if err != nil {
 				writeResponse(w, nil, err)
 				return
@@ -33,6 +33,7 @@ func (c *Controller) requireAccess(level config.AccessLevel) func(next http.Hand
 			loggers := get[*loggers](r)
 			loggers.Logger = loggers.authn.With(fields...) // Replace authz logger fields
 
+			r = set(r, authz)
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -61,6 +62,28 @@ func denyBot(next http.Handler) http.Handler {
 	})
 }
 
+func denyScopedToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := get[*authnInfo](r)
+		if info.APIToken != nil && info.APIToken.IsScoped() {
+			writeResponse(w, nil, models.ErrAccessDenied)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func denyAPIToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		info := get[*authnInfo](r)
+		if info.APIToken != nil {
+			writeResponse(w, nil, models.ErrAccessDenied)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func requireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		info := get[*authnInfo](r)
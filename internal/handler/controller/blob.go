@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"context"
+	"io"
+
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/oursky/pageship/internal/models"
+	"go.uber.org/zap"
+)
+
+// uploadBlob stores the file content as content-addressed blob, unless an
+// identical blob is already stored for the app.
+func (c *Controller) uploadBlob(
+	ctx context.Context,
+	deployment *models.Deployment,
+	entry models.FileEntry,
+	reader io.Reader,
+) error {
+	now := c.Clock.Now().UTC()
+
+	exists, err := c.DB.UseBlob(ctx, deployment.AppID, entry.Hash, now)
+	if err != nil {
+		return err
+	} else if exists {
+		// Content must still match its hash, so that its size matches the
+		// stored blob.
+		hash := deploy.NewFileHash()
+		if _, err := io.Copy(hash, reader); err != nil {
+			return err
+		}
+		if hash.Sum() != entry.Hash {
+			return deploy.ErrUnexpectedFileHash
+		}
+		return nil
+	}
+
+	// Upload to staging key first; blobs must match its hash.
+	stagingKey := deployment.StorageKeyPrefix + entry.Path
+	defer func() {
+		if err := c.Storage.Delete(ctx, stagingKey); err != nil {
+			c.Logger.Warn("failed to delete staging object", zap.String("key", stagingKey), zap.Error(err))
+		}
+	}()
+
+	hash := deploy.NewFileHash()
+	if err := c.Storage.Upload(ctx, stagingKey, io.TeeReader(reader, hash)); err != nil {
+		return err
+	}
+	if hash.Sum() != entry.Hash {
+		return deploy.ErrUnexpectedFileHash
+	}
+
+	blob := models.NewBlob(now, deployment.AppID, entry.Hash, entry.Size, c.Config.StorageKeyPrefix)
+	if err := c.Storage.Copy(ctx, blob.StorageKey, stagingKey); err != nil {
+		return err
+	}
+
+	return c.DB.CreateBlob(ctx, blob)
+}
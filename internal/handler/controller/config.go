@@ -3,6 +3,7 @@ package controller
 import (
 	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/watch"
+	"golang.org/x/crypto/ssh"
 )
 
 type Config struct {
@@ -14,6 +15,8 @@ type Config struct {
 	TokenAuthority            string
 	TokenSigningKey           []byte
 	ACL                       *watch.File[config.ACL]
+	OIDCIssuers               []config.OIDCIssuerConfig
+	SSHUserCAKeys             []ssh.PublicKey
 	DomainVerificationEnabled bool
 
 	ServerVersion       string
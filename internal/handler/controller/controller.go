@@ -46,6 +46,7 @@ func (c *Controller) Handler() http.Handler {
 	}
 
 	r := chi.NewRouter()
+	r.Use(middlewareTrace)
 	r.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			r = set(r, &loggers{
@@ -60,15 +61,23 @@ func (c *Controller) Handler() http.Handler {
 	r.Route("/api/v1", func(r chi.Router) {
 		r.With(requireAuth).Route("/apps", func(r chi.Router) {
 			r.Get("/", c.handleAppList)
-			r.With(denyBot).Post("/", c.handleAppCreate)
+			r.With(denyBot, denyScopedToken).Post("/", c.handleAppCreate)
 
 			r.With(
 				c.middlewareLoadApp(),
 				c.requireAccessReader(),
 			).Route("/{app-id}", func(r chi.Router) {
 				r.Get("/", c.handleAppGet)
+				r.With(c.requireAccessAdmin()).Delete("/", c.handleAppDelete)
 				r.Get("/config", c.handleAppConfigGet)
 				r.With(c.requireAccessAdmin()).Put("/config", c.handleAppConfigSet)
+				r.With(c.requireAccessAdmin()).Get("/audit", c.handleAuditLogList)
+
+				r.Route("/users", func(r chi.Router) {
+					r.Get("/", c.handleAppUserList)
+					r.With(c.requireAccessAdmin()).Post("/", c.handleAppUserAdd)
+					r.With(c.requireAccessAdmin()).Delete("/{user-id}", c.handleAppUserDelete)
+				})
 
 				r.Route("/sites", func(r chi.Router) {
 					r.Get("/", c.handleSiteList)
@@ -76,6 +85,10 @@ func (c *Controller) Handler() http.Handler {
 
 					r.With(c.middlewareLoadSite()).Route("/{site-name}", func(r chi.Router) {
 						r.With(c.requireAccessDeployer()).Patch("/", c.handleSiteUpdate)
+						r.With(c.requireAccessAdmin()).Delete("/", c.handleSiteDelete)
+						r.With(c.requireAccessDeployer()).Post("/rollback", c.handleSiteRollback)
+						r.Get("/history", c.handleSiteHistory)
+						r.Get("/analytics", c.handleSiteAnalytics)
 					})
 				})
 
@@ -85,6 +98,8 @@ func (c *Controller) Handler() http.Handler {
 
 					r.With(c.middlewareLoadDeployment()).Route("/{deployment-name}", func(r chi.Router) {
 						r.With(c.requireAccessDeployer()).Get("/", c.handleDeploymentGet)
+						r.With(c.requireAccessDeployer()).Delete("/", c.handleDeploymentDelete)
+						r.With(c.requireAccessDeployer()).Get("/tarball", c.handleDeploymentDownload)
 						r.With(c.requireAccessDeployer()).Put("/tarball", c.handleDeploymentUpload)
 					})
 				})
@@ -102,9 +117,24 @@ func (c *Controller) Handler() http.Handler {
 
 		r.With(requireAuth).Get("/manifest", c.handleManifest)
 
+		r.With(requireAuth, denyBot, denyAPIToken).Route("/tokens", func(r chi.Router) {
+			r.Get("/", c.handleAPITokenList)
+			r.Post("/", c.handleAPITokenCreate)
+			r.Delete("/{token-id}", c.handleAPITokenRevoke)
+		})
+
+		r.With(requireAuth, denyBot, denyAPIToken).Route("/keys", func(r chi.Router) {
+			r.Get("/", c.handleSSHKeyList)
+			r.Post("/", c.handleSSHKeyCreate)
+			r.Delete("/{key-id}", c.handleSSHKeyDelete)
+		})
+
 		r.With(requireAuth).Get("/auth/me", c.handleMe)
 		r.Get("/auth/github-ssh", c.handleAuthGithubSSH)
 		r.Post("/auth/github-oidc", c.handleAuthGithubOIDC)
+		r.Post("/auth/oidc", c.handleAuthOIDC)
+		r.Post("/auth/refresh", c.handleAuthRefresh)
+		r.Post("/auth/refresh/revoke", c.handleAuthRefreshRevoke)
 	})
 	return r
 }
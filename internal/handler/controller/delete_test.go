@@ -0,0 +1,155 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func deleteResource(c *testutil.TestController, token string, path string) error {
+	req := httptest.NewRequest("DELETE", "http://localtest.me/api/v1/apps/"+path, nil)
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	_, err := testutil.DecodeJSONResponse[struct{}](w.Result())
+	return err
+}
+
+func TestDelete(t *testing.T) {
+	t.Run("Should not delete active deployment", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+			setupSite(t, c, token, "v1", "v2")
+
+			_, err := activateSite(c, token, "v1")
+			assert.NoError(t, err)
+
+			err = deleteResource(c, token, "test/deployments/v1")
+			if assert.Error(t, err) {
+				assert.Equal(t, 409, err.(api.ServerError).Code)
+			}
+
+			assert.NoError(t, deleteResource(c, token, "test/deployments/v2"))
+			_, err = c.DB.GetDeploymentByName(c.Context, "test", "v2")
+			assert.ErrorIs(t, err, models.ErrDeploymentNotFound)
+		})
+	})
+
+	t.Run("Should delete deployment after site deleted", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+			setupSite(t, c, token, "v1")
+
+			_, err := activateSite(c, token, "v1")
+			assert.NoError(t, err)
+
+			assert.NoError(t, deleteResource(c, token, "test/sites/main"))
+			_, err = c.DB.GetSiteByName(c.Context, "test", "main")
+			assert.ErrorIs(t, err, models.ErrSiteNotFound)
+
+			assert.NoError(t, deleteResource(c, token, "test/deployments/v1"))
+
+			deployments, err := c.DB.ListDeploymentsPendingStorageCleanup(c.Context, 10)
+			assert.NoError(t, err)
+			assert.Len(t, deployments, 1)
+		})
+	})
+
+	t.Run("Should delete analytics of deleted site", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+			setupSite(t, c, token, "v1")
+
+			err := c.DB.AddSiteAnalytics(c.Context, []*models.SiteAnalytics{{
+				AppID:     "test",
+				SiteName:  "main",
+				Hour:      time.Now().UTC().Truncate(time.Hour),
+				Dimension: models.SiteAnalyticsTotal,
+				Requests:  1,
+				Bytes:     100,
+			}})
+			assert.NoError(t, err)
+
+			assert.NoError(t, deleteResource(c, token, "test/sites/main"))
+
+			entries, err := c.DB.ListSiteAnalytics(c.Context, "test", "main", time.Now().Add(-time.Hour*24), time.Now().Add(time.Hour))
+			assert.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	})
+
+	t.Run("Should allow app ID reuse after app deleted", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+			setupSite(t, c, token, "v1")
+
+			_, err := activateSite(c, token, "v1")
+			assert.NoError(t, err)
+			err = c.DB.AddSiteAnalytics(c.Context, []*models.SiteAnalytics{{
+				AppID:     "test",
+				SiteName:  "main",
+				Hour:      time.Now().UTC().Truncate(time.Hour),
+				Dimension: models.SiteAnalyticsTotal,
+				Requests:  1,
+				Bytes:     100,
+			}})
+			assert.NoError(t, err)
+
+			assert.NoError(t, deleteResource(c, token, "test"))
+			_, err = c.DB.GetApp(c.Context, "test")
+			assert.ErrorIs(t, err, models.ErrAppNotFound)
+
+			newUser, newToken := c.SigninUser("new user")
+			setupDeploymentApp(c, newUser)
+
+			_, err = c.DB.GetDeploymentByName(c.Context, "test", "v1")
+			assert.ErrorIs(t, err, models.ErrDeploymentNotFound)
+
+			// Content of deleted app must be uploaded again.
+			files, tarball := packFiles(t, map[string]string{"/index.html": "v1"})
+			deployment := createDeployment(t, c, newToken, "v1", files)
+			if assert.NotNil(t, deployment.MissingHashes) {
+				assert.Len(t, *deployment.MissingHashes, 1)
+			}
+			_, err = uploadDeployment(c, newToken, "v1", tarball)
+			assert.NoError(t, err)
+
+			body, _ := json.Marshal(map[string]any{"name": "main"})
+			req := httptest.NewRequest("POST", "http://localtest.me/api/v1/apps/test/sites", bytes.NewReader(body))
+			req.Header.Add("Authorization", "bearer "+newToken)
+			w := httptest.NewRecorder()
+			c.ServeHTTP(w, req)
+			site, err := testutil.DecodeJSONResponse[*api.APISite](w.Result())
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			activations, err := c.DB.ListSiteActivations(c.Context, site.ID, 10)
+			assert.NoError(t, err)
+			assert.Empty(t, activations)
+
+			stats, err := getSiteAnalytics(c, newToken, "")
+			if assert.NoError(t, err) {
+				assert.Equal(t, int64(0), stats.Requests)
+			}
+
+			logs, err := listAuditLogs(c, newToken, "")
+			if assert.NoError(t, err) {
+				for _, l := range logs {
+					assert.Equal(t, string(models.TokenSubjectUser(newUser.ID)), l.Subject)
+				}
+			}
+		})
+	})
+}
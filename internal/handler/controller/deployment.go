@@ -13,14 +13,16 @@ import (
 	"github.com/oursky/pageship/internal/db"
 	"github.com/oursky/pageship/internal/deploy"
 	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/metrics"
 	"github.com/oursky/pageship/internal/models"
 	"go.uber.org/zap"
 )
 
 type apiDeployment struct {
 	*models.Deployment
-	FirstSiteName *string `json:"siteName"`
-	URL           string  `json:"url,omitempty"`
+	FirstSiteName *string   `json:"siteName"`
+	URL           string    `json:"url,omitempty"`
+	MissingHashes *[]string `json:"missingHashes,omitempty"`
 }
 
 func (c *Controller) makeAPIDeployment(app *models.App, d db.DeploymentInfo) *apiDeployment {
@@ -107,9 +109,37 @@ func (c *Controller) handleDeploymentCreate(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	for _, entry := range files {
+		// Reserved paths would collide with pre-compressed variants.
+		if models.IsReservedPath(entry.Path) {
+			writeJSON(w, http.StatusBadRequest, response{
+				Error: fmt.Errorf("%w: %s", deploy.ErrReservedPath, entry.Path),
+			})
+			return
+		}
+		for _, enc := range entry.Encodings {
+			if !models.IsValidFileEncoding(enc.Encoding) {
+				writeJSON(w, http.StatusBadRequest, response{
+					Error: fmt.Errorf("invalid file encoding: %s", enc.Encoding),
+				})
+				return
+			}
+			// Variants are stored only if smaller than original content.
+			if enc.Size < 0 || enc.Size > entry.Size {
+				writeJSON(w, http.StatusBadRequest, response{
+					Error: fmt.Errorf("invalid file encoding size: %s", entry.Path),
+				})
+				return
+			}
+		}
+	}
+
 	var totalSize int64 = 0
 	for _, entry := range files {
 		totalSize += entry.Size
+		for _, enc := range entry.Encodings {
+			totalSize += enc.Size
+		}
 	}
 	if totalSize > c.Config.MaxDeploymentSize {
 		writeJSON(w, http.StatusBadRequest, response{
@@ -148,17 +178,83 @@ func (c *Controller) handleDeploymentCreate(w http.ResponseWriter, r *http.Reque
 			return nil, err
 		}
 
-		log(r).Info("creating deployment", zap.String("deployment", deployment.ID))
+		// Report content not yet stored, so clients may upload only these.
+		hashes := deployment.BlobHashes()
+		usedSizes, err := tx.UseBlobs(r.Context(), app.ID, hashes, now)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkBlobSizes(files, usedSizes); err != nil {
+			return nil, err
+		}
+		missingHashes := []string{}
+		for _, hash := range hashes {
+			if _, ok := usedSizes[hash]; !ok {
+				missingHashes = append(missingHashes, hash)
+			}
+		}
+
+		log(r).Info("creating deployment",
+			zap.String("deployment", deployment.ID),
+			zap.Int("missing", len(missingHashes)),
+		)
 
-		return c.makeAPIDeployment(app, db.DeploymentInfo{
+		err = c.audit(r, tx, models.AuditActionDeploymentCreate, deployment.Name, models.AuditLogDetails{
+			"deployment": deployment.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result := c.makeAPIDeployment(app, db.DeploymentInfo{
 			Deployment:    deployment,
 			FirstSiteName: nil,
-		}), nil
+		})
+		result.MissingHashes = &missingHashes
+		return result, nil
 	})()
+	if errors.As(err, new(deploy.Error)) {
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
+		return
+	}
 
 	writeResponse(w, deployment, err)
 }
 
+// checkBlobSizes checks the declared file sizes are consistent with the sizes
+// of stored blobs and other files with same content, so that known content
+// cannot be referenced with a different size.
+func checkBlobSizes(files []models.FileEntry, storedSizes map[string]int64) error {
+	sizes := make(map[string]int64, len(storedSizes))
+	for hash, size := range storedSizes {
+		sizes[hash] = size
+	}
+
+	check := func(path string, hash string, size int64) error {
+		if hash == "" {
+			// Directories have no content
+			return nil
+		}
+		if s, ok := sizes[hash]; ok && s != size {
+			return fmt.Errorf("%w: %s", deploy.ErrUnexpectedFileSize, path)
+		}
+		sizes[hash] = size
+		return nil
+	}
+
+	for _, entry := range files {
+		if err := check(entry.Path, entry.Hash, entry.Size); err != nil {
+			return err
+		}
+		for _, enc := range entry.Encodings {
+			if err := check(entry.Path, enc.Hash, enc.Size); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Controller) handleDeploymentUpload(w http.ResponseWriter, r *http.Request) {
 	app := get[*models.App](r)
 	deployment := get[*models.Deployment](r)
@@ -186,9 +282,25 @@ func (c *Controller) handleDeploymentUpload(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	metrics.DeploymentUploadSize.Observe(float64(r.ContentLength))
+
+	// Files with stored content may be omitted from the tarball.
+	knownSizes, err := c.DB.UseBlobs(r.Context(), app.ID, deployment.BlobHashes(), c.Clock.Now().UTC())
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+	isKnown := func(e models.FileEntry) bool {
+		size, ok := knownSizes[e.Hash]
+		return ok && size == e.Size
+	}
+
 	handleFile := func(e models.FileEntry, reader io.Reader) error {
-		key := deployment.StorageKeyPrefix + e.Path
-		return c.Storage.Upload(r.Context(), key, reader)
+		if e.Hash == "" {
+			// Directories have no content
+			return nil
+		}
+		return c.uploadBlob(r.Context(), deployment, e, reader)
 	}
 
 	reader := io.LimitReader(
@@ -199,7 +311,7 @@ func (c *Controller) handleDeploymentUpload(w http.ResponseWriter, r *http.Reque
 		),
 		c.Config.MaxDeploymentSize,
 	)
-	err := deploy.ExtractFiles(reader, deployment.Metadata.Files, handleFile)
+	err = deploy.ExtractFiles(reader, deployment.Metadata.Files, isKnown, handleFile)
 	if errors.As(err, new(deploy.Error)) {
 		writeJSON(w, http.StatusBadRequest, response{Error: err})
 		return
@@ -230,11 +342,31 @@ func (c *Controller) handleDeploymentUpload(w http.ResponseWriter, r *http.Reque
 			return nil, models.ErrDeploymentAlreadyUploaded
 		}
 
+		err = tx.AddDeploymentBlobs(r.Context(), deployment, deployment.BlobHashes())
+		if err != nil {
+			return nil, err
+		}
+
+		blobKeyPrefix := models.BlobKeyPrefix(c.Config.StorageKeyPrefix, app.ID)
+		deployment.BlobKeyPrefix = &blobKeyPrefix
+		deployment.UpdatedAt = now
+		err = tx.SetDeploymentBlobKeyPrefix(r.Context(), deployment)
+		if err != nil {
+			return nil, err
+		}
+
 		err = tx.MarkDeploymentUploaded(r.Context(), now, deployment)
 		if err != nil {
 			return nil, err
 		}
 
+		err = c.audit(r, tx, models.AuditActionDeploymentUpload, deployment.Name, models.AuditLogDetails{
+			"deployment": deployment.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
 		return c.makeAPIDeployment(app, db.DeploymentInfo{
 			Deployment:    deployment,
 			FirstSiteName: nil,
@@ -242,6 +374,36 @@ func (c *Controller) handleDeploymentUpload(w http.ResponseWriter, r *http.Reque
 	}))
 }
 
+func (c *Controller) handleDeploymentDownload(w http.ResponseWriter, r *http.Request) {
+	deployment := get[*models.Deployment](r)
+
+	if deployment.UploadedAt == nil {
+		writeResponse(w, nil, models.ErrDeploymentNotUploaded)
+		return
+	} else if deployment.IsExpired(c.Clock.Now().UTC()) {
+		writeResponse(w, nil, models.ErrDeploymentExpired)
+		return
+	}
+
+	log(r).Info("downloading deployment", zap.String("deployment", deployment.ID))
+
+	// Reconstruct the tarball from stored content
+	open := func(e models.FileEntry) (io.ReadCloser, error) {
+		return c.Storage.OpenRead(r.Context(), deployment.StorageKey(e))
+	}
+
+	w.Header().Set("Content-Type", "application/zstd")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.tar.zst"`, deployment.Name))
+	writer := httputil.NewTimeoutResponseWriter(w, 10*time.Second)
+	files := deploy.TarballEntries(deployment.Metadata.Files)
+	err := deploy.PackFiles(writer, files, *deployment.UploadedAt, open)
+	if err != nil {
+		// Response is already started; abort it so that client sees an error.
+		log(r).Error("failed to download deployment", zap.Error(err))
+		panic(http.ErrAbortHandler)
+	}
+}
+
 func (c *Controller) handleDeploymentList(w http.ResponseWriter, r *http.Request) {
 	app := get[*models.App](r)
 
@@ -261,3 +423,38 @@ func (c *Controller) handleDeploymentList(w http.ResponseWriter, r *http.Request
 		}), nil
 	})
 }
+
+func (c *Controller) handleDeploymentDelete(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+	deployment := get[*models.Deployment](r)
+
+	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+		sites, err := tx.GetDeploymentSiteNames(r.Context(), deployment)
+		if err != nil {
+			return nil, err
+		}
+		if len(sites) > 0 {
+			return nil, fmt.Errorf("%w: used by site %q", models.ErrDeploymentActive, sites[0])
+		}
+
+		// Deleted deployments are removed from storage by cron jobs.
+		err = tx.DeleteDeployment(r.Context(), deployment.ID, c.Clock.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+
+		log(r).Info("deleting deployment",
+			zap.String("app", app.ID),
+			zap.String("deployment", deployment.ID),
+		)
+
+		err = c.audit(r, tx, models.AuditActionDeploymentDelete, deployment.Name, models.AuditLogDetails{
+			"deployment": deployment.ID,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return struct{}{}, nil
+	}))
+}
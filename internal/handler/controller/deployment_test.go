@@ -0,0 +1,505 @@
+package controller_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/oursky/pageship/internal/handler/controller"
+	"github.com/oursky/pageship/internal/models"
+	sitelocal "github.com/oursky/pageship/internal/site/local"
+	"github.com/oursky/pageship/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func collectFiles(t *testing.T, files map[string]string) *deploy.Collector {
+	collector := deploy.NewCollector(time.Now())
+	collector.AddDir("/")
+	for path, content := range files {
+		if err := collector.AddFile(path, []byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return collector
+}
+
+func packTarball(t *testing.T, collector *deploy.Collector, include func(models.FileEntry) bool) []byte {
+	var buf bytes.Buffer
+	if err := collector.Pack(&buf, include); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func packFiles(t *testing.T, files map[string]string) ([]models.FileEntry, []byte) {
+	collector := collectFiles(t, files)
+	tarball := packTarball(t, collector, func(models.FileEntry) bool { return true })
+	return collector.Files(), tarball
+}
+
+func setupDeploymentApp(c *testutil.TestController, user *models.User) {
+	c.UpdateConfig(func(config *controller.Config) {
+		config.MaxDeploymentSize = 10 * 1024 * 1024
+	})
+
+	conf := config.DefaultAppConfig()
+	conf.SetDefaults()
+	c.NewApp("test", user, &conf)
+}
+
+func createDeployment(t *testing.T, c *testutil.TestController, token string, name string, files []models.FileEntry) *api.APIDeployment {
+	body, _ := json.Marshal(map[string]any{
+		"name":        name,
+		"files":       files,
+		"site_config": config.DefaultSiteConfig(),
+	})
+	req := httptest.NewRequest("POST", "http://localtest.me/api/v1/apps/test/deployments", bytes.NewReader(body))
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	deployment, err := testutil.DecodeJSONResponse[*api.APIDeployment](w.Result())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return deployment
+}
+
+func uploadDeployment(c *testutil.TestController, token string, name string, tarball []byte) (*api.APIDeployment, error) {
+	req := httptest.NewRequest("PUT", "http://localtest.me/api/v1/apps/test/deployments/"+name+"/tarball", bytes.NewReader(tarball))
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	return testutil.DecodeJSONResponse[*api.APIDeployment](w.Result())
+}
+
+func TestDeploymentUpload(t *testing.T) {
+	t.Run("Should store files as deduplicated blobs", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			files1, tarball1 := packFiles(t, map[string]string{
+				"/index.html": "hello",
+				"/about.html": "about",
+			})
+			createDeployment(t, c, token, "v1", files1)
+			_, err := uploadDeployment(c, token, "v1", tarball1)
+			assert.NoError(t, err)
+
+			files2, tarball2 := packFiles(t, map[string]string{
+				"/index.html": "hello",
+				"/copy.html":  "hello",
+			})
+			createDeployment(t, c, token, "v2", files2)
+			_, err = uploadDeployment(c, token, "v2", tarball2)
+			assert.NoError(t, err)
+
+			d1, err := c.DB.GetDeploymentByName(c.Context, "test", "v1")
+			assert.NoError(t, err)
+			d2, err := c.DB.GetDeploymentByName(c.Context, "test", "v2")
+			assert.NoError(t, err)
+			if assert.NotNil(t, d1.BlobKeyPrefix) && assert.NotNil(t, d2.BlobKeyPrefix) {
+				assert.Equal(t, *d1.BlobKeyPrefix, *d2.BlobKeyPrefix)
+			}
+			assert.Len(t, d2.BlobHashes(), 1)
+
+			for _, entry := range d2.Metadata.Files {
+				if entry.Hash == "" {
+					continue
+				}
+				reader, err := c.Storage.OpenRead(c.Context, d2.StorageKey(entry))
+				if assert.NoError(t, err) {
+					data, _ := io.ReadAll(reader)
+					reader.Close()
+					assert.Equal(t, "hello", string(data))
+				}
+			}
+		})
+	})
+
+	t.Run("Should store pre-compressed variants", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			html := strings.Repeat("<p>hello</p>", 100)
+			files, tarball := packFiles(t, map[string]string{
+				"/index.html": html,
+				"/small.html": "hello",
+			})
+			for _, entry := range files {
+				switch entry.Path {
+				case "/index.html":
+					if assert.Len(t, entry.Encodings, 2) {
+						assert.Equal(t, "br", entry.Encodings[0].Encoding)
+						assert.Equal(t, "gzip", entry.Encodings[1].Encoding)
+					}
+				case "/small.html":
+					assert.Empty(t, entry.Encodings)
+				}
+			}
+
+			createDeployment(t, c, token, "v1", files)
+			_, err := uploadDeployment(c, token, "v1", tarball)
+			assert.NoError(t, err)
+
+			d, err := c.DB.GetDeploymentByName(c.Context, "test", "v1")
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Len(t, d.BlobHashes(), 4)
+
+			for _, entry := range d.Metadata.Files {
+				if entry.Path != "/index.html" {
+					continue
+				}
+				reader, err := c.Storage.OpenRead(c.Context, d.EncodingStorageKey(entry.Encodings[1]))
+				if assert.NoError(t, err) {
+					gr, err := gzip.NewReader(reader)
+					if assert.NoError(t, err) {
+						data, _ := io.ReadAll(gr)
+						assert.Equal(t, html, string(data))
+					}
+					reader.Close()
+				}
+			}
+		})
+	})
+
+	t.Run("Should reject invalid file encoding", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			files, _ := packFiles(t, map[string]string{"/index.html": "hello"})
+			for i := range files {
+				if files[i].Path == "/index.html" {
+					files[i].Encodings = []models.FileEncoding{{Encoding: "zstd", Size: 1, Hash: "x"}}
+				}
+			}
+			body, _ := json.Marshal(map[string]any{
+				"name":        "v1",
+				"files":       files,
+				"site_config": config.DefaultSiteConfig(),
+			})
+			req := httptest.NewRequest("POST", "http://localtest.me/api/v1/apps/test/deployments", bytes.NewReader(body))
+			req.Header.Add("Authorization", "bearer "+token)
+			w := httptest.NewRecorder()
+			c.ServeHTTP(w, req)
+			_, err := testutil.DecodeJSONResponse[*api.APIDeployment](w.Result())
+			assert.Equal(t, 400, errorCode(err))
+		})
+	})
+
+	t.Run("Should reject file encoding larger than original", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			files, _ := packFiles(t, map[string]string{"/index.html": "hello"})
+			for i := range files {
+				if files[i].Path == "/index.html" {
+					files[i].Encodings = []models.FileEncoding{{Encoding: "br", Size: 6, Hash: "x"}}
+				}
+			}
+			body, _ := json.Marshal(map[string]any{
+				"name":        "v1",
+				"files":       files,
+				"site_config": config.DefaultSiteConfig(),
+			})
+			req := httptest.NewRequest("POST", "http://localtest.me/api/v1/apps/test/deployments", bytes.NewReader(body))
+			req.Header.Add("Authorization", "bearer "+token)
+			w := httptest.NewRecorder()
+			c.ServeHTTP(w, req)
+			_, err := testutil.DecodeJSONResponse[*api.APIDeployment](w.Result())
+			assert.Equal(t, 400, errorCode(err))
+		})
+	})
+
+	t.Run("Should reject files in reserved path", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			err := deploy.NewCollector(time.Now()).AddFile("/.pageship/encoded/x", []byte("hello"))
+			assert.ErrorIs(t, err, deploy.ErrReservedPath)
+			err = deploy.NewCollector(time.Now()).Collect(fstest.MapFS{
+				".pageship/index.html": &fstest.MapFile{Data: []byte("hello")},
+			}, "/")
+			assert.ErrorIs(t, err, deploy.ErrReservedPath)
+
+			files, _ := packFiles(t, map[string]string{"/index.html": "hello"})
+			for i := range files {
+				if files[i].Path == "/index.html" {
+					files[i].Path = "/.pageship/encoded/x"
+				}
+			}
+			body, _ := json.Marshal(map[string]any{
+				"name":        "v1",
+				"files":       files,
+				"site_config": config.DefaultSiteConfig(),
+			})
+			req := httptest.NewRequest("POST", "http://localtest.me/api/v1/apps/test/deployments", bytes.NewReader(body))
+			req.Header.Add("Authorization", "bearer "+token)
+			w := httptest.NewRecorder()
+			c.ServeHTTP(w, req)
+			_, err = testutil.DecodeJSONResponse[*api.APIDeployment](w.Result())
+			assert.Equal(t, 400, errorCode(err))
+		})
+	})
+
+	t.Run("Should count file encodings in deployment size", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			files, _ := packFiles(t, map[string]string{"/index.html": "hello"})
+			for i := range files {
+				if files[i].Path == "/index.html" {
+					files[i].Size = 6 * 1024 * 1024
+					files[i].Encodings = []models.FileEncoding{{Encoding: "br", Size: 5 * 1024 * 1024, Hash: "x"}}
+				}
+			}
+			body, _ := json.Marshal(map[string]any{
+				"name":        "v1",
+				"files":       files,
+				"site_config": config.DefaultSiteConfig(),
+			})
+			req := httptest.NewRequest("POST", "http://localtest.me/api/v1/apps/test/deployments", bytes.NewReader(body))
+			req.Header.Add("Authorization", "bearer "+token)
+			w := httptest.NewRecorder()
+			c.ServeHTTP(w, req)
+			_, err := testutil.DecodeJSONResponse[*api.APIDeployment](w.Result())
+			assert.Equal(t, 400, errorCode(err))
+		})
+	})
+
+	t.Run("Should reject mismatched file hash", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			files, tarball := packFiles(t, map[string]string{"/index.html": "hello"})
+			for i := range files {
+				if files[i].Path == "/index.html" {
+					files[i].Hash = deploy.NewFileHash().Sum()
+				}
+			}
+			createDeployment(t, c, token, "v1", files)
+			_, err := uploadDeployment(c, token, "v1", tarball)
+			if assert.Error(t, err) {
+				assert.Equal(t, 400, err.(api.ServerError).Code)
+			}
+		})
+	})
+
+	t.Run("Should accept partial tarball with missing files only", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			files1, tarball1 := packFiles(t, map[string]string{"/index.html": "hello"})
+			d1 := createDeployment(t, c, token, "v1", files1)
+			if assert.NotNil(t, d1.MissingHashes) {
+				assert.Len(t, *d1.MissingHashes, 1)
+			}
+			_, err := uploadDeployment(c, token, "v1", tarball1)
+			assert.NoError(t, err)
+
+			collector := collectFiles(t, map[string]string{
+				"/index.html": "hello",
+				"/about.html": "about",
+			})
+			d2 := createDeployment(t, c, token, "v2", collector.Files())
+			if !assert.NotNil(t, d2.MissingHashes) || !assert.Len(t, *d2.MissingHashes, 1) {
+				return
+			}
+			missing := (*d2.MissingHashes)[0]
+
+			tarball := packTarball(t, collector, func(entry models.FileEntry) bool {
+				return entry.Hash == missing
+			})
+			_, err = uploadDeployment(c, token, "v2", tarball)
+			assert.NoError(t, err)
+
+			d, err := c.DB.GetDeploymentByName(c.Context, "test", "v2")
+			assert.NoError(t, err)
+			assert.NotNil(t, d.UploadedAt)
+			assert.Len(t, d.BlobHashes(), 2)
+		})
+	})
+
+	t.Run("Should reject partial tarball with unknown files omitted", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			collector := collectFiles(t, map[string]string{"/index.html": "hello"})
+			createDeployment(t, c, token, "v1", collector.Files())
+
+			tarball := packTarball(t, collector, func(models.FileEntry) bool { return false })
+			_, err := uploadDeployment(c, token, "v1", tarball)
+			if assert.Error(t, err) {
+				assert.Equal(t, 400, err.(api.ServerError).Code)
+			}
+		})
+	})
+
+	t.Run("Should reject known content with mismatched size", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			files1, tarball1 := packFiles(t, map[string]string{"/index.html": "hello"})
+			createDeployment(t, c, token, "v1", files1)
+			_, err := uploadDeployment(c, token, "v1", tarball1)
+			assert.NoError(t, err)
+
+			files2, _ := packFiles(t, map[string]string{"/index.html": "hello"})
+			for i := range files2 {
+				if files2[i].Path == "/index.html" {
+					files2[i].Size = 1
+				}
+			}
+			body, _ := json.Marshal(map[string]any{
+				"name":        "v2",
+				"files":       files2,
+				"site_config": config.DefaultSiteConfig(),
+			})
+			req := httptest.NewRequest("POST", "http://localtest.me/api/v1/apps/test/deployments", bytes.NewReader(body))
+			req.Header.Add("Authorization", "bearer "+token)
+			w := httptest.NewRecorder()
+			c.ServeHTTP(w, req)
+			_, err = testutil.DecodeJSONResponse[*api.APIDeployment](w.Result())
+			assert.Equal(t, 400, errorCode(err))
+		})
+	})
+
+	t.Run("Should not treat content stored with different size as known", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			collector := collectFiles(t, map[string]string{"/index.html": "hello"})
+			files := collector.Files()
+			for i := range files {
+				if files[i].Path == "/index.html" {
+					files[i].Size = 1
+				}
+			}
+			createDeployment(t, c, token, "v2", files)
+
+			files1, tarball1 := packFiles(t, map[string]string{"/index.html": "hello"})
+			createDeployment(t, c, token, "v1", files1)
+			_, err := uploadDeployment(c, token, "v1", tarball1)
+			assert.NoError(t, err)
+
+			tarball := packTarball(t, collector, func(models.FileEntry) bool { return false })
+			_, err = uploadDeployment(c, token, "v2", tarball)
+			assert.Equal(t, 400, errorCode(err))
+		})
+	})
+
+	t.Run("Should reject inconsistent sizes of same content", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+
+			files, _ := packFiles(t, map[string]string{
+				"/index.html": "hello",
+				"/copy.html":  "hello",
+			})
+			for i := range files {
+				if files[i].Path == "/copy.html" {
+					files[i].Size = 1
+				}
+			}
+			body, _ := json.Marshal(map[string]any{
+				"name":        "v1",
+				"files":       files,
+				"site_config": config.DefaultSiteConfig(),
+			})
+			req := httptest.NewRequest("POST", "http://localtest.me/api/v1/apps/test/deployments", bytes.NewReader(body))
+			req.Header.Add("Authorization", "bearer "+token)
+			w := httptest.NewRecorder()
+			c.ServeHTTP(w, req)
+			_, err := testutil.DecodeJSONResponse[*api.APIDeployment](w.Result())
+			assert.Equal(t, 400, errorCode(err))
+		})
+	})
+}
+
+func downloadDeployment(c *testutil.TestController, token string, name string) ([]byte, error) {
+	resp := callAPIResponse(c, token, "GET", "apps/test/deployments/"+name+"/tarball")
+	if resp.StatusCode != 200 {
+		_, err := testutil.DecodeJSONResponse[any](resp)
+		return nil, err
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func TestDeploymentDownload(t *testing.T) {
+	testutil.WithTestController(func(c *testutil.TestController) {
+		user, token := c.SigninUser("mock user")
+		setupDeploymentApp(c, user)
+
+		files, tarball := packFiles(t, map[string]string{
+			"/pageship.json":     `{"app": {"id": "test"}, "site": {"public": "public"}}`,
+			"/public/index.html": "hello",
+			"/public/large.html": strings.Repeat("<p>hello</p>", 100),
+		})
+		createDeployment(t, c, token, "v1", files)
+
+		_, err := downloadDeployment(c, token, "v1")
+		assert.Equal(t, 400, errorCode(err))
+
+		_, err = uploadDeployment(c, token, "v1", tarball)
+		assert.NoError(t, err)
+
+		downloaded, err := downloadDeployment(c, token, "v1")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		resolver, err := sitelocal.NewTarballResolver(bytes.NewReader(downloaded))
+		if !assert.NoError(t, err) {
+			return
+		}
+		desc, err := resolver.Resolve(c.Context, "")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, "test", desc.ID)
+		assert.Equal(t, "public", desc.Config.Public)
+
+		info, err := desc.FS.Stat("/public/index.html")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(5), info.Size)
+			assert.Equal(t, "text/html; charset=utf-8", info.ContentType)
+		}
+		info, err = desc.FS.Stat("/")
+		if assert.NoError(t, err) {
+			assert.True(t, info.IsDir)
+		}
+		info, err = desc.FS.Stat("/public/large.html")
+		if assert.NoError(t, err) {
+			assert.Len(t, info.Encodings, 2)
+		}
+
+		reader, err := desc.FS.Open(c.Context, "/public/index.html")
+		if assert.NoError(t, err) {
+			data, _ := io.ReadAll(reader)
+			reader.Close()
+			assert.Equal(t, "hello", string(data))
+		}
+	})
+}
@@ -75,6 +75,14 @@ func (c *Controller) handleDomainCreate(w http.ResponseWriter, r *http.Request)
 			log(r).Info("creating domain verification",
 				zap.String("domain", domainName),
 				zap.String("site", config.Site))
+
+			err = c.audit(r, tx, models.AuditActionDomainActivate, domainName, models.AuditLogDetails{
+				"site":         config.Site,
+				"verification": domainVerification.ID,
+			})
+			if err != nil {
+				return nil, err
+			}
 		} else if domainVerification.WillCheckAt == nil {
 			err := tx.ScheduleDomainVerificationAt(r.Context(), domainVerification.ID, c.Clock.Now().UTC())
 			if err != nil {
@@ -129,6 +137,15 @@ func (c *Controller) createDomain(w http.ResponseWriter, r *http.Request) {
 			zap.String("domain", domain.Domain),
 			zap.String("site", domain.SiteName))
 
+		details := models.AuditLogDetails{"site": domain.SiteName}
+		if replaceApp != "" {
+			details["replacedApp"] = replaceApp
+		}
+		err = c.audit(r, tx, models.AuditActionDomainActivate, domain.Domain, details)
+		if err != nil {
+			return nil, err
+		}
+
 		return c.makeAPIDomain(domain, domainVerification), nil
 	}))
 }
@@ -156,6 +173,13 @@ func (c *Controller) handleDomainDelete(w http.ResponseWriter, r *http.Request)
 			zap.String("domain", domain.Domain),
 			zap.String("site", domain.SiteName))
 
+		err = c.audit(r, tx, models.AuditActionDomainDeactivate, domain.Domain, models.AuditLogDetails{
+			"site": domain.SiteName,
+		})
+		if err != nil {
+			return nil, err
+		}
+
 		return struct{}{}, nil
 	}))
 }
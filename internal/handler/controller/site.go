@@ -2,7 +2,11 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -18,6 +22,20 @@ type apiSite struct {
 	DeploymentName *string `json:"deploymentName"`
 }
 
+type apiSiteActivation struct {
+	*models.SiteActivation
+	DeploymentName         *string `json:"deploymentName"`
+	PreviousDeploymentName *string `json:"previousDeploymentName"`
+}
+
+func (c *Controller) makeAPISiteActivation(a db.SiteActivationInfo) *apiSiteActivation {
+	return &apiSiteActivation{
+		SiteActivation:         a.SiteActivation,
+		DeploymentName:         a.DeploymentName,
+		PreviousDeploymentName: a.PreviousDeploymentName,
+	}
+}
+
 func (c *Controller) makeAPISite(app *models.App, site db.SiteInfo) *apiSite {
 	sub := site.Name
 	if site.Name == app.Config.DefaultSite {
@@ -98,7 +116,16 @@ func (c *Controller) updateDeploymentExpiry(
 		return err
 	}
 
-	if len(sites) == 0 && deployment.ExpireAt == nil {
+	// Keep active and recently active deployments for rollback.
+	active := len(sites) > 0
+	if !active {
+		active, err = tx.IsDeploymentRecentlyActive(ctx, deployment, uint(conf.Deployments.KeepPreviousCount())+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !active && deployment.ExpireAt == nil {
 		deploymentTTL, err := time.ParseDuration(conf.Deployments.TTL)
 		if err != nil {
 			return err
@@ -111,7 +138,7 @@ func (c *Controller) updateDeploymentExpiry(
 		if err != nil {
 			return err
 		}
-	} else if len(sites) > 0 && deployment.ExpireAt != nil {
+	} else if active && deployment.ExpireAt != nil {
 		deployment.ExpireAt = nil
 		deployment.UpdatedAt = now
 		err = tx.SetDeploymentExpiry(ctx, deployment)
@@ -123,69 +150,159 @@ func (c *Controller) updateDeploymentExpiry(
 	return nil
 }
 
+// siteUpdateDeploymentName activates the named deployment for the site. It
+// returns whether the site is changed and the previously active deployment.
 func (c *Controller) siteUpdateDeploymentName(
 	ctx context.Context,
 	tx db.Tx,
 	now time.Time,
-	conf *config.AppConfig,
+	app *models.App,
+	subject string,
+	authz *models.AppAuthzResult,
 	site *models.Site,
 	deploymentName string,
-) error {
+) (changed bool, previous *models.Deployment, err error) {
+	conf := app.Config
+
 	var currentDeployment *models.Deployment
 	if site.DeploymentID != nil {
-		d, err := tx.GetDeployment(ctx, site.AppID, *site.DeploymentID)
+		d, err := tx.GetDeployment(ctx, app.ID, *site.DeploymentID)
 		if err != nil {
-			return err
+			return false, nil, err
 		}
 
 		if d.Name == deploymentName {
 			// Same deployment
-			return nil
+			return false, nil, nil
 		}
 		currentDeployment = d
 	} else if deploymentName == "" {
 		// Same deployment
-		return nil
+		return false, nil, nil
+	}
+
+	recentDeploymentIDs, err := tx.ListRecentSiteDeploymentIDs(ctx, site.ID, uint(conf.Deployments.KeepPreviousCount())+1)
+	if err != nil {
+		return false, nil, err
 	}
 
 	var newDeployment *models.Deployment
 	if deploymentName != "" {
-		d, err := tx.GetDeploymentByName(ctx, site.AppID, deploymentName)
+		d, err := tx.GetDeploymentByName(ctx, app.ID, deploymentName)
 		if err != nil {
-			return err
+			return false, nil, err
 		}
 
 		if err := d.CheckAlive(now); err != nil {
-			return err
+			return false, nil, err
 		}
 
 		site.DeploymentID = &d.ID
 		err = tx.SetSiteDeployment(ctx, site)
 		if err != nil {
-			return err
+			return false, nil, err
 		}
 		newDeployment = d
 	} else {
 		site.DeploymentID = nil
 		err := tx.SetSiteDeployment(ctx, site)
 		if err != nil {
-			return err
+			return false, nil, err
 		}
 		newDeployment = nil
 	}
 
+	var previousDeploymentID *string
+	if currentDeployment != nil {
+		previousDeploymentID = &currentDeployment.ID
+	}
+	activation := models.NewSiteActivation(now, site, previousDeploymentID)
+	activation.Subject = subject
+	activation.CredentialID = string(authz.CredentialID)
+	activation.CredentialRule = authz.MatchedRule()
+
+	err = tx.CreateSiteActivation(ctx, activation)
+	if err != nil {
+		return false, nil, err
+	}
+
+	// Recently active deployments may be no longer kept for rollback.
+	for _, id := range recentDeploymentIDs {
+		if (currentDeployment != nil && id == currentDeployment.ID) ||
+			(newDeployment != nil && id == newDeployment.ID) {
+			continue
+		}
+
+		d, err := tx.GetDeployment(ctx, app.ID, id)
+		if err != nil {
+			return false, nil, err
+		}
+		if err := c.updateDeploymentExpiry(ctx, tx, now, conf, d); err != nil {
+			return false, nil, err
+		}
+	}
+
 	if currentDeployment != nil {
 		if err := c.updateDeploymentExpiry(ctx, tx, now, conf, currentDeployment); err != nil {
-			return err
+			return false, nil, err
 		}
 	}
 	if newDeployment != nil {
 		if err := c.updateDeploymentExpiry(ctx, tx, now, conf, newDeployment); err != nil {
-			return err
+			return false, nil, err
 		}
 	}
 
-	return nil
+	return true, currentDeployment, nil
+}
+
+// updateSiteDeployment activates the named deployment of the site, and
+// records the change in audit log.
+func (c *Controller) updateSiteDeployment(
+	r *http.Request,
+	tx db.Tx,
+	now time.Time,
+	app *models.App,
+	site *models.Site,
+	deploymentName string,
+	logMessage string,
+) error {
+	oldDeployment := ""
+	if site.DeploymentID != nil {
+		oldDeployment = *site.DeploymentID
+	}
+	log(r).Info(logMessage,
+		zap.String("site", site.ID),
+		zap.String("site_name", site.Name),
+		zap.String("old_deployment", oldDeployment),
+		zap.String("new_deployment", deploymentName),
+	)
+
+	changed, previous, err := c.siteUpdateDeploymentName(
+		r.Context(), tx, now, app, getSubject(r), get[*models.AppAuthzResult](r),
+		site, deploymentName,
+	)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return nil
+	}
+
+	details := models.AuditLogDetails{"deployment": deploymentName}
+	if previous != nil {
+		details["previousDeployment"] = previous.Name
+	}
+	return c.audit(r, tx, models.AuditActionSiteUpdate, site.Name, details)
+}
+
+func (c *Controller) getAPISite(ctx context.Context, tx db.Tx, app *models.App, site *models.Site) (*apiSite, error) {
+	info, err := tx.GetSiteInfo(ctx, app.ID, site.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.makeAPISite(app, *info), nil
 }
 
 func (c *Controller) handleSiteUpdate(w http.ResponseWriter, r *http.Request) {
@@ -203,27 +320,268 @@ func (c *Controller) handleSiteUpdate(w http.ResponseWriter, r *http.Request) {
 
 	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
 		if request.DeploymentName != nil {
-			oldDeployment := ""
-			if site.DeploymentID != nil {
-				oldDeployment = *site.DeploymentID
+			err := c.updateSiteDeployment(r, tx, now, app, site, *request.DeploymentName, "updating site deployment")
+			if err != nil {
+				return nil, err
 			}
-			log(r).Info("updating site deployment",
-				zap.String("site", site.ID),
-				zap.String("site_name", site.Name),
-				zap.String("old_deployment", oldDeployment),
-				zap.String("new_deployment", *request.DeploymentName),
-			)
-
-			if err := c.siteUpdateDeploymentName(r.Context(), tx, now, app.Config, site, *request.DeploymentName); err != nil {
+		}
+
+		return c.getAPISite(r.Context(), tx, app, site)
+	}))
+}
+
+func (c *Controller) handleSiteRollback(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+	site := get[*models.Site](r)
+
+	var request struct {
+		DeploymentName *string `json:"deploymentName,omitempty" binding:"omitempty,dnsLabel"`
+	}
+	if !bindJSON(w, r, &request) {
+		return
+	}
+
+	now := c.Clock.Now().UTC()
+
+	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+		deploymentName := ""
+		if request.DeploymentName != nil {
+			deploymentName = *request.DeploymentName
+		} else {
+			// Default to the previously active deployment
+			ids, err := tx.ListRecentSiteDeploymentIDs(r.Context(), site.ID, 2)
+			if err != nil {
 				return nil, err
 			}
+			for _, id := range ids {
+				if site.DeploymentID != nil && id == *site.DeploymentID {
+					continue
+				}
+
+				d, err := tx.GetDeployment(r.Context(), app.ID, id)
+				if err != nil {
+					return nil, err
+				}
+				deploymentName = d.Name
+				break
+			}
+			if deploymentName == "" {
+				return nil, models.ErrNoPreviousDeployment
+			}
 		}
 
-		info, err := tx.GetSiteInfo(r.Context(), app.ID, site.ID)
+		err := c.updateSiteDeployment(r, tx, now, app, site, deploymentName, "rolling back site deployment")
 		if err != nil {
 			return nil, err
 		}
 
-		return c.makeAPISite(app, *info), nil
+		return c.getAPISite(r.Context(), tx, app, site)
 	}))
 }
+
+const (
+	siteHistoryDefaultLimit = 20
+	siteHistoryMaxLimit     = 100
+)
+
+func (c *Controller) handleSiteHistory(w http.ResponseWriter, r *http.Request) {
+	site := get[*models.Site](r)
+
+	limit, ok := parseLimit(w, r, siteHistoryDefaultLimit, siteHistoryMaxLimit)
+	if !ok {
+		return
+	}
+
+	respond(w, func() (any, error) {
+		activations, err := c.DB.ListSiteActivations(r.Context(), site.ID, uint(limit))
+		if err != nil {
+			return nil, err
+		}
+
+		return mapModels(activations, c.makeAPISiteActivation), nil
+	})
+}
+
+func (c *Controller) handleSiteDelete(w http.ResponseWriter, r *http.Request) {
+	app := get[*models.App](r)
+	site := get[*models.Site](r)
+
+	now := c.Clock.Now().UTC()
+
+	respond(w, withTx(r.Context(), c.DB, func(tx db.Tx) (any, error) {
+		deploymentIDs, err := tx.ListRecentSiteDeploymentIDs(r.Context(), site.ID, uint(app.Config.Deployments.KeepPreviousCount())+1)
+		if err != nil {
+			return nil, err
+		}
+		if site.DeploymentID != nil {
+			deploymentIDs = append(deploymentIDs, *site.DeploymentID)
+		}
+
+		if err := tx.DeleteSite(r.Context(), site.ID, now); err != nil {
+			return nil, err
+		}
+		// Analytics are keyed by site name; recreated site starts afresh.
+		if err := tx.DeleteSiteAnalyticsBySite(r.Context(), app.ID, site.Name); err != nil {
+			return nil, err
+		}
+
+		log(r).Info("deleting site",
+			zap.String("site", site.ID),
+			zap.String("site_name", site.Name),
+		)
+
+		if err := c.audit(r, tx, models.AuditActionSiteDelete, site.Name, nil); err != nil {
+			return nil, err
+		}
+
+		// Deployments of the site are no longer kept.
+		for _, id := range deploymentIDs {
+			d, err := tx.GetDeployment(r.Context(), app.ID, id)
+			if errors.Is(err, models.ErrDeploymentNotFound) {
+				continue
+			} else if err != nil {
+				return nil, err
+			}
+
+			if err := c.updateDeploymentExpiry(r.Context(), tx, now, app.Config, d); err != nil {
+				return nil, err
+			}
+		}
+
+		return struct{}{}, nil
+	}))
+}
+
+const (
+	siteAnalyticsDefaultHours = 24
+	siteAnalyticsMaxHours     = 24 * 31
+	siteAnalyticsTopLimit     = 10
+)
+
+type apiSiteAnalytics struct {
+	From         time.Time                `json:"from"`
+	To           time.Time                `json:"to"`
+	Requests     int64                    `json:"requests"`
+	Bytes        int64                    `json:"bytes"`
+	Hours        []apiSiteAnalyticsHour   `json:"hours"`
+	TopPaths     []apiSiteAnalyticsCounts `json:"topPaths"`
+	TopReferrers []apiSiteAnalyticsCounts `json:"topReferrers"`
+}
+
+type apiSiteAnalyticsHour struct {
+	Hour     time.Time        `json:"hour"`
+	Requests int64            `json:"requests"`
+	Bytes    int64            `json:"bytes"`
+	Statuses map[string]int64 `json:"statuses"`
+}
+
+type apiSiteAnalyticsCounts struct {
+	Value    string `json:"value"`
+	Requests int64  `json:"requests"`
+	Bytes    int64  `json:"bytes"`
+}
+
+func (c *Controller) handleSiteAnalytics(w http.ResponseWriter, r *http.Request) {
+	site := get[*models.Site](r)
+
+	hours := siteAnalyticsDefaultHours
+	if value := r.URL.Query().Get("hours"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > siteAnalyticsMaxHours {
+			writeJSON(w, http.StatusBadRequest, response{
+				Error: fmt.Errorf("invalid hours: must be between 1 and %d", siteAnalyticsMaxHours),
+			})
+			return
+		}
+		hours = n
+	}
+
+	to := c.Clock.Now().UTC().Truncate(time.Hour).Add(time.Hour)
+	from := to.Add(-time.Duration(hours) * time.Hour)
+
+	respond(w, func() (any, error) {
+		entries, err := c.DB.ListSiteAnalytics(r.Context(), site.AppID, site.Name, from, to)
+		if err != nil {
+			return nil, err
+		}
+
+		return makeAPISiteAnalytics(from, to, entries), nil
+	})
+}
+
+func makeAPISiteAnalytics(from time.Time, to time.Time, entries []*models.SiteAnalytics) *apiSiteAnalytics {
+	result := &apiSiteAnalytics{
+		From:         from,
+		To:           to,
+		Hours:        []apiSiteAnalyticsHour{},
+		TopPaths:     []apiSiteAnalyticsCounts{},
+		TopReferrers: []apiSiteAnalyticsCounts{},
+	}
+
+	hours := make(map[time.Time]*apiSiteAnalyticsHour)
+	getHour := func(t time.Time) *apiSiteAnalyticsHour {
+		t = t.UTC()
+		h, ok := hours[t]
+		if !ok {
+			h = &apiSiteAnalyticsHour{Hour: t, Statuses: make(map[string]int64)}
+			hours[t] = h
+		}
+		return h
+	}
+	paths := make(map[string]*apiSiteAnalyticsCounts)
+	referrers := make(map[string]*apiSiteAnalyticsCounts)
+	addCounts := func(m map[string]*apiSiteAnalyticsCounts, e *models.SiteAnalytics) {
+		counts, ok := m[e.Value]
+		if !ok {
+			counts = &apiSiteAnalyticsCounts{Value: e.Value}
+			m[e.Value] = counts
+		}
+		counts.Requests += e.Requests
+		counts.Bytes += e.Bytes
+	}
+
+	for _, e := range entries {
+		switch e.Dimension {
+		case models.SiteAnalyticsTotal:
+			h := getHour(e.Hour)
+			h.Requests += e.Requests
+			h.Bytes += e.Bytes
+			result.Requests += e.Requests
+			result.Bytes += e.Bytes
+		case models.SiteAnalyticsStatus:
+			getHour(e.Hour).Statuses[e.Value] += e.Requests
+		case models.SiteAnalyticsPath:
+			addCounts(paths, e)
+		case models.SiteAnalyticsReferrer:
+			addCounts(referrers, e)
+		}
+	}
+
+	for _, h := range hours {
+		result.Hours = append(result.Hours, *h)
+	}
+	sort.Slice(result.Hours, func(i, j int) bool {
+		return result.Hours[i].Hour.Before(result.Hours[j].Hour)
+	})
+	result.TopPaths = topSiteAnalyticsCounts(paths)
+	result.TopReferrers = topSiteAnalyticsCounts(referrers)
+
+	return result
+}
+
+func topSiteAnalyticsCounts(m map[string]*apiSiteAnalyticsCounts) []apiSiteAnalyticsCounts {
+	list := make([]apiSiteAnalyticsCounts, 0, len(m))
+	for _, counts := range m {
+		list = append(list, *counts)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Requests != list[j].Requests {
+			return list[i].Requests > list[j].Requests
+		}
+		return list[i].Value < list[j].Value
+	})
+	if len(list) > siteAnalyticsTopLimit {
+		list = list[:siteAnalyticsTopLimit]
+	}
+	return list
+}
@@ -0,0 +1,259 @@
+package controller_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/handler/controller"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSite(t *testing.T, c *testutil.TestController, token string, deploymentNames ...string) {
+	for _, name := range deploymentNames {
+		files, tarball := packFiles(t, map[string]string{"/index.html": name})
+		createDeployment(t, c, token, name, files)
+		_, err := uploadDeployment(c, token, name, tarball)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+	}
+
+	body, _ := json.Marshal(map[string]any{"name": "main"})
+	req := httptest.NewRequest("POST", "http://localtest.me/api/v1/apps/test/sites", bytes.NewReader(body))
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	_, err := testutil.DecodeJSONResponse[*api.APISite](w.Result())
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+}
+
+func activateSite(c *testutil.TestController, token string, deploymentName string) (*api.APISite, error) {
+	body, _ := json.Marshal(api.SitePatchRequest{DeploymentName: &deploymentName})
+	req := httptest.NewRequest("PATCH", "http://localtest.me/api/v1/apps/test/sites/main", bytes.NewReader(body))
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	return testutil.DecodeJSONResponse[*api.APISite](w.Result())
+}
+
+func rollbackSite(c *testutil.TestController, token string, request api.SiteRollbackRequest) (*api.APISite, error) {
+	body, _ := json.Marshal(request)
+	req := httptest.NewRequest("POST", "http://localtest.me/api/v1/apps/test/sites/main/rollback", bytes.NewReader(body))
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	return testutil.DecodeJSONResponse[*api.APISite](w.Result())
+}
+
+func getDeployment(t *testing.T, c *testutil.TestController, name string) *models.Deployment {
+	d, err := c.DB.GetDeploymentByName(c.Context, "test", name)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	return d
+}
+
+func TestSiteRollback(t *testing.T) {
+	t.Run("Should rollback to previous deployment", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			setupDeploymentApp(c, user)
+			setupSite(t, c, token, "v1", "v2", "v3")
+
+			_, err := rollbackSite(c, token, api.SiteRollbackRequest{})
+			if assert.Error(t, err) {
+				assert.Equal(t, 400, err.(api.ServerError).Code)
+			}
+
+			for _, name := range []string{"v1", "v2", "v3"} {
+				_, err := activateSite(c, token, name)
+				assert.NoError(t, err)
+			}
+
+			site, err := rollbackSite(c, token, api.SiteRollbackRequest{})
+			if assert.NoError(t, err) && assert.NotNil(t, site.DeploymentName) {
+				assert.Equal(t, "v2", *site.DeploymentName)
+			}
+			assert.Nil(t, getDeployment(t, c, "v3").ExpireAt)
+
+			to := "v1"
+			site, err = rollbackSite(c, token, api.SiteRollbackRequest{DeploymentName: &to})
+			if assert.NoError(t, err) && assert.NotNil(t, site.DeploymentName) {
+				assert.Equal(t, "v1", *site.DeploymentName)
+			}
+		})
+	})
+
+	t.Run("Should keep recent deployments only", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			c.UpdateConfig(func(config *controller.Config) {
+				config.MaxDeploymentSize = 10 * 1024 * 1024
+			})
+			conf := config.DefaultAppConfig()
+			keepPrevious := 1
+			conf.Deployments.KeepPrevious = &keepPrevious
+			conf.SetDefaults()
+			c.NewApp("test", user, &conf)
+			setupSite(t, c, token, "v1", "v2", "v3")
+
+			_, err := activateSite(c, token, "v1")
+			assert.NoError(t, err)
+			_, err = activateSite(c, token, "v2")
+			assert.NoError(t, err)
+			assert.Nil(t, getDeployment(t, c, "v1").ExpireAt)
+			assert.Nil(t, getDeployment(t, c, "v2").ExpireAt)
+			assert.NotNil(t, getDeployment(t, c, "v3").ExpireAt)
+
+			_, err = activateSite(c, token, "v3")
+			assert.NoError(t, err)
+			assert.NotNil(t, getDeployment(t, c, "v1").ExpireAt)
+			assert.Nil(t, getDeployment(t, c, "v2").ExpireAt)
+			assert.Nil(t, getDeployment(t, c, "v3").ExpireAt)
+		})
+	})
+
+	t.Run("Should keep no previous deployments if configured", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			c.UpdateConfig(func(config *controller.Config) {
+				config.MaxDeploymentSize = 10 * 1024 * 1024
+			})
+			conf := config.DefaultAppConfig()
+			keepPrevious := 0
+			conf.Deployments.KeepPrevious = &keepPrevious
+			conf.SetDefaults()
+			c.NewApp("test", user, &conf)
+			setupSite(t, c, token, "v1", "v2")
+
+			_, err := activateSite(c, token, "v1")
+			assert.NoError(t, err)
+			_, err = activateSite(c, token, "v2")
+			assert.NoError(t, err)
+			assert.NotNil(t, getDeployment(t, c, "v1").ExpireAt)
+			assert.Nil(t, getDeployment(t, c, "v2").ExpireAt)
+		})
+	})
+}
+
+func TestSiteHistory(t *testing.T) {
+	testutil.WithTestController(func(c *testutil.TestController) {
+		user, token := c.SigninUser("mock user")
+		setupDeploymentApp(c, user)
+		setupSite(t, c, token, "v1", "v2")
+
+		_, err := activateSite(c, token, "v1")
+		assert.NoError(t, err)
+		_, err = activateSite(c, token, "v2")
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest("GET", "http://localtest.me/api/v1/apps/test/sites/main/history", nil)
+		req.Header.Add("Authorization", "bearer "+token)
+		w := httptest.NewRecorder()
+		c.ServeHTTP(w, req)
+		history, err := testutil.DecodeJSONResponse[[]api.APISiteActivation](w.Result())
+		if !assert.NoError(t, err) || !assert.Len(t, history, 2) {
+			return
+		}
+
+		latest := history[0]
+		if assert.NotNil(t, latest.DeploymentName) && assert.NotNil(t, latest.PreviousDeploymentName) {
+			assert.Equal(t, "v2", *latest.DeploymentName)
+			assert.Equal(t, "v1", *latest.PreviousDeploymentName)
+		}
+		assert.Equal(t, string(models.TokenSubjectUser(user.ID)), latest.Subject)
+		assert.Equal(t, "<owner>", latest.CredentialRule)
+		assert.Nil(t, history[1].PreviousDeploymentName)
+
+		req = httptest.NewRequest("GET", "http://localtest.me/api/v1/apps/test/sites/main/history?limit=1", nil)
+		req.Header.Add("Authorization", "bearer "+token)
+		w = httptest.NewRecorder()
+		c.ServeHTTP(w, req)
+		history, err = testutil.DecodeJSONResponse[[]api.APISiteActivation](w.Result())
+		assert.NoError(t, err)
+		assert.Len(t, history, 1)
+	})
+}
+
+func getSiteAnalytics(c *testutil.TestController, token string, query string) (*api.APISiteAnalytics, error) {
+	req := httptest.NewRequest("GET", "http://localtest.me/api/v1/apps/test/sites/main/analytics"+query, nil)
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	return testutil.DecodeJSONResponse[*api.APISiteAnalytics](w.Result())
+}
+
+func TestSiteAnalytics(t *testing.T) {
+	testutil.WithTestController(func(c *testutil.TestController) {
+		user, token := c.SigninUser("mock user")
+		setupDeploymentApp(c, user)
+		setupSite(t, c, token, "v1")
+
+		hour := time.Now().UTC().Truncate(time.Hour)
+		entry := func(hour time.Time, dim models.SiteAnalyticsDimension, value string, requests int64) *models.SiteAnalytics {
+			return &models.SiteAnalytics{
+				AppID:     "test",
+				SiteName:  "main",
+				Hour:      hour,
+				Dimension: dim,
+				Value:     value,
+				Requests:  requests,
+				Bytes:     requests * 100,
+			}
+		}
+		for i := 0; i < 2; i++ {
+			err := c.DB.AddSiteAnalytics(c.Context, []*models.SiteAnalytics{
+				entry(hour, models.SiteAnalyticsTotal, "", 3),
+				entry(hour, models.SiteAnalyticsStatus, "200", 2),
+				entry(hour, models.SiteAnalyticsStatus, "404", 1),
+				entry(hour, models.SiteAnalyticsPath, "/", 2),
+				entry(hour, models.SiteAnalyticsPath, "/missing", 1),
+				entry(hour, models.SiteAnalyticsReferrer, "example.com", 1),
+				entry(hour.Add(-time.Hour), models.SiteAnalyticsTotal, "", 1),
+				entry(hour.Add(-time.Hour), models.SiteAnalyticsPath, "/missing", 1),
+				entry(hour.Add(-48*time.Hour), models.SiteAnalyticsTotal, "", 10),
+			})
+			assert.NoError(t, err)
+		}
+
+		stats, err := getSiteAnalytics(c, token, "")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, int64(8), stats.Requests)
+		assert.Equal(t, int64(800), stats.Bytes)
+		if assert.Len(t, stats.Hours, 2) {
+			assert.True(t, stats.Hours[0].Hour.Equal(hour.Add(-time.Hour)))
+			assert.Equal(t, int64(2), stats.Hours[0].Requests)
+			assert.True(t, stats.Hours[1].Hour.Equal(hour))
+			assert.Equal(t, int64(6), stats.Hours[1].Requests)
+			assert.Equal(t, map[string]int64{"200": 4, "404": 2}, stats.Hours[1].Statuses)
+		}
+		assert.Equal(t, []api.APISiteAnalyticsCounts{
+			{Value: "/", Requests: 4, Bytes: 400},
+			{Value: "/missing", Requests: 4, Bytes: 400},
+		}, stats.TopPaths)
+		assert.Equal(t, []api.APISiteAnalyticsCounts{
+			{Value: "example.com", Requests: 2, Bytes: 200},
+		}, stats.TopReferrers)
+
+		stats, err = getSiteAnalytics(c, token, "?hours=72")
+		if assert.NoError(t, err) {
+			assert.Equal(t, int64(28), stats.Requests)
+		}
+
+		_, err = getSiteAnalytics(c, token, "?hours=0")
+		if assert.Error(t, err) {
+			assert.Equal(t, 400, err.(api.ServerError).Code)
+		}
+	})
+}
@@ -0,0 +1,88 @@
+package controller
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oursky/pageship/internal/models"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/ssh"
+)
+
+func (c *Controller) handleSSHKeyList(w http.ResponseWriter, r *http.Request) {
+	respond(w, func() (any, error) {
+		return c.DB.ListSSHKeys(r.Context(), getSubject(r))
+	})
+}
+
+func (c *Controller) handleSSHKeyCreate(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Name      string `json:"name" binding:"required,max=100"`
+		PublicKey string `json:"publicKey" binding:"required,max=16384"`
+		Signature string `json:"signature" binding:"required,max=16384"`
+	}
+	if !bindJSON(w, r, &request) {
+		return
+	}
+
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(request.PublicKey))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Error: fmt.Errorf("invalid public key: %w", err)})
+		return
+	}
+	if _, ok := pubKey.(*ssh.Certificate); ok {
+		// Certificates are verified against trusted CA instead.
+		writeJSON(w, http.StatusBadRequest, response{Error: errors.New("certificate cannot be registered")})
+		return
+	}
+
+	// Require proof of possession, so that keys of others cannot be
+	// registered.
+	if err := verifySSHKeyProof(pubKey, getSubject(r), request.Signature); err != nil {
+		writeJSON(w, http.StatusBadRequest, response{Error: fmt.Errorf("invalid signature: %w", err)})
+		return
+	}
+
+	key := models.NewSSHKey(c.Clock.Now().UTC(), getSubject(r), request.Name, pubKey)
+	err = c.DB.CreateSSHKey(r.Context(), key)
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	log(r).Info("registered SSH key",
+		zap.String("key", key.ID),
+		zap.String("fingerprint", key.Fingerprint),
+	)
+	writeResponse(w, key, nil)
+}
+
+func (c *Controller) handleSSHKeyDelete(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "key-id")
+
+	err := c.DB.DeleteSSHKey(r.Context(), getSubject(r), id, c.Clock.Now().UTC())
+	if err != nil {
+		writeResponse(w, nil, err)
+		return
+	}
+
+	log(r).Info("removed SSH key", zap.String("key", id))
+	writeResponse(w, struct{}{}, nil)
+}
+
+func verifySSHKeyProof(pubKey ssh.PublicKey, userID string, signature string) error {
+	data, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return err
+	}
+
+	var sig ssh.Signature
+	if err := ssh.Unmarshal(data, &sig); err != nil {
+		return err
+	}
+
+	return pubKey.Verify(models.SSHKeyProofData(userID), &sig)
+}
@@ -0,0 +1,234 @@
+package controller_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/api"
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/handler/controller"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/testutil"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func newSSHSigner(t *testing.T) ssh.Signer {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromSigner(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func signSSHKeyProof(t *testing.T, signer ssh.Signer, userID string) string {
+	sig, err := signer.Sign(rand.Reader, models.SSHKeyProofData(userID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(ssh.Marshal(sig))
+}
+
+func addSSHKey(c *testutil.TestController, token string, name string, pubKey ssh.PublicKey, signature string) (*models.SSHKey, error) {
+	body, _ := json.Marshal(api.SSHKeyAddRequest{
+		Name:      name,
+		PublicKey: strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pubKey))),
+		Signature: signature,
+	})
+	req := httptest.NewRequest("POST", "http://localtest.me/api/v1/keys", bytes.NewReader(body))
+	req.Header.Add("Authorization", "bearer "+token)
+	w := httptest.NewRecorder()
+	c.ServeHTTP(w, req)
+	return testutil.DecodeJSONResponse[*models.SSHKey](w.Result())
+}
+
+func loginSSH(c *testutil.TestController, user string, signer ssh.Signer) (*api.APIAuthTokens, error) {
+	server := httptest.NewServer(c)
+	defer server.Close()
+
+	ws, err := api.NewClient(server.URL).OpenAuthGitHubSSH(context.Background())
+	if err != nil {
+		return nil, err
+	}
+
+	conn, _, _, err := ssh.NewClientConn(ws, "", &ssh.ClientConfig{
+		User:            user,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	_, reply, err := conn.SendRequest("pageship", true, []byte("refresh-token"))
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens api.APIAuthTokens
+	if err := json.Unmarshal(reply, &tokens); err != nil {
+		return nil, err
+	}
+	return &tokens, nil
+}
+
+func TestSSHKeys(t *testing.T) {
+	t.Run("Should manage registered keys", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			signer := newSSHSigner(t)
+
+			key, err := addSSHKey(c, token, "laptop", signer.PublicKey(), signSSHKeyProof(t, signer, user.ID))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, ssh.FingerprintSHA256(signer.PublicKey()), key.Fingerprint)
+
+			other, otherToken := c.SigninUser("other user")
+			_, err = addSSHKey(c, otherToken, "copy", signer.PublicKey(), signSSHKeyProof(t, signer, other.ID))
+			assert.Equal(t, 409, errorCode(err))
+
+			keys, err := testutil.DecodeJSONResponse[[]*models.SSHKey](callAPIResponse(c, token, "GET", "keys"))
+			if assert.NoError(t, err) && assert.Len(t, keys, 1) {
+				assert.Equal(t, "laptop", keys[0].Name)
+			}
+
+			assert.Equal(t, 404, errorCode(callAPI(c, otherToken, "DELETE", "keys/"+key.ID)))
+			assert.NoError(t, callAPI(c, token, "DELETE", "keys/"+key.ID))
+			assert.Equal(t, 404, errorCode(callAPI(c, token, "DELETE", "keys/"+key.ID)))
+		})
+	})
+
+	t.Run("Should login with registered key", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			signer := newSSHSigner(t)
+
+			_, err := addSSHKey(c, token, "laptop", signer.PublicKey(), signSSHKeyProof(t, signer, user.ID))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			tokens, err := loginSSH(c, user.ID, signer)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.True(t, models.IsRefreshToken(tokens.RefreshToken))
+
+			me, err := testutil.DecodeJSONResponse[*api.APIUser](callAPIResponse(c, tokens.Token, "GET", "auth/me"))
+			if assert.NoError(t, err) {
+				assert.Equal(t, user.ID, me.ID)
+			}
+
+			keys, err := c.DB.ListSSHKeys(c.Context, user.ID)
+			if assert.NoError(t, err) && assert.Len(t, keys, 1) {
+				assert.NotNil(t, keys[0].LastUsedAt)
+			}
+		})
+	})
+
+	t.Run("Should require proof of possession of key", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, _ := c.SigninUser("mock user")
+			other, otherToken := c.SigninUser("other user")
+			signer := newSSHSigner(t)
+
+			_, err := addSSHKey(c, otherToken, "stolen", signer.PublicKey(), "")
+			assert.Equal(t, 400, errorCode(err))
+			_, err = addSSHKey(c, otherToken, "stolen", signer.PublicKey(), signSSHKeyProof(t, signer, user.ID))
+			assert.Equal(t, 400, errorCode(err))
+			_, err = addSSHKey(c, otherToken, "stolen", signer.PublicKey(), signSSHKeyProof(t, newSSHSigner(t), other.ID))
+			assert.Equal(t, 400, errorCode(err))
+		})
+	})
+
+	t.Run("Should login with registered key only as key owner", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			user, token := c.SigninUser("mock user")
+			cred := models.NewUserCredential(time.Now(), user.ID, models.CredentialGitHubUser("mock-user"), &models.UserCredentialData{})
+			if !assert.NoError(t, db.WithTx(c.Context, c.DB, func(tx db.Tx) error {
+				return tx.AddCredential(c.Context, cred)
+			})) {
+				return
+			}
+
+			signer := newSSHSigner(t)
+			_, err := addSSHKey(c, token, "laptop", signer.PublicKey(), signSSHKeyProof(t, signer, user.ID))
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			tokens, err := loginSSH(c, "Mock-User", signer)
+			if !assert.NoError(t, err) {
+				return
+			}
+			me, err := testutil.DecodeJSONResponse[*api.APIUser](callAPIResponse(c, tokens.Token, "GET", "auth/me"))
+			if assert.NoError(t, err) {
+				assert.Equal(t, user.ID, me.ID)
+			}
+
+			// Falls back to GitHub keys of claimed user.
+			other, _ := c.SigninUser("other user")
+			_, err = loginSSH(c, other.ID, signer)
+			assert.Error(t, err)
+		})
+	})
+
+	t.Run("Should login with certificate signed by trusted CA", func(t *testing.T) {
+		testutil.WithTestController(func(c *testutil.TestController) {
+			ca := newSSHSigner(t)
+			c.UpdateConfig(func(config *controller.Config) {
+				config.SSHUserCAKeys = []ssh.PublicKey{ca.PublicKey()}
+			})
+
+			signer := newSSHSigner(t)
+			cert := &ssh.Certificate{
+				Key:             signer.PublicKey(),
+				CertType:        ssh.UserCert,
+				KeyId:           "alice@example.com",
+				ValidPrincipals: []string{"alice"},
+				ValidBefore:     uint64(time.Now().Add(time.Hour).Unix()),
+			}
+			if !assert.NoError(t, cert.SignCert(rand.Reader, ca)) {
+				return
+			}
+			certSigner, err := ssh.NewCertSigner(cert, signer)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			tokens, err := loginSSH(c, "alice", certSigner)
+			if !assert.NoError(t, err) {
+				return
+			}
+			me, err := testutil.DecodeJSONResponse[*api.APIUser](callAPIResponse(c, tokens.Token, "GET", "auth/me"))
+			if assert.NoError(t, err) {
+				assert.Contains(t, me.Credentials, models.CredentialSSHPrincipal("alice"))
+			}
+
+			_, err = loginSSH(c, "bob", certSigner)
+			assert.Error(t, err)
+
+			untrusted := newSSHSigner(t)
+			if !assert.NoError(t, cert.SignCert(rand.Reader, untrusted)) {
+				return
+			}
+			certSigner, _ = ssh.NewCertSigner(cert, signer)
+			_, err = loginSSH(c, "alice", certSigner)
+			assert.Error(t, err)
+		})
+	})
+}
@@ -0,0 +1,22 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/oursky/pageship/internal/tracing"
+)
+
+func middlewareTrace(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := tracing.Start(r.Context(), "controller")
+		defer span.End()
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+
+		// Route pattern is known only after routing.
+		if pattern := chi.RouteContext(ctx).RoutePattern(); pattern != "" {
+			span.SetName("controller " + r.Method + " " + pattern)
+		}
+	})
+}
@@ -0,0 +1,44 @@
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oursky/pageship/internal/tracing"
+	"github.com/oursky/pageship/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	testutil.WithTestController(func(c *testutil.TestController) {
+		_, token := c.SigninUser("mock user")
+		handler := tracing.Middleware("http.server")(c)
+
+		req := httptest.NewRequest("GET", "http://localtest.me/api/v1/apps", nil)
+		req.Header.Add("Authorization", "bearer "+token)
+		req.Header.Add("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	names := make(map[string]int)
+	for _, span := range recorder.Ended() {
+		if span.SpanContext().TraceID().String() == traceID {
+			names[span.Name()]++
+		}
+	}
+	assert.Equal(t, 1, names["http.server"])
+	assert.Equal(t, 1, names["controller GET /api/v1/apps"])
+	assert.Greater(t, names["db.query"], 0)
+}
@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
@@ -25,6 +27,16 @@ func init() {
 		value := fl.Field().String()
 		return config.ValidateDNSLabel(value)
 	})
+
+	validate.RegisterValidation("accessLevel", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		return config.AccessLevel(value).IsValid()
+	})
+
+	validate.RegisterValidation("duration", func(fl validator.FieldLevel) bool {
+		value := fl.Field().String()
+		return config.ValidateDuration(value)
+	})
 }
 
 const maxJSONSize = 10 * 1024 * 1024 // 10MB
@@ -87,10 +99,14 @@ func writeResponse(w http.ResponseWriter, result any, err error) {
 		writeJSON(w, http.StatusConflict, response{Error: err})
 	case errors.Is(err, models.ErrAppNotFound):
 		writeJSON(w, http.StatusNotFound, response{Error: err})
+	case errors.Is(err, models.ErrInvalidAppConfig):
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
 	case errors.Is(err, models.ErrUndefinedSite):
 		writeJSON(w, http.StatusBadRequest, response{Error: err})
 	case errors.Is(err, models.ErrSiteNotFound):
 		writeJSON(w, http.StatusNotFound, response{Error: err})
+	case errors.Is(err, models.ErrNoPreviousDeployment):
+		writeJSON(w, http.StatusBadRequest, response{Error: err})
 	case errors.Is(err, models.ErrDeploymentNotFound):
 		writeJSON(w, http.StatusNotFound, response{Error: err})
 	case errors.Is(err, models.ErrDeploymentUsedName):
@@ -101,12 +117,20 @@ func writeResponse(w http.ResponseWriter, result any, err error) {
 		writeJSON(w, http.StatusBadRequest, response{Error: err})
 	case errors.Is(err, models.ErrDeploymentExpired):
 		writeJSON(w, http.StatusBadRequest, response{Error: err})
+	case errors.Is(err, models.ErrDeploymentActive):
+		writeJSON(w, http.StatusConflict, response{Error: err})
 	case errors.Is(err, models.ErrUndefinedDomain):
 		writeJSON(w, http.StatusBadRequest, response{Error: err})
 	case errors.Is(err, models.ErrDomainNotFound):
 		writeJSON(w, http.StatusNotFound, response{Error: err})
 	case errors.Is(err, models.ErrDomainUsedName):
 		writeJSON(w, http.StatusConflict, response{Error: err})
+	case errors.Is(err, models.ErrAPITokenNotFound):
+		writeJSON(w, http.StatusNotFound, response{Error: err})
+	case errors.Is(err, models.ErrSSHKeyNotFound):
+		writeJSON(w, http.StatusNotFound, response{Error: err})
+	case errors.Is(err, models.ErrSSHKeyUsed):
+		writeJSON(w, http.StatusConflict, response{Error: err})
 	case errors.Is(err, models.ErrUserNotFound):
 		writeJSON(w, http.StatusNotFound, response{Error: err})
 	case errors.Is(err, models.ErrAccessDenied):
@@ -142,6 +166,22 @@ func withTx[T any](ctx context.Context, d db.DB, fn func(tx db.Tx) (T, error)) f
 	}
 }
 
+func parseLimit(w http.ResponseWriter, r *http.Request, defaultLimit int, maxLimit int) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return defaultLimit, true
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 || limit > maxLimit {
+		writeJSON(w, http.StatusBadRequest, response{
+			Error: fmt.Errorf("invalid limit: must be between 1 and %d", maxLimit),
+		})
+		return 0, false
+	}
+	return limit, true
+}
+
 func requestID(r *http.Request) string {
 	return middleware.GetReqID(r.Context())
 }
@@ -0,0 +1,47 @@
+package site
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/oursky/pageship/internal/site"
+)
+
+// negotiateEncoding selects the pre-compressed variant accepted by client
+// according to Accept-Encoding header. Variants are listed in order of
+// server preference, which breaks ties of client preference.
+func negotiateEncoding(acceptEncoding string, encodings []site.FileEncoding) *site.FileEncoding {
+	accepted := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "q") {
+				if v, err := strconv.ParseFloat(value, 64); err == nil {
+					q = v
+				}
+			}
+		}
+		accepted[name] = q
+	}
+
+	var selected *site.FileEncoding
+	selectedQ := 0.0
+	for i, enc := range encodings {
+		q, ok := accepted[enc.Encoding]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > selectedQ {
+			selected = &encodings[i]
+			selectedQ = q
+		}
+	}
+	return selected
+}
@@ -2,30 +2,56 @@ package site
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/oursky/pageship/internal/cache"
 	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/domain"
 	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/metrics"
 	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/oidc"
 	"github.com/oursky/pageship/internal/site"
+	"github.com/oursky/pageship/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"golang.org/x/time/rate"
 )
 
 const (
 	cacheSize int           = 100
 	cacheTTL  time.Duration = time.Second * 1
+
+	basicAuthCacheSize int = 1000
+	// Password verification of each basic auth user is rate limited, so that
+	// guessing passwords cannot exhaust CPU.
+	basicAuthVerifyInterval time.Duration = time.Second * 1
+	basicAuthVerifyBurst    int           = 10
+
+	metricsPreviewSite = "(preview)"
 )
 
 type HandlerConfig struct {
 	HostPattern string
 	Middlewares []Middleware
+	SSO         SSOConfig
+	Analytics   AnalyticsRecorder
+	LiveReload  *LiveReload
+}
+
+type AnalyticsRecorder interface {
+	Record(desc *site.Descriptor, r *http.Request, status int, bytes int64)
 }
 
 type Handler struct {
@@ -36,6 +62,14 @@ type Handler struct {
 	hostPattern    *config.HostPattern
 	cache          *cache.Cache[*SiteHandler]
 	middlewares    []Middleware
+	basicAuthCache *lru.Cache[string, struct{}]
+	basicAuthLimit *lru.Cache[string, *rate.Limiter]
+	sso            SSOConfig
+	analytics      AnalyticsRecorder
+	liveReload     *LiveReload
+	ssoKeys        *oidc.Keys
+	ssoClient      *http.Client
+	ssoIssuers     map[string]*config.SSOIssuerConfig
 }
 
 func NewHandler(ctx context.Context, logger *zap.Logger, domainResolver domain.Resolver, siteResolver site.Resolver, conf HandlerConfig) (*Handler, error) {
@@ -46,29 +80,62 @@ func NewHandler(ctx context.Context, logger *zap.Logger, domainResolver domain.R
 		siteResolver:   siteResolver,
 		hostPattern:    config.NewHostPattern(conf.HostPattern),
 		middlewares:    conf.Middlewares,
+		sso:            conf.SSO,
+		analytics:      conf.Analytics,
+		liveReload:     conf.LiveReload,
+		ssoClient:      &http.Client{Timeout: 10 * time.Second},
+		ssoIssuers:     make(map[string]*config.SSOIssuerConfig),
+	}
+	for i, issuer := range conf.SSO.Issuers {
+		h.ssoIssuers[issuer.Issuer] = &conf.SSO.Issuers[i]
 	}
 
-	cache, err := cache.NewCache(cacheSize, cacheTTL, h.doResolveHandler)
+	cache, err := cache.NewCache("site", cacheSize, cacheTTL, h.doResolveHandler)
 	if err != nil {
 		return nil, fmt.Errorf("setup cache: %w", err)
 	}
 	h.cache = cache
 
+	basicAuthCache, err := lru.New[string, struct{}](basicAuthCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("setup basic auth cache: %w", err)
+	}
+	h.basicAuthCache = basicAuthCache
+
+	basicAuthLimit, err := lru.New[string, *rate.Limiter](basicAuthCacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("setup basic auth limit: %w", err)
+	}
+	h.basicAuthLimit = basicAuthLimit
+
+	ssoKeys, err := oidc.NewKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("setup OIDC keys: %w", err)
+	}
+	h.ssoKeys = ssoKeys
+
 	return h, nil
 }
 
-func (h *Handler) resolveHandler(host string) (*SiteHandler, error) {
-	return h.cache.Load(host)
+func (h *Handler) resolveHandler(ctx context.Context, host string) (*SiteHandler, error) {
+	// Resolved handlers are shared between requests; continue the trace of
+	// the request without inheriting its cancellation.
+	ctx = trace.ContextWithSpan(h.ctx, trace.SpanFromContext(ctx))
+	return h.cache.Load(ctx, host)
 }
 
 func (h *Handler) ResolveSite(host string) (*site.Descriptor, error) {
+	return h.resolveSite(h.ctx, host)
+}
+
+func (h *Handler) resolveSite(ctx context.Context, host string) (*site.Descriptor, error) {
 	matchedID, ok := h.hostPattern.MatchString(host)
 	if !ok {
 		hostname, _, err := net.SplitHostPort(host)
 		if err != nil {
 			hostname = host
 		}
-		id, err := h.domainResolver.Resolve(h.ctx, hostname)
+		id, err := h.domainResolver.Resolve(ctx, hostname)
 		if errors.Is(err, domain.ErrDomainNotFound) {
 			return nil, site.ErrSiteNotFound
 		} else if err != nil {
@@ -77,7 +144,7 @@ func (h *Handler) ResolveSite(host string) (*site.Descriptor, error) {
 		matchedID = id
 	}
 
-	desc, err := h.siteResolver.Resolve(h.ctx, matchedID)
+	desc, err := h.siteResolver.Resolve(ctx, matchedID)
 	if err != nil {
 		return nil, err
 	}
@@ -85,8 +152,8 @@ func (h *Handler) ResolveSite(host string) (*site.Descriptor, error) {
 	return desc, nil
 }
 
-func (h *Handler) doResolveHandler(host string) (*SiteHandler, error) {
-	desc, err := h.ResolveSite(host)
+func (h *Handler) doResolveHandler(ctx context.Context, host string) (*SiteHandler, error) {
+	desc, err := h.resolveSite(ctx, host)
 	if err != nil {
 		return nil, err
 	}
@@ -94,6 +161,12 @@ func (h *Handler) doResolveHandler(host string) (*SiteHandler, error) {
 	return NewSiteHandler(desc, h.middlewares), nil
 }
 
+// Invalidate discards resolved sites, so that site config changes are
+// picked up immediately.
+func (h *Handler) Invalidate() {
+	h.cache.Purge()
+}
+
 func (h *Handler) AcceptsAllDomain() bool {
 	return h.siteResolver.IsWildcard()
 }
@@ -120,12 +193,96 @@ func (h *Handler) checkAuthz(r *http.Request, handler *SiteHandler) error {
 		credentials = append(credentials, models.CredentialIP(ip))
 	}
 
+	if username, ok := h.checkBasicAuth(r, handler.desc.BasicAuth); ok {
+		credentials = append(credentials, models.CredentialBasicAuth(username))
+	}
+
+	if sso := h.ssoConfig(handler); sso != nil {
+		credentials = append(credentials, h.ssoCredentials(r, sso)...)
+	}
+
 	_, err = models.CheckACLAuthz(access, credentials)
 	return err
 }
 
+func (h *Handler) checkBasicAuth(r *http.Request, users []config.AppBasicAuthConfig) (string, bool) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return "", false
+	}
+
+	for _, u := range users {
+		if u.Username != username {
+			continue
+		}
+
+		// Password hashing is slow by design; remember verified credentials
+		// to avoid verifying again for every request.
+		key := sha256.Sum256([]byte(u.PasswordHash + "\x00" + password))
+		cacheKey := hex.EncodeToString(key[:])
+		if _, ok := h.basicAuthCache.Get(cacheKey); ok {
+			return username, true
+		}
+
+		if !h.basicAuthLimiter(u).Allow() {
+			return "", false
+		}
+		if !u.CheckPassword(password) {
+			return "", false
+		}
+		h.basicAuthCache.Add(cacheKey, struct{}{})
+		return username, true
+	}
+	return "", false
+}
+
+// basicAuthLimiter returns the rate limiter of password verification of the
+// basic auth user.
+func (h *Handler) basicAuthLimiter(u config.AppBasicAuthConfig) *rate.Limiter {
+	key := sha256.Sum256([]byte(u.Username + "\x00" + u.PasswordHash))
+	cacheKey := hex.EncodeToString(key[:])
+
+	limiter := rate.NewLimiter(rate.Every(basicAuthVerifyInterval), basicAuthVerifyBurst)
+	if prev, ok, _ := h.basicAuthLimit.PeekOrAdd(cacheKey, limiter); ok {
+		return prev
+	}
+	return limiter
+}
+
+func requiresBasicAuth(access config.ACL) bool {
+	for _, r := range access {
+		if r.BasicAuth != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// metricsSiteLabel returns the site label of request metrics. Deployment is
+// omitted from IDs constructed by DB resolver ("<app>/<site>/<deployment>"),
+// and preview deployments are counted together, so that the number of
+// series does not grow with deployments.
+func metricsSiteLabel(id string) string {
+	parts := strings.SplitN(id, "/", 3)
+	if len(parts) != 3 {
+		return id
+	} else if parts[1] == "" {
+		return metricsPreviewSite
+	}
+	return parts[0] + "/" + parts[1]
+}
+
 func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	handler, err := h.resolveHandler(r.Host)
+	if h.liveReload != nil && r.URL.Path == LiveReloadPath {
+		h.liveReload.ServeHTTP(w, r)
+		return
+	}
+
+	ctx, span := tracing.Start(r.Context(), "site.resolve",
+		trace.WithAttributes(attribute.String("site.host", r.Host)))
+	handler, err := h.resolveHandler(ctx, r.Host)
+	tracing.End(span, err)
+
 	if errors.Is(err, site.ErrSiteNotFound) {
 		http.NotFound(w, r)
 		return
@@ -140,7 +297,43 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	e := entry.(*httputil.LogEntry)
 	e.Logger = e.Logger.With(zap.String("site", handler.ID()))
 
+	// Middlewares may rewrite request URL; keep the original for analytics.
+	origReq := *r
+	origURL := *r.URL
+	origReq.URL = &origURL
+
+	start := time.Now()
+	ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+	defer func() {
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		label := metricsSiteLabel(handler.ID())
+		metrics.SiteRequests.WithLabelValues(label, strconv.Itoa(status)).Inc()
+		metrics.SiteRequestDuration.WithLabelValues(label).Observe(time.Since(start).Seconds())
+		if h.analytics != nil {
+			h.analytics.Record(handler.desc, &origReq, status, int64(ww.BytesWritten()))
+		}
+	}()
+	w = ww
+
+	sso := h.ssoConfig(handler)
+	if sso != nil && strings.HasPrefix(r.URL.Path, ssoPathPrefix) {
+		h.serveSSO(w, r, sso)
+		return
+	}
+
 	if err := h.checkAuthz(r, handler); err != nil {
+		if sso != nil && r.Header.Get("Authorization") == "" {
+			h.startSSOLogin(w, r, sso, r.URL.RequestURI())
+			return
+		}
+		if requiresBasicAuth(handler.desc.Config.Access) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="pageship", charset="UTF-8"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
 		http.NotFound(w, r)
 		return
 	}
@@ -3,13 +3,29 @@ package site_test
 import (
 	"context"
 	"errors"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/domain"
 	sitehandler "github.com/oursky/pageship/internal/handler/site"
+	"github.com/oursky/pageship/internal/handler/site/middleware"
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/metrics"
 	"github.com/oursky/pageship/internal/site"
+	"github.com/oursky/pageship/internal/tracing"
+	"github.com/prometheus/client_golang/prometheus"
+	promtestutil "github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"go.uber.org/zap"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type mockDomainResolver struct {
@@ -84,3 +100,217 @@ func TestHandleResolution(t *testing.T) {
 	assert.Equal(t, resolve("pageship.local"), nil)
 	assert.Equal(t, resolve("main.pageship.local"), nil)
 }
+
+type descriptorResolver struct {
+	desc *site.Descriptor
+}
+
+func (r *descriptorResolver) IsWildcard() bool { return true }
+
+func (*descriptorResolver) Kind() string { return "mock" }
+
+func (r *descriptorResolver) Resolve(ctx context.Context, matchedID string) (*site.Descriptor, error) {
+	return r.desc, nil
+}
+
+func TestHandleBasicAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	guestHash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	conf := config.DefaultSiteConfig()
+	conf.Access = config.ACL{{BasicAuth: "client"}, {BasicAuth: "guest"}}
+	desc := &site.Descriptor{
+		ID:     "test",
+		Config: &conf,
+		BasicAuth: []config.AppBasicAuthConfig{
+			{Username: "client", PasswordHash: string(hash)},
+			{Username: "other", PasswordHash: string(hash)},
+			{Username: "guest", PasswordHash: string(guestHash)},
+		},
+		FS: mapFS{fstest.MapFS{
+			"index.html": {Data: []byte("index"), ModTime: time.Now()},
+		}},
+	}
+
+	handler, err := sitehandler.NewHandler(context.Background(), zap.NewNop(),
+		&mockDomainResolver{}, &descriptorResolver{desc: desc},
+		sitehandler.HandlerConfig{HostPattern: "http://*.pageship.local", Middlewares: middleware.Default})
+	assert.NoError(t, err)
+	h := chimiddleware.RequestLogger(httputil.LogFormatter{Logger: zap.NewNop()})(handler)
+
+	serve := func(username, password string) *http.Response {
+		req := httptest.NewRequest("GET", "http://test.pageship.local/", nil)
+		if username != "" {
+			req.SetBasicAuth(username, password)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Result()
+	}
+
+	resp := serve("", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("WWW-Authenticate"), "Basic")
+
+	assert.Equal(t, http.StatusUnauthorized, serve("client", "wrong").StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, serve("other", "secret").StatusCode)
+	assert.Equal(t, http.StatusOK, serve("client", "secret").StatusCode)
+	assert.Equal(t, http.StatusOK, serve("client", "secret").StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, serve("client", "wrong").StatusCode)
+
+	// Password verification is rate limited; verified credentials are still
+	// accepted.
+	for i := 0; i < 10; i++ {
+		serve("client", "wrong")
+		serve("guest", "wrong")
+	}
+	assert.Equal(t, http.StatusOK, serve("client", "secret").StatusCode)
+	assert.Equal(t, http.StatusUnauthorized, serve("guest", "secret").StatusCode)
+
+	conf.Access = config.ACL{{IpRange: "10.0.0.0/8"}}
+	assert.Equal(t, http.StatusNotFound, serve("client", "secret").StatusCode)
+}
+
+func TestHandleMetrics(t *testing.T) {
+	conf := config.DefaultSiteConfig()
+	desc := &site.Descriptor{
+		ID:     "metrics",
+		Config: &conf,
+		FS: mapFS{fstest.MapFS{
+			"index.html": {Data: []byte("index"), ModTime: time.Now()},
+		}},
+	}
+
+	handler, err := sitehandler.NewHandler(context.Background(), zap.NewNop(),
+		&mockDomainResolver{}, &descriptorResolver{desc: desc},
+		sitehandler.HandlerConfig{HostPattern: "http://*.pageship.local", Middlewares: middleware.Default})
+	assert.NoError(t, err)
+	h := chimiddleware.RequestLogger(httputil.LogFormatter{Logger: zap.NewNop()})(handler)
+
+	serve := func() int {
+		req := httptest.NewRequest("GET", "http://metrics.pageship.local/", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Result().StatusCode
+	}
+
+	assert.Equal(t, http.StatusOK, serve())
+	assert.Equal(t, http.StatusOK, serve())
+
+	conf.Access = config.ACL{{IpRange: "10.0.0.0/8"}}
+	assert.Equal(t, http.StatusNotFound, serve())
+
+	assert.Equal(t, 2.0, promtestutil.ToFloat64(metrics.SiteRequests.WithLabelValues("metrics", "200")))
+	assert.Equal(t, 1.0, promtestutil.ToFloat64(metrics.SiteRequests.WithLabelValues("metrics", "404")))
+	assert.Equal(t, 1, promtestutil.CollectAndCount(metrics.SiteRequestDuration.WithLabelValues("metrics").(prometheus.Histogram)))
+
+	// Deployments of same site are counted together, and so are previews.
+	previewConf := config.DefaultSiteConfig()
+	for _, id := range []string{"app/main/deployment_1", "app/main/deployment_2", "app//deployment_3", "other//deployment_4"} {
+		desc := &site.Descriptor{
+			ID:     id,
+			Config: &previewConf,
+			FS: mapFS{fstest.MapFS{
+				"index.html": {Data: []byte("index"), ModTime: time.Now()},
+			}},
+		}
+		handler, err := sitehandler.NewHandler(context.Background(), zap.NewNop(),
+			&mockDomainResolver{}, &descriptorResolver{desc: desc},
+			sitehandler.HandlerConfig{HostPattern: "http://*.pageship.local", Middlewares: middleware.Default})
+		assert.NoError(t, err)
+		h := chimiddleware.RequestLogger(httputil.LogFormatter{Logger: zap.NewNop()})(handler)
+
+		req := httptest.NewRequest("GET", "http://metrics.pageship.local/", nil)
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	assert.Equal(t, 2.0, promtestutil.ToFloat64(metrics.SiteRequests.WithLabelValues("app/main", "200")))
+	assert.Equal(t, 2.0, promtestutil.ToFloat64(metrics.SiteRequests.WithLabelValues("(preview)", "200")))
+}
+
+func TestHandleTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(sdktrace.NewTracerProvider())
+
+	conf := config.DefaultSiteConfig()
+	desc := &site.Descriptor{
+		ID:     "trace",
+		Config: &conf,
+		FS: mapFS{fstest.MapFS{
+			"index.html": {Data: []byte("index"), ModTime: time.Now()},
+		}},
+	}
+
+	handler, err := sitehandler.NewHandler(context.Background(), zap.NewNop(),
+		&mockDomainResolver{}, &descriptorResolver{desc: desc},
+		sitehandler.HandlerConfig{HostPattern: "http://*.pageship.local", Middlewares: middleware.Default})
+	assert.NoError(t, err)
+	h := chimiddleware.RequestLogger(httputil.LogFormatter{Logger: zap.NewNop()})(handler)
+	h = tracing.Middleware("http.server")(h)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest("GET", "http://trace.pageship.local/", nil)
+	req.Header.Add("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	names := make(map[string]int)
+	for _, span := range recorder.Ended() {
+		assert.Equal(t, traceID, span.SpanContext().TraceID().String())
+		names[span.Name()]++
+	}
+	assert.Equal(t, 1, names["http.server"])
+	assert.Equal(t, 1, names["site.resolve"])
+	assert.Equal(t, 1, names["site.middleware.CustomHeaders"])
+	assert.Equal(t, 1, names["site.middleware.IndexPage"])
+	assert.Equal(t, 1, names["site.middleware.compression"])
+}
+
+type analyticsRecord struct {
+	site   string
+	path   string
+	status int
+	bytes  int64
+}
+
+type mockAnalytics struct {
+	records []analyticsRecord
+}
+
+func (a *mockAnalytics) Record(desc *site.Descriptor, r *http.Request, status int, bytes int64) {
+	a.records = append(a.records, analyticsRecord{site: desc.ID, path: r.URL.Path, status: status, bytes: bytes})
+}
+
+func TestHandleAnalytics(t *testing.T) {
+	conf := config.DefaultSiteConfig()
+	desc := &site.Descriptor{
+		ID:     "analytics",
+		Config: &conf,
+		FS: mapFS{fstest.MapFS{
+			"index.html": {Data: []byte("index"), ModTime: time.Now()},
+		}},
+	}
+
+	analytics := &mockAnalytics{}
+	handler, err := sitehandler.NewHandler(context.Background(), zap.NewNop(),
+		&mockDomainResolver{}, &descriptorResolver{desc: desc},
+		sitehandler.HandlerConfig{
+			HostPattern: "http://*.pageship.local",
+			Middlewares: middleware.Default,
+			Analytics:   analytics,
+		})
+	assert.NoError(t, err)
+	h := chimiddleware.RequestLogger(httputil.LogFormatter{Logger: zap.NewNop()})(handler)
+
+	req := httptest.NewRequest("GET", "http://analytics.pageship.local/", nil)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+
+	assert.Equal(t, []analyticsRecord{
+		{site: "analytics", path: "/", status: http.StatusOK, bytes: 5},
+	}, analytics.records)
+}
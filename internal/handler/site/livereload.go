@@ -0,0 +1,95 @@
+package site
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// LiveReloadPath is the path of server-sent events endpoint notifying
+// browsers to reload pages.
+const LiveReloadPath = "/.pageship/livereload"
+
+// LiveReload notifies connected browsers to reload, for local development.
+type LiveReload struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]struct{}
+	done    chan struct{}
+	closed  bool
+}
+
+func NewLiveReload() *LiveReload {
+	return &LiveReload{
+		clients: make(map[chan struct{}]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Reload notifies all connected browsers to reload.
+func (l *LiveReload) Reload() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for c := range l.clients {
+		select {
+		case c <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Close disconnects all browsers, so that server can be shutdown promptly.
+func (l *LiveReload) Close() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.closed {
+		close(l.done)
+		l.closed = true
+	}
+}
+
+func (l *LiveReload) subscribe() chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	c := make(chan struct{}, 1)
+	l.clients[c] = struct{}{}
+	return c
+}
+
+func (l *LiveReload) unsubscribe(c chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.clients, c)
+}
+
+func (l *LiveReload) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	c := l.subscribe()
+	defer l.unsubscribe(c)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 1000\n\n")
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-l.done:
+			return
+		case <-c:
+			fmt.Fprint(w, "event: reload\ndata: {}\n\n")
+			flusher.Flush()
+		}
+	}
+}
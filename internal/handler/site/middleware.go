@@ -2,8 +2,12 @@ package site
 
 import (
 	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
 
 	"github.com/oursky/pageship/internal/site"
+	"github.com/oursky/pageship/internal/tracing"
 )
 
 type Middleware func(*site.Descriptor, http.Handler) http.Handler
@@ -11,6 +15,12 @@ type Middleware func(*site.Descriptor, http.Handler) http.Handler
 func applyMiddleware(site *site.Descriptor, middlewares []Middleware, handler http.Handler) http.Handler {
 	for i := len(middlewares) - 1; i >= 0; i-- {
 		handler = middlewares[i](site, handler)
+		handler = tracing.Handler("site.middleware."+middlewareName(middlewares[i]), handler)
 	}
 	return handler
 }
+
+func middlewareName(m Middleware) string {
+	name := runtime.FuncForPC(reflect.ValueOf(m).Pointer()).Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
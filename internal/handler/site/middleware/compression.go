@@ -9,6 +9,8 @@ import (
 	"github.com/oursky/pageship/internal/site"
 )
 
+// Compression compresses responses on the fly. Responses with pre-compressed
+// content (i.e. Content-Encoding is set) are passed through.
 func Compression(next http.Handler) http.Handler {
 	c := middleware.NewCompressor(5)
 	c.SetEncoder("br", func(w io.Writer, level int) io.Writer {
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/site"
+)
+
+// CustomHeaders applies configured response headers to matching paths.
+func CustomHeaders(site *site.Descriptor, next http.Handler) http.Handler {
+	if len(site.Config.Headers) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rules []config.SiteHeadersConfig
+		for _, rule := range site.Config.Headers {
+			if config.MatchPathPattern(rule.Path, r.URL.Path) {
+				rules = append(rules, rule)
+			}
+		}
+		if len(rules) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		hw := &headersResponseWriter{ResponseWriter: w, rules: rules}
+		next.ServeHTTP(hw, r)
+		// Response header is written after handler returns, if handler did
+		// not write anything.
+		hw.applyRules()
+	})
+}
+
+// headersResponseWriter applies header rules just before writing response
+// header, so that headers set by later handlers can be overridden.
+type headersResponseWriter struct {
+	http.ResponseWriter
+	rules       []config.SiteHeadersConfig
+	wroteHeader bool
+}
+
+func (w *headersResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *headersResponseWriter) applyRules() {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	header := w.ResponseWriter.Header()
+	for _, rule := range w.rules {
+		for name, value := range rule.Set {
+			header.Set(name, value)
+		}
+		for name, value := range rule.Append {
+			header.Add(name, value)
+		}
+		for _, name := range rule.Remove {
+			header.Del(name)
+		}
+	}
+}
+
+func (w *headersResponseWriter) WriteHeader(statusCode int) {
+	w.applyRules()
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *headersResponseWriter) Write(p []byte) (int, error) {
+	w.applyRules()
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *headersResponseWriter) Flush() {
+	w.applyRules()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
@@ -0,0 +1,85 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/handler/site/middleware"
+	"github.com/oursky/pageship/internal/site"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomHeaders(t *testing.T) {
+	desc := &site.Descriptor{
+		Config: &config.SiteConfig{
+			Headers: []config.SiteHeadersConfig{
+				{
+					Path: "/**",
+					Set:  map[string]string{"x-frame-options": "DENY"},
+				},
+				{
+					Path:   "/assets/**",
+					Set:    map[string]string{"Cache-Control": "public, max-age=31536000, immutable"},
+					Append: map[string]string{"Vary": "Origin"},
+					Remove: []string{"ETag"},
+				},
+			},
+		},
+	}
+	h := middleware.CustomHeaders(desc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("ETag", `"hash"`)
+		w.Header().Set("Vary", "Accept-Encoding")
+		w.Write([]byte("hello"))
+	}))
+
+	serve := func(path string) *http.Response {
+		req := httptest.NewRequest("GET", path, nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	resp := serve("/index.html")
+	assert.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+	assert.Equal(t, `"hash"`, resp.Header.Get("ETag"))
+	assert.Equal(t, []string{"Accept-Encoding"}, resp.Header.Values("Vary"))
+
+	resp = serve("/assets/js/main.js")
+	assert.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+	assert.Equal(t, "public, max-age=31536000, immutable", resp.Header.Get("Cache-Control"))
+	assert.Equal(t, "", resp.Header.Get("ETag"))
+	assert.Equal(t, []string{"Accept-Encoding", "Origin"}, resp.Header.Values("Vary"))
+}
+
+func TestCustomHeadersWithoutWrite(t *testing.T) {
+	desc := &site.Descriptor{
+		Config: &config.SiteConfig{
+			Headers: []config.SiteHeadersConfig{
+				{Path: "/**", Set: map[string]string{"x-frame-options": "DENY"}},
+			},
+		},
+	}
+
+	serve := func(handler http.HandlerFunc) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/", nil)
+		rec := httptest.NewRecorder()
+		middleware.CustomHeaders(desc, handler).ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(func(w http.ResponseWriter, r *http.Request) {})
+	assert.Equal(t, "DENY", rec.Result().Header.Get("X-Frame-Options"))
+
+	rec = serve(func(w http.ResponseWriter, r *http.Request) {
+		f, ok := w.(http.Flusher)
+		if assert.True(t, ok) {
+			f.Flush()
+		}
+	})
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, "DENY", rec.Result().Header.Get("X-Frame-Options"))
+}
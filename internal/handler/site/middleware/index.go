@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"net/http"
+	"os"
 
 	"github.com/oursky/pageship/internal/site"
 )
@@ -11,7 +12,11 @@ func IndexPage(site *site.Descriptor, next http.Handler) http.Handler {
 		const indexPage = "index.html"
 
 		info, err := site.FS.Stat(r.URL.Path)
-		if err != nil {
+		if os.IsNotExist(err) {
+			// Leave it to file handler
+			next.ServeHTTP(w, r)
+			return
+		} else if err != nil {
 			http.Error(w, "internal server error", http.StatusInternalServerError)
 			return
 		}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+
+	handler "github.com/oursky/pageship/internal/handler/site"
+	"github.com/oursky/pageship/internal/site"
+)
+
+const liveReloadScript = `<script>new EventSource("` + handler.LiveReloadPath + `")` +
+	`.addEventListener("reload", function () { location.reload(); });</script>`
+
+// InjectLiveReload injects live reload client into HTML pages.
+func InjectLiveReload(site *site.Descriptor, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lw := &liveReloadResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(lw, r)
+		lw.finish()
+	})
+}
+
+// liveReloadResponseWriter buffers HTML pages, so that the client script can
+// be injected before closing body tag.
+type liveReloadResponseWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+	inject      bool
+	body        bytes.Buffer
+}
+
+func (w *liveReloadResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *liveReloadResponseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	header := w.ResponseWriter.Header()
+	// Always revalidate, so that reloaded pages are up-to-date.
+	header.Set("Cache-Control", "no-cache")
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	isPage := statusCode == http.StatusOK || statusCode == http.StatusNotFound
+	if mediaType == "text/html" && isPage && header.Get("Content-Encoding") == "" {
+		w.inject = true
+		header.Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *liveReloadResponseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.inject {
+		return w.body.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *liveReloadResponseWriter) finish() {
+	if !w.inject {
+		return
+	}
+
+	body := w.body.Bytes()
+	i := bytes.LastIndex(bytes.ToLower(body), []byte("</body>"))
+	if i < 0 {
+		i = len(body)
+	}
+
+	w.ResponseWriter.Write(body[:i])
+	w.ResponseWriter.Write([]byte(liveReloadScript))
+	w.ResponseWriter.Write(body[i:])
+}
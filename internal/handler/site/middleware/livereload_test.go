@@ -0,0 +1,44 @@
+package middleware_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/handler/site/middleware"
+	"github.com/oursky/pageship/internal/site"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjectLiveReload(t *testing.T) {
+	desc := &site.Descriptor{Config: &config.SiteConfig{}}
+
+	serve := func(contentType string, body string) *http.Response {
+		handler := middleware.InjectLiveReload(desc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Length", "100")
+			io.WriteString(w, body)
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", "http://test.pageship.local/", nil))
+		return w.Result()
+	}
+
+	resp := serve("text/html; charset=utf-8", "<html><BODY>hello</BODY></html>")
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "", resp.Header.Get("Content-Length"))
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+	assert.Regexp(t, `^<html><BODY>hello<script>new EventSource\("/\.pageship/livereload"\).*</script></BODY></html>$`, string(body))
+
+	resp = serve("text/html", "hello")
+	body, _ = io.ReadAll(resp.Body)
+	assert.Regexp(t, `^hello<script>.*</script>$`, string(body))
+
+	resp = serve("text/css", "body {}")
+	body, _ = io.ReadAll(resp.Body)
+	assert.Equal(t, "100", resp.Header.Get("Content-Length"))
+	assert.Equal(t, "body {}", string(body))
+}
@@ -5,9 +5,15 @@ import (
 )
 
 var Default = []site.Middleware{
+	CustomHeaders,
 	RedirectCustomDomain,
 	CanonicalizePath,
+	RedirectRules,
 	RouteSPA,
 	IndexPage,
 	compression,
 }
+
+// LiveReload is Default with live reload client injected, for local
+// development.
+var LiveReload = append(Default[:len(Default):len(Default)], InjectLiveReload)
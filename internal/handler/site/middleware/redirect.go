@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/oursky/pageship/internal/config"
+	handler "github.com/oursky/pageship/internal/handler/site"
+	"github.com/oursky/pageship/internal/site"
+)
+
+// RedirectRules applies configured redirect and rewrite rules. Unless forced,
+// rules are not applied to paths of existing files.
+func RedirectRules(site *site.Descriptor, next http.Handler) http.Handler {
+	if len(site.Config.Redirects) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var fileExists *bool
+		for _, rule := range site.Config.Redirects {
+			target, ok := matchRedirectRule(&rule, r.URL)
+			if !ok {
+				continue
+			}
+
+			if !rule.Force {
+				if fileExists == nil {
+					_, err := site.FS.Stat(r.URL.Path)
+					if err != nil && !os.IsNotExist(err) {
+						handler.Error(w, r, err)
+						return
+					}
+					exists := err == nil
+					fileExists = &exists
+				}
+				if *fileExists {
+					continue
+				}
+			}
+
+			if rule.IsRewrite() {
+				u, err := url.Parse(target)
+				if err != nil {
+					handler.Error(w, r, err)
+					return
+				}
+				r.URL.Path = u.Path
+				if u.RawQuery != "" {
+					r.URL.RawQuery = u.RawQuery
+				}
+				break
+			}
+
+			http.Redirect(w, r, target, rule.StatusCode())
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var redirectPlaceholder = regexp.MustCompile(`:[A-Za-z_][A-Za-z0-9_]*`)
+
+func matchRedirectRule(rule *config.SiteRedirectConfig, u *url.URL) (string, bool) {
+	params, ok := matchRedirectPath(rule.From, u.Path)
+	if !ok {
+		return "", false
+	}
+
+	if len(rule.Query) > 0 {
+		query := u.Query()
+		for name, expected := range rule.Query {
+			value, ok := lookupQuery(query, name)
+			if !ok {
+				return "", false
+			}
+
+			if strings.HasPrefix(expected, ":") {
+				params[expected[1:]] = value
+			} else if value != expected {
+				return "", false
+			}
+		}
+	}
+
+	// Captured values are from decoded URL; escape them so that they cannot
+	// alter structure of target URL.
+	toPath, toQuery, hasQuery := strings.Cut(rule.To, "?")
+	target := substituteParams(toPath, params, escapePathParam)
+	if hasQuery {
+		target += "?" + substituteParams(toQuery, params, url.QueryEscape)
+	}
+
+	// Pass through query string unless matched explicitly.
+	if len(rule.Query) == 0 && u.RawQuery != "" && !strings.Contains(target, "?") {
+		target += "?" + u.RawQuery
+	}
+
+	return target, true
+}
+
+func substituteParams(s string, params map[string]string, escape func(string) string) string {
+	return redirectPlaceholder.ReplaceAllStringFunc(s, func(s string) string {
+		if value, ok := params[s[1:]]; ok {
+			return escape(value)
+		}
+		return s
+	})
+}
+
+func escapePathParam(value string) string {
+	segs := strings.Split(value, "/")
+	for i, seg := range segs {
+		segs[i] = url.PathEscape(seg)
+	}
+	return strings.Join(segs, "/")
+}
+
+func matchRedirectPath(pattern string, urlpath string) (map[string]string, bool) {
+	params := make(map[string]string)
+
+	patternSegs := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	pathSegs := strings.Split(strings.TrimPrefix(urlpath, "/"), "/")
+	for i, seg := range patternSegs {
+		if seg == "*" {
+			params["splat"] = strings.Join(pathSegs[i:], "/")
+			return params, true
+		}
+
+		if i >= len(pathSegs) {
+			return nil, false
+		}
+
+		if strings.HasPrefix(seg, ":") {
+			if pathSegs[i] == "" {
+				return nil, false
+			}
+			params[seg[1:]] = pathSegs[i]
+		} else if seg != pathSegs[i] {
+			return nil, false
+		}
+	}
+
+	if len(pathSegs) != len(patternSegs) {
+		return nil, false
+	}
+	return params, true
+}
+
+func lookupQuery(query url.Values, name string) (string, bool) {
+	// Query names in config may be normalized to lower case.
+	for key, values := range query {
+		if strings.EqualFold(key, name) && len(values) > 0 {
+			return values[0], true
+		}
+	}
+	return "", false
+}
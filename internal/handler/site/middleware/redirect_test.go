@@ -0,0 +1,96 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/handler/site/middleware"
+	"github.com/oursky/pageship/internal/site"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockFS map[string]bool
+
+func (m mockFS) Stat(path string) (*site.FileInfo, error) {
+	isDir, ok := m[path]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: path, Err: fs.ErrNotExist}
+	}
+	return &site.FileInfo{IsDir: isDir}, nil
+}
+
+func (m mockFS) Open(ctx context.Context, path string) (io.ReadSeekCloser, error) {
+	return nil, &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+}
+
+func TestRedirectRules(t *testing.T) {
+	desc := &site.Descriptor{
+		FS: mockFS{
+			"/":                true,
+			"/old/exists.html": false,
+		},
+		Config: &config.SiteConfig{
+			Redirects: []config.SiteRedirectConfig{
+				{From: "/blog/:year/:slug", To: "/posts/:slug?year=:year"},
+				{From: "/docs/*", To: "https://docs.example.com/:splat", Status: 302},
+				{From: "/store", To: "/products/:id", Query: map[string]string{"id": ":id"}, Status: 307},
+				{From: "/store", To: "/store/help", Query: map[string]string{"page": "help"}, Status: 308},
+				{From: "/app/*", To: "/app/index.html", Status: 200},
+				{From: "/old/*", To: "/new/:splat"},
+				{From: "/forced/*", To: "/", Status: 200, Force: true},
+				{From: "/", To: "/home", Status: 302, Force: true},
+			},
+		},
+	}
+	h := middleware.RedirectRules(desc, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Path", r.URL.Path)
+		w.Header().Set("X-Query", r.URL.RawQuery)
+	}))
+
+	cases := []struct {
+		url      string
+		status   int
+		location string
+		path     string
+		query    string
+	}{
+		{url: "/blog/2023/hello", status: 301, location: "/posts/hello?year=2023"},
+		{url: "/blog/2023", status: 200, path: "/blog/2023"},
+		{url: "/blog/a%26b=c/x%3Fy%23z", status: 301, location: "/posts/x%3Fy%23z?year=a%26b%3Dc"},
+		{url: "/blog/2023/hello/world", status: 200, path: "/blog/2023/hello/world"},
+		{url: "/docs/guide/intro?ref=1", status: 302, location: "https://docs.example.com/guide/intro?ref=1"},
+		{url: "/docs", status: 302, location: "https://docs.example.com/"},
+		{url: "/store?id=42", status: 307, location: "/products/42"},
+		{url: "/store?ID=42", status: 307, location: "/products/42"},
+		{url: "/store?page=help", status: 308, location: "/store/help"},
+		{url: "/store?page=other", status: 200, path: "/store", query: "page=other"},
+		{url: "/app/users/1?tab=2", status: 200, path: "/app/index.html", query: "tab=2"},
+		{url: "/old/missing.html", status: 301, location: "/new/missing.html"},
+		{url: "/old/a%2F%2Fb/c%20d", status: 301, location: "/new/a/b/c%20d"},
+		{url: "/old/exists.html", status: 200, path: "/old/exists.html"},
+		{url: "/forced/a", status: 200, path: "/"},
+		{url: "/", status: 302, location: "/home"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.url, func(t *testing.T) {
+			req := httptest.NewRequest("GET", c.url, nil)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+
+			resp := rec.Result()
+			assert.Equal(t, c.status, resp.StatusCode)
+			if c.location != "" {
+				assert.Equal(t, c.location, resp.Header.Get("Location"))
+			} else {
+				assert.Equal(t, c.path, resp.Header.Get("X-Path"))
+				assert.Equal(t, c.query, resp.Header.Get("X-Query"))
+			}
+		})
+	}
+}
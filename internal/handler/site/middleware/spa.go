@@ -11,6 +11,10 @@ import (
 
 // RouteSPA routes non-existing files to nearest parent directory
 func RouteSPA(site *site.Descriptor, next http.Handler) http.Handler {
+	if site.Config.NoSPA {
+		return next
+	}
+
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		urlpath := r.URL.Path
 		for {
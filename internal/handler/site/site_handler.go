@@ -60,38 +60,115 @@ func (h *SiteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 func (h *SiteHandler) serveFile(w http.ResponseWriter, r *http.Request) {
 	info, err := h.publicFS.Stat(r.URL.Path)
-	if os.IsNotExist(err) {
-		http.NotFound(w, r)
+	if os.IsNotExist(err) || (err == nil && info.IsDir) {
+		h.serveNotFound(w, r)
 		return
 	} else if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
+		Error(w, r, err)
 		return
 	}
 
+	reader, etag := h.openContent(w, r, r.URL.Path, info)
+	defer reader.Close()
+
+	if etag != "" {
+		w.Header().Set("ETag", fmt.Sprintf(`"%s"`, etag))
+	}
+	w.Header().Set("Cache-Control", h.cacheControl(r.URL.Path, info))
+
+	writer := httputil.NewTimeoutResponseWriter(w, 10*time.Second)
+	http.ServeContent(writer, r, path.Base(r.URL.Path), info.ModTime, reader)
+}
+
+// openContent negotiates the content encoding of the file, and returns the
+// content reader with the ETag of the selected variant.
+func (h *SiteHandler) openContent(w http.ResponseWriter, r *http.Request, filePath string, info *site.FileInfo) (*lazyReader, string) {
+	reader := &lazyReader{
+		fs:   h.publicFS,
+		path: filePath,
+		ctx:  r.Context(),
+	}
+
+	etag := info.Hash
+	if len(info.Encodings) > 0 {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if enc := negotiateEncoding(r.Header.Get("Accept-Encoding"), info.Encodings); enc != nil {
+			// Serve pre-compressed content; each variant has its own ETag.
+			w.Header().Set("Content-Encoding", enc.Encoding)
+			etag = enc.Hash
+			reader.encoding = enc.Encoding
+		}
+	}
+
 	if info.ContentType != "" {
 		w.Header().Set("Content-Type", info.ContentType)
 	}
+	return reader, etag
+}
+
+func (h *SiteHandler) cacheControl(urlpath string, info *site.FileInfo) string {
+	if value, ok := h.desc.Config.ResolveCacheControl(urlpath); ok {
+		return value
+	}
+
 	if info.Hash != "" {
-		w.Header().Set("ETag", fmt.Sprintf(`"%s"`, info.Hash))
-		w.Header().Set("Cache-Control", "public, max-age=31536000, no-cache")
+		// Always revalidate using ETag by default
+		return "public, max-age=31536000, no-cache"
 	}
+	// Revalidate using modification time if content hash is unavailable
+	return "no-cache"
+}
 
-	reader := &lazyReader{
-		fs:   h.publicFS,
-		path: r.URL.Path,
-		ctx:  r.Context(),
+func (h *SiteHandler) serveNotFound(w http.ResponseWriter, r *http.Request) {
+	if h.desc.Config.NotFound == "" {
+		http.NotFound(w, r)
+		return
 	}
+
+	notFoundPath := path.Clean("/" + h.desc.Config.NotFound)
+	info, err := h.publicFS.Stat(notFoundPath)
+	if os.IsNotExist(err) || (err == nil && info.IsDir) {
+		http.NotFound(w, r)
+		return
+	} else if err != nil {
+		Error(w, r, err)
+		return
+	}
+
+	reader, _ := h.openContent(w, r, notFoundPath, info)
 	defer reader.Close()
 
-	writer := httputil.NewTimeoutResponseWriter(w, 10*time.Second)
-	http.ServeContent(writer, r, path.Base(r.URL.Path), info.ModTime, reader)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	// The page is served in full regardless of conditional and range
+	// headers, which apply to the requested path instead.
+	req := r.Clone(r.Context())
+	for _, name := range []string{"If-Match", "If-None-Match", "If-Modified-Since", "If-Unmodified-Since", "If-Range", "Range"} {
+		req.Header.Del(name)
+	}
+
+	writer := httputil.NewTimeoutResponseWriter(&notFoundResponseWriter{ResponseWriter: w}, 10*time.Second)
+	http.ServeContent(writer, req, path.Base(notFoundPath), info.ModTime, reader)
+}
+
+// notFoundResponseWriter serves successful response with 404 status.
+type notFoundResponseWriter struct {
+	http.ResponseWriter
+}
+
+func (w *notFoundResponseWriter) WriteHeader(statusCode int) {
+	if statusCode == http.StatusOK {
+		statusCode = http.StatusNotFound
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
 }
 
 type lazyReader struct {
-	fs     site.FS
-	path   string
-	ctx    context.Context
-	reader io.ReadSeekCloser
+	fs       site.FS
+	path     string
+	encoding string
+	ctx      context.Context
+	reader   io.ReadSeekCloser
 }
 
 func (r *lazyReader) init() error {
@@ -99,7 +176,13 @@ func (r *lazyReader) init() error {
 		return nil
 	}
 
-	reader, err := r.fs.Open(r.ctx, r.path)
+	var reader io.ReadSeekCloser
+	var err error
+	if efs, ok := r.fs.(site.EncodedFS); ok && r.encoding != "" {
+		reader, err = efs.OpenEncoded(r.ctx, r.path, r.encoding)
+	} else {
+		reader, err = r.fs.Open(r.ctx, r.path)
+	}
 	if err != nil {
 		return err
 	}
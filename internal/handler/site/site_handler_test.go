@@ -0,0 +1,199 @@
+package site_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/oursky/pageship/internal/config"
+	sitehandler "github.com/oursky/pageship/internal/handler/site"
+	"github.com/oursky/pageship/internal/handler/site/middleware"
+	"github.com/oursky/pageship/internal/site"
+	"github.com/stretchr/testify/assert"
+)
+
+type mapFS struct{ fstest.MapFS }
+
+func (f mapFS) path(p string) string {
+	if p == "/" {
+		return "."
+	}
+	return p[1:]
+}
+
+func (f mapFS) Stat(p string) (*site.FileInfo, error) {
+	info, err := fs.Stat(f.MapFS, f.path(p))
+	if err != nil {
+		return nil, err
+	}
+	return &site.FileInfo{IsDir: info.IsDir(), ModTime: info.ModTime(), Size: info.Size()}, nil
+}
+
+func (f mapFS) Open(ctx context.Context, p string) (io.ReadSeekCloser, error) {
+	data, err := fs.ReadFile(f.MapFS, f.path(p))
+	if err != nil {
+		return nil, err
+	}
+	return nopCloser{bytes.NewReader(data)}, nil
+}
+
+type nopCloser struct{ io.ReadSeeker }
+
+func (nopCloser) Close() error { return nil }
+
+func TestSiteHandlerNotFound(t *testing.T) {
+	fsys := mapFS{fstest.MapFS{
+		"index.html":      {Data: []byte("index"), ModTime: time.Now()},
+		"docs/index.html": {Data: []byte("docs"), ModTime: time.Now()},
+		"404.html":        {Data: []byte("not found"), ModTime: time.Now()},
+	}}
+
+	serve := func(conf config.SiteConfig, path string) (int, string) {
+		desc := &site.Descriptor{ID: "test", Config: &conf, FS: fsys}
+		h := sitehandler.NewSiteHandler(desc, middleware.Default)
+
+		req := httptest.NewRequest("GET", "http://test.localhost"+path, nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Code, rec.Body.String()
+	}
+
+	conf := config.DefaultSiteConfig()
+	code, body := serve(conf, "/docs/missing")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "docs", body)
+
+	conf.NoSPA = true
+	code, _ = serve(conf, "/docs/missing")
+	assert.Equal(t, 404, code)
+
+	conf.NotFound = "404.html"
+	code, body = serve(conf, "/docs/missing")
+	assert.Equal(t, 404, code)
+	assert.Equal(t, "not found", body)
+
+	code, body = serve(conf, "/docs/")
+	assert.Equal(t, 200, code)
+	assert.Equal(t, "docs", body)
+
+	conf.NotFound = "missing.html"
+	code, _ = serve(conf, "/docs/missing")
+	assert.Equal(t, 404, code)
+}
+
+func TestSiteHandlerCacheControl(t *testing.T) {
+	fsys := mapFS{fstest.MapFS{
+		"index.html":       {Data: []byte("index"), ModTime: time.Now()},
+		"assets/main.js":   {Data: []byte("main"), ModTime: time.Now()},
+		"assets/style.css": {Data: []byte("style"), ModTime: time.Now()},
+	}}
+
+	conf := config.DefaultSiteConfig()
+	conf.Cache = []config.SiteCacheConfig{
+		{Path: "/**/*.html", Control: "no-store"},
+		{Path: "/assets/*.js", Control: config.CacheControlImmutable},
+	}
+	desc := &site.Descriptor{ID: "test", Config: &conf, FS: fsys}
+	h := sitehandler.NewSiteHandler(desc, middleware.Default)
+
+	serve := func(path string) string {
+		req := httptest.NewRequest("GET", "http://test.localhost"+path, nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec.Header().Get("Cache-Control")
+	}
+
+	assert.Equal(t, "no-store", serve("/"))
+	assert.Equal(t, "public, max-age=31536000, immutable", serve("/assets/main.js"))
+	assert.Equal(t, "no-cache", serve("/assets/style.css"))
+}
+
+type encodedFS struct {
+	mapFS
+	encodings map[string]map[string]string
+}
+
+func (f encodedFS) Stat(p string) (*site.FileInfo, error) {
+	info, err := f.mapFS.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	info.ContentType = "text/html; charset=utf-8"
+	info.Hash = "identity"
+	for _, encoding := range []string{"br", "gzip"} {
+		if _, ok := f.encodings[p][encoding]; ok {
+			info.Encodings = append(info.Encodings, site.FileEncoding{Encoding: encoding, Hash: encoding})
+		}
+	}
+	return info, nil
+}
+
+func (f encodedFS) OpenEncoded(ctx context.Context, p string, encoding string) (io.ReadSeekCloser, error) {
+	data, ok := f.encodings[p][encoding]
+	if !ok {
+		return nil, fs.ErrNotExist
+	}
+	return nopCloser{bytes.NewReader([]byte(data))}, nil
+}
+
+func TestSiteHandlerEncodings(t *testing.T) {
+	fsys := encodedFS{
+		mapFS: mapFS{fstest.MapFS{
+			"index.html": {Data: []byte("index"), ModTime: time.Now()},
+			"plain.html": {Data: []byte("plain"), ModTime: time.Now()},
+		}},
+		encodings: map[string]map[string]string{
+			"/index.html": {"br": "brotli data", "gzip": "gzip data"},
+		},
+	}
+
+	serve := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		conf := config.DefaultSiteConfig()
+		desc := &site.Descriptor{ID: "test", Config: &conf, FS: fsys}
+		h := sitehandler.NewSiteHandler(desc, middleware.Default)
+
+		req := httptest.NewRequest("GET", "http://test.localhost"+path, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve("/", map[string]string{"Accept-Encoding": "gzip, deflate, br"})
+	assert.Equal(t, 200, rec.Code)
+	assert.Equal(t, "br", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, `"br"`, rec.Header().Get("ETag"))
+	assert.Equal(t, []string{"Accept-Encoding"}, rec.Header().Values("Vary"))
+	assert.Equal(t, "brotli data", rec.Body.String())
+
+	rec = serve("/", map[string]string{"Accept-Encoding": "gzip, br;q=0.5"})
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, `"gzip"`, rec.Header().Get("ETag"))
+	assert.Equal(t, "gzip data", rec.Body.String())
+
+	rec = serve("/", map[string]string{"Accept-Encoding": "identity"})
+	assert.Equal(t, "", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, `"identity"`, rec.Header().Get("ETag"))
+	assert.Equal(t, []string{"Accept-Encoding"}, rec.Header().Values("Vary"))
+	assert.Equal(t, "index", rec.Body.String())
+
+	rec = serve("/", map[string]string{"Accept-Encoding": "br", "Range": "bytes=0-5"})
+	assert.Equal(t, 206, rec.Code)
+	assert.Equal(t, "br", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "brotli", rec.Body.String())
+
+	rec = serve("/", map[string]string{"Accept-Encoding": "br", "If-None-Match": `"br"`})
+	assert.Equal(t, 304, rec.Code)
+
+	rec = serve("/plain.html", map[string]string{"Accept-Encoding": "identity"})
+	assert.Equal(t, "", rec.Header().Get("Content-Encoding"))
+	assert.Empty(t, rec.Header().Values("Vary"))
+	assert.Equal(t, "plain", rec.Body.String())
+}
@@ -0,0 +1,439 @@
+package site
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/models"
+	"go.uber.org/zap"
+)
+
+const (
+	ssoPathPrefix   = "/.pageship/sso/"
+	ssoLoginPath    = ssoPathPrefix + "login"
+	ssoCallbackPath = ssoPathPrefix + "callback"
+	ssoLogoutPath   = ssoPathPrefix + "logout"
+
+	ssoStateCookie   = "pageship-sso-state"
+	ssoSessionCookie = "pageship-session"
+
+	ssoStateDuration   = 10 * time.Minute
+	ssoSessionDuration = 12 * time.Hour
+)
+
+type SSOConfig struct {
+	// SessionKey signs visitor session cookies. SSO is disabled if empty.
+	SessionKey []byte
+	// Issuers are identity providers trusted by operator. Apps using other
+	// issuers have SSO disabled, so that server does not request arbitrary
+	// URLs specified in app config.
+	Issuers []config.SSOIssuerConfig
+	// ResolveCredentials returns additional credentials of authenticated
+	// visitors, e.g. their Pageship users.
+	ResolveCredentials func(ctx context.Context, ids []models.CredentialID) ([]models.CredentialID, error)
+}
+
+type ssoStateClaims struct {
+	jwt.RegisteredClaims
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	Redirect string `json:"redirect"`
+}
+
+type ssoSessionClaims struct {
+	jwt.RegisteredClaims
+	Credentials []models.CredentialID `json:"credentials"`
+}
+
+func (h *Handler) ssoConfig(handler *SiteHandler) *config.AppSSOConfig {
+	if len(h.sso.SessionKey) == 0 || handler.desc.SSO == nil {
+		return nil
+	}
+	if _, ok := h.ssoIssuers[handler.desc.SSO.Issuer]; !ok {
+		return nil
+	}
+	return handler.desc.SSO
+}
+
+func (h *Handler) serveSSO(w http.ResponseWriter, r *http.Request, sso *config.AppSSOConfig) {
+	switch r.URL.Path {
+	case ssoLoginPath:
+		h.startSSOLogin(w, r, sso, r.URL.Query().Get("redirect"))
+	case ssoCallbackPath:
+		h.handleSSOCallback(w, r, sso)
+	case ssoLogoutPath:
+		http.SetCookie(w, h.ssoCookie(r, ssoSessionCookie, "/", "", -1))
+		http.Redirect(w, r, "/", http.StatusFound)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (h *Handler) ssoCredentials(r *http.Request, sso *config.AppSSOConfig) []models.CredentialID {
+	cookie, err := r.Cookie(ssoSessionCookie)
+	if err != nil {
+		return nil
+	}
+
+	claims := &ssoSessionClaims{}
+	_, err = jwt.ParseWithClaims(
+		cookie.Value,
+		claims,
+		func(t *jwt.Token) (any, error) { return h.sso.SessionKey, nil },
+		jwt.WithValidMethods([]string{"HS256"}),
+		jwt.WithIssuer(sso.Issuer),
+		jwt.WithAudience(r.Host),
+	)
+	if err != nil {
+		return nil
+	}
+	return claims.Credentials
+}
+
+func (h *Handler) startSSOLogin(w http.ResponseWriter, r *http.Request, sso *config.AppSSOConfig, redirect string) {
+	issuer := h.ssoIssuers[sso.Issuer]
+
+	var authorizationEndpoint string
+	var scopes []string
+	switch issuer.Kind {
+	case config.SSOIssuerKindGitHub:
+		authorizationEndpoint = issuer.Issuer + "/login/oauth/authorize"
+		scopes = append([]string{"read:org"}, sso.Scopes...)
+	default:
+		key, err := h.ssoKeys.Get(sso.Issuer)
+		if err != nil {
+			h.logger.Error("failed to load OIDC provider", zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		authorizationEndpoint = key.AuthorizationEndpoint
+		scopes = append([]string{"openid"}, sso.Scopes...)
+	}
+
+	state, nonce, verifier := randomToken(), randomToken(), randomToken()
+	now := time.Now()
+	stateClaims := &ssoStateClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        state,
+			Audience:  jwt.ClaimStrings{r.Host},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ssoStateDuration)),
+		},
+		Nonce:    nonce,
+		Verifier: verifier,
+		Redirect: sanitizeRedirect(redirect),
+	}
+	stateToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, stateClaims).SignedString(h.sso.SessionKey)
+	if err != nil {
+		h.logger.Error("failed to sign SSO state", zap.Error(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	http.SetCookie(w, h.ssoCookie(r, ssoStateCookie, ssoPathPrefix, stateToken, int(ssoStateDuration.Seconds())))
+
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {sso.ClientID},
+		"redirect_uri":          {h.ssoCallbackURL(r)},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	http.Redirect(w, r, authorizationEndpoint+"?"+query.Encode(), http.StatusFound)
+}
+
+func (h *Handler) handleSSOCallback(w http.ResponseWriter, r *http.Request, sso *config.AppSSOConfig) {
+	stateClaims := &ssoStateClaims{}
+	cookie, err := r.Cookie(ssoStateCookie)
+	if err == nil {
+		_, err = jwt.ParseWithClaims(
+			cookie.Value,
+			stateClaims,
+			func(t *jwt.Token) (any, error) { return h.sso.SessionKey, nil },
+			jwt.WithValidMethods([]string{"HS256"}),
+			jwt.WithAudience(r.Host),
+		)
+	}
+	if err != nil || stateClaims.ID != r.URL.Query().Get("state") {
+		http.Error(w, "invalid login state", http.StatusBadRequest)
+		return
+	}
+	http.SetCookie(w, h.ssoCookie(r, ssoStateCookie, ssoPathPrefix, "", -1))
+
+	if e := r.URL.Query().Get("error"); e != "" {
+		h.logger.Debug("SSO login failed", zap.String("error", e))
+		http.Error(w, "login failed", http.StatusForbidden)
+		return
+	}
+
+	credentials, err := h.exchangeSSOCode(r, sso, stateClaims)
+	if err != nil {
+		h.logger.Debug("SSO login failed", zap.Error(err))
+		http.Error(w, "login failed", http.StatusForbidden)
+		return
+	}
+
+	if h.sso.ResolveCredentials != nil {
+		extra, err := h.sso.ResolveCredentials(r.Context(), credentials)
+		if err != nil {
+			h.logger.Error("failed to resolve credentials", zap.Error(err))
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		credentials = append(credentials, extra...)
+	}
+
+	now := time.Now()
+	sessionClaims := &ssoSessionClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    sso.Issuer,
+			Audience:  jwt.ClaimStrings{r.Host},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ssoSessionDuration)),
+		},
+		Credentials: credentials,
+	}
+	session, err := jwt.NewWithClaims(jwt.SigningMethodHS256, sessionClaims).SignedString(h.sso.SessionKey)
+	if err != nil {
+		h.logger.Error("failed to sign SSO session", zap.Error(err))
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	h.logger.Info("site visitor authenticated", zap.Any("credentials", credentials))
+	http.SetCookie(w, h.ssoCookie(r, ssoSessionCookie, "/", session, int(ssoSessionDuration.Seconds())))
+	http.Redirect(w, r, stateClaims.Redirect, http.StatusFound)
+}
+
+func (h *Handler) exchangeSSOCode(r *http.Request, sso *config.AppSSOConfig, state *ssoStateClaims) ([]models.CredentialID, error) {
+	issuer := h.ssoIssuers[sso.Issuer]
+	if issuer.Kind == config.SSOIssuerKindGitHub {
+		return h.exchangeGitHubCode(r, sso, issuer, state)
+	}
+
+	key, err := h.ssoKeys.Get(sso.Issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens struct {
+		IDToken string `json:"id_token"`
+	}
+	err = h.requestSSOToken(r, key.TokenEndpoint, sso, issuer, state, &tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(
+		tokens.IDToken,
+		claims,
+		key.JWKS.Keyfunc,
+		jwt.WithAudience(sso.ClientID),
+		jwt.WithIssuer(key.Issuer),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+	if nonce, _ := claims["nonce"].(string); nonce != state.Nonce {
+		return nil, errors.New("invalid ID token: nonce mismatch")
+	}
+
+	var credentials []models.CredentialID
+	if sso.GitHubUserClaim != "" {
+		if username, _ := claims[sso.GitHubUserClaim].(string); username != "" {
+			credentials = append(credentials, models.CredentialGitHubUser(username))
+		}
+	}
+	if sso.GitHubOrgsClaim != "" {
+		values, _ := claims[sso.GitHubOrgsClaim].([]any)
+		var orgs []string
+		for _, v := range values {
+			// Groups of teams are in form of `org:team`.
+			if group, ok := v.(string); ok {
+				org, _, _ := strings.Cut(group, ":")
+				orgs = append(orgs, org)
+			}
+		}
+		credentials = append(credentials, githubOrgCredentials(orgs)...)
+	}
+	return credentials, nil
+}
+
+// exchangeGitHubCode authenticates visitor through GitHub OAuth app, which
+// does not issue ID tokens; user and organizations are fetched from API.
+func (h *Handler) exchangeGitHubCode(
+	r *http.Request,
+	sso *config.AppSSOConfig,
+	issuer *config.SSOIssuerConfig,
+	state *ssoStateClaims,
+) ([]models.CredentialID, error) {
+	var tokens struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	err := h.requestSSOToken(r, issuer.Issuer+"/login/oauth/access_token", sso, issuer, state, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	if tokens.AccessToken == "" {
+		return nil, fmt.Errorf("exchange code: %s", tokens.Error)
+	}
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := h.requestGitHubAPI(r, issuer, tokens.AccessToken, "/user", &user); err != nil {
+		return nil, err
+	}
+	if user.Login == "" {
+		return nil, errors.New("get user: missing login")
+	}
+
+	var orgs []struct {
+		Login string `json:"login"`
+	}
+	if err := h.requestGitHubAPI(r, issuer, tokens.AccessToken, "/user/orgs?per_page=100", &orgs); err != nil {
+		return nil, err
+	}
+
+	credentials := []models.CredentialID{models.CredentialGitHubUser(user.Login)}
+	var orgNames []string
+	for _, org := range orgs {
+		orgNames = append(orgNames, org.Login)
+	}
+	return append(credentials, githubOrgCredentials(orgNames)...), nil
+}
+
+func (h *Handler) requestSSOToken(
+	r *http.Request,
+	endpoint string,
+	sso *config.AppSSOConfig,
+	issuer *config.SSOIssuerConfig,
+	state *ssoStateClaims,
+	tokens any,
+) error {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {r.URL.Query().Get("code")},
+		"redirect_uri":  {h.ssoCallbackURL(r)},
+		"client_id":     {sso.ClientID},
+		"code_verifier": {state.Verifier},
+	}
+	secret, hasSecret := issuer.ClientSecrets[sso.ClientID]
+	if hasSecret && issuer.Kind == config.SSOIssuerKindGitHub {
+		// GitHub accepts client secret in request body only.
+		form.Set("client_secret", secret)
+	}
+
+	req, err := http.NewRequestWithContext(r.Context(), "POST", endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if hasSecret && issuer.Kind != config.SSOIssuerKindGitHub {
+		req.SetBasicAuth(url.QueryEscape(sso.ClientID), url.QueryEscape(secret))
+	}
+
+	resp, err := h.ssoClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("exchange code: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("exchange code: unexpected status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(tokens); err != nil {
+		return fmt.Errorf("exchange code: %w", err)
+	}
+	return nil
+}
+
+func (h *Handler) requestGitHubAPI(r *http.Request, issuer *config.SSOIssuerConfig, token string, path string, result any) error {
+	req, err := http.NewRequestWithContext(r.Context(), "GET", strings.TrimSuffix(issuer.GitHubAPIURL, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := h.ssoClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("github API: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("github API: unexpected status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("github API: %w", err)
+	}
+	return nil
+}
+
+func githubOrgCredentials(orgs []string) []models.CredentialID {
+	var credentials []models.CredentialID
+	seen := make(map[string]struct{})
+	for _, org := range orgs {
+		key := strings.ToLower(org)
+		if _, ok := seen[key]; ok || org == "" {
+			continue
+		}
+		seen[key] = struct{}{}
+		credentials = append(credentials, models.CredentialGitHubOrg(org))
+	}
+	return credentials
+}
+
+func (h *Handler) ssoCallbackURL(r *http.Request) string {
+	scheme := h.hostPattern.LeadingScheme
+	if r.TLS != nil {
+		scheme = "https://"
+	}
+	return scheme + r.Host + ssoCallbackPath
+}
+
+func (h *Handler) ssoCookie(r *http.Request, name string, path string, value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		Secure:   r.TLS != nil || h.hostPattern.LeadingScheme == "https://",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func sanitizeRedirect(redirect string) string {
+	// Allow only local paths to avoid open redirect.
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") || strings.HasPrefix(redirect, "/\\") {
+		return "/"
+	}
+	return redirect
+}
+
+func randomToken() string {
+	data := make([]byte, 32)
+	_, err := rand.Read(data)
+	if err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
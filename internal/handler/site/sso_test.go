@@ -0,0 +1,318 @@
+package site_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	chimiddleware "github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/oursky/pageship/internal/config"
+	sitehandler "github.com/oursky/pageship/internal/handler/site"
+	"github.com/oursky/pageship/internal/handler/site/middleware"
+	"github.com/oursky/pageship/internal/httputil"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/site"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+type fakeOIDCProvider struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	m     sync.Mutex
+	codes map[string]jwt.MapClaims
+}
+
+func newFakeOIDCProvider(t *testing.T) *fakeOIDCProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := &fakeOIDCProvider{key: key, codes: make(map[string]jwt.MapClaims)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "test",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		p.m.Lock()
+		claims, ok := p.codes[r.FormValue("code")]
+		delete(p.codes, r.FormValue("code"))
+		p.m.Unlock()
+		if !ok || r.FormValue("code_verifier") == "" {
+			http.Error(w, "invalid_grant", http.StatusBadRequest)
+			return
+		}
+		if id, secret, _ := r.BasicAuth(); id != "pageship" || secret != "client-secret" {
+			http.Error(w, "invalid_client", http.StatusUnauthorized)
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		idToken, _ := token.SignedString(key)
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idToken})
+	})
+	p.Server = httptest.NewServer(mux)
+	return p
+}
+
+// authorize simulates user login at the provider, returning callback URL.
+func (p *fakeOIDCProvider) authorize(t *testing.T, authURL string, claims jwt.MapClaims) string {
+	u, err := url.Parse(authURL)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	q := u.Query()
+
+	code := "code-" + q.Get("state")
+	claims["iss"] = p.URL
+	claims["aud"] = q.Get("client_id")
+	claims["nonce"] = q.Get("nonce")
+	claims["exp"] = time.Now().Add(time.Minute).Unix()
+
+	p.m.Lock()
+	p.codes[code] = claims
+	p.m.Unlock()
+
+	return q.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+}
+
+func newSSOTestHandler(t *testing.T, sso *config.AppSSOConfig, issuers []config.SSOIssuerConfig) func(target string, cookies []*http.Cookie) *http.Response {
+	conf := config.DefaultSiteConfig()
+	conf.Access = config.ACL{{GitHubUser: "octocat"}, {PageshipUser: "user_1"}, {GitHubOrg: "oursky"}}
+	desc := &site.Descriptor{
+		ID:     "test",
+		Config: &conf,
+		SSO:    sso,
+		FS: mapFS{fstest.MapFS{
+			"index.html": {Data: []byte("index"), ModTime: time.Now()},
+		}},
+	}
+
+	handler, err := sitehandler.NewHandler(context.Background(), zap.NewNop(),
+		&mockDomainResolver{}, &descriptorResolver{desc: desc},
+		sitehandler.HandlerConfig{
+			HostPattern: "http://*.pageship.local",
+			Middlewares: middleware.Default,
+			SSO: sitehandler.SSOConfig{
+				SessionKey: []byte("secret"),
+				Issuers:    issuers,
+				ResolveCredentials: func(ctx context.Context, ids []models.CredentialID) ([]models.CredentialID, error) {
+					for _, id := range ids {
+						if id == models.CredentialGitHubUser("linked") {
+							return []models.CredentialID{models.CredentialUserID("user_1")}, nil
+						}
+					}
+					return nil, nil
+				},
+			},
+		})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	h := chimiddleware.RequestLogger(httputil.LogFormatter{Logger: zap.NewNop()})(handler)
+
+	return func(target string, cookies []*http.Cookie) *http.Response {
+		req := httptest.NewRequest("GET", target, nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		return w.Result()
+	}
+}
+
+func sessionCookie(resp *http.Response) []*http.Cookie {
+	for _, c := range resp.Cookies() {
+		if c.Name == "pageship-session" {
+			return []*http.Cookie{c}
+		}
+	}
+	return nil
+}
+
+func TestHandleSSO(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+	defer provider.Close()
+
+	serve := newSSOTestHandler(t,
+		&config.AppSSOConfig{
+			Issuer:          provider.URL,
+			ClientID:        "pageship",
+			GitHubUserClaim: "preferred_username",
+			GitHubOrgsClaim: "groups",
+		},
+		[]config.SSOIssuerConfig{{
+			Issuer:        provider.URL,
+			ClientSecrets: map[string]string{"pageship": "client-secret"},
+		}},
+	)
+
+	login := func(username string, groups ...string) *http.Response {
+		resp := serve("http://test.pageship.local/docs?page=1", nil)
+		if !assert.Equal(t, http.StatusFound, resp.StatusCode) {
+			t.FailNow()
+		}
+		callback := provider.authorize(t, resp.Header.Get("Location"), jwt.MapClaims{
+			"sub":                "sub-" + username,
+			"preferred_username": username,
+			"groups":             groups,
+		})
+		return serve(callback, resp.Cookies())
+	}
+
+	t.Run("Should redirect to provider", func(t *testing.T) {
+		resp := serve("http://test.pageship.local/", nil)
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+		u, err := url.Parse(resp.Header.Get("Location"))
+		assert.NoError(t, err)
+		assert.Equal(t, provider.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+		assert.Equal(t, "pageship", u.Query().Get("client_id"))
+		assert.Equal(t, "http://test.pageship.local/.pageship/sso/callback", u.Query().Get("redirect_uri"))
+		assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	})
+
+	t.Run("Should allow visitor matching ACL", func(t *testing.T) {
+		resp := login("octocat")
+		assert.Equal(t, http.StatusFound, resp.StatusCode)
+		assert.Equal(t, "/docs?page=1", resp.Header.Get("Location"))
+
+		cookies := sessionCookie(resp)
+		assert.Len(t, cookies, 1)
+		assert.Equal(t, http.StatusOK, serve("http://test.pageship.local/", cookies).StatusCode)
+
+		// Session is bound to the host.
+		assert.Equal(t, http.StatusFound, serve("http://dev.test.pageship.local/", cookies).StatusCode)
+	})
+
+	t.Run("Should allow visitor linked to Pageship user", func(t *testing.T) {
+		cookies := sessionCookie(login("linked"))
+		assert.Equal(t, http.StatusOK, serve("http://test.pageship.local/", cookies).StatusCode)
+	})
+
+	t.Run("Should allow visitor in GitHub organization", func(t *testing.T) {
+		cookies := sessionCookie(login("someone", "github", "oursky:developers"))
+		assert.Equal(t, http.StatusOK, serve("http://test.pageship.local/", cookies).StatusCode)
+	})
+
+	t.Run("Should deny visitor not matching ACL", func(t *testing.T) {
+		cookies := sessionCookie(login("someone", "github"))
+		assert.Equal(t, http.StatusFound, serve("http://test.pageship.local/", cookies).StatusCode)
+	})
+
+	t.Run("Should reject mismatched state", func(t *testing.T) {
+		resp := serve("http://test.pageship.local/", nil)
+		callback := provider.authorize(t, resp.Header.Get("Location"), jwt.MapClaims{
+			"preferred_username": "octocat",
+		})
+		assert.Equal(t, http.StatusBadRequest, serve(callback, nil).StatusCode)
+	})
+}
+
+func TestHandleSSOUntrustedIssuer(t *testing.T) {
+	provider := newFakeOIDCProvider(t)
+	defer provider.Close()
+
+	serve := newSSOTestHandler(t,
+		&config.AppSSOConfig{Issuer: provider.URL, ClientID: "pageship"},
+		[]config.SSOIssuerConfig{{Issuer: "https://dex.example.com"}},
+	)
+
+	assert.Equal(t, http.StatusNotFound, serve("http://test.pageship.local/", nil).StatusCode)
+	assert.Equal(t, http.StatusNotFound, serve("http://test.pageship.local/.pageship/sso/login", nil).StatusCode)
+}
+
+func TestHandleSSOGitHub(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/login/oauth/access_token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("client_secret") != "client-secret" {
+			json.NewEncoder(w).Encode(map[string]string{"error": "incorrect_client_credentials"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "token-" + r.FormValue("code")})
+	})
+	mux.HandleFunc("/api/user", func(w http.ResponseWriter, r *http.Request) {
+		login, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer token-")
+		json.NewEncoder(w).Encode(map[string]string{"login": login})
+	})
+	mux.HandleFunc("/api/user/orgs", func(w http.ResponseWriter, r *http.Request) {
+		orgs := []map[string]string{{"login": "github"}}
+		if r.Header.Get("Authorization") == "Bearer token-member" {
+			orgs = append(orgs, map[string]string{"login": "Oursky"})
+		}
+		json.NewEncoder(w).Encode(orgs)
+	})
+	github := httptest.NewServer(mux)
+	defer github.Close()
+
+	serve := newSSOTestHandler(t,
+		&config.AppSSOConfig{Issuer: github.URL, ClientID: "pageship"},
+		[]config.SSOIssuerConfig{{
+			Issuer:        github.URL,
+			Kind:          config.SSOIssuerKindGitHub,
+			GitHubAPIURL:  github.URL + "/api",
+			ClientSecrets: map[string]string{"pageship": "client-secret"},
+		}},
+	)
+
+	login := func(username string) *http.Response {
+		resp := serve("http://test.pageship.local/", nil)
+		if !assert.Equal(t, http.StatusFound, resp.StatusCode) {
+			t.FailNow()
+		}
+		u, err := url.Parse(resp.Header.Get("Location"))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, github.URL+"/login/oauth/authorize", u.Scheme+"://"+u.Host+u.Path)
+		assert.Equal(t, "read:org", u.Query().Get("scope"))
+
+		callback := u.Query().Get("redirect_uri") + "?" + url.Values{
+			"code":  {username},
+			"state": {u.Query().Get("state")},
+		}.Encode()
+		return serve(callback, resp.Cookies())
+	}
+
+	cookies := sessionCookie(login("member"))
+	assert.Equal(t, http.StatusOK, serve("http://test.pageship.local/", cookies).StatusCode)
+
+	cookies = sessionCookie(login("octocat"))
+	assert.Equal(t, http.StatusOK, serve("http://test.pageship.local/", cookies).StatusCode)
+
+	cookies = sessionCookie(login("someone"))
+	assert.Equal(t, http.StatusFound, serve("http://test.pageship.local/", cookies).StatusCode)
+}
@@ -12,6 +12,8 @@ import (
 	"github.com/caddyserver/certmagic"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/oursky/pageship/internal/metrics"
+	"github.com/oursky/pageship/internal/tracing"
 	"go.uber.org/zap"
 	"golang.org/x/sync/errgroup"
 )
@@ -75,6 +77,7 @@ func (s *Server) serveTLS(ctx context.Context, server *http.Server) error {
 
 func (s *Server) buildHandler(handler http.Handler) http.Handler {
 	middlewares := chi.Chain(
+		tracing.Middleware("http.server"),
 		RequestId,
 		middleware.RequestLogger(LogFormatter{Logger: s.Logger}),
 		middleware.Recoverer,
@@ -89,6 +92,7 @@ func (s *Server) setupTLS(ctx context.Context, httpHandler *http.Handler) (*http
 		OnDemand: &certmagic.OnDemandConfig{
 			DecisionFunc: s.TLS.CheckDomain,
 		},
+		OnEvent: onCertEvent,
 	}
 
 	cache := certmagic.NewCache(certmagic.CacheOptions{
@@ -140,6 +144,14 @@ func (s *Server) setupTLS(ctx context.Context, httpHandler *http.Handler) (*http
 	return server, nil
 }
 
+func onCertEvent(ctx context.Context, event string, data map[string]any) error {
+	switch event {
+	case "cert_obtaining", "cert_obtained", "cert_failed", "cert_ocsp_revoked":
+		metrics.CertEvents.WithLabelValues(event).Inc()
+	}
+	return nil
+}
+
 func (s *Server) Run(ctx context.Context) error {
 	httpHandler := s.buildHandler(s.Handler)
 
@@ -0,0 +1,102 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "pageship"
+
+var Registry = prometheus.NewRegistry()
+
+var (
+	SiteRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "site",
+		Name:      "requests_total",
+		Help:      "Number of requests served by sites.",
+	}, []string{"site", "code"})
+
+	SiteRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "site",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of requests served by sites.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"site"})
+
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Number of cache lookups.",
+	}, []string{"cache", "result"})
+
+	CacheSize = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "cache",
+		Name:      "size_bytes",
+		Help:      "Total size of cached content.",
+	}, []string{"cache"})
+
+	StorageReadDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "storage",
+		Name:      "read_duration_seconds",
+		Help:      "Duration of opening objects for read from storage.",
+		Buckets:   prometheus.DefBuckets,
+	})
+
+	DeploymentUploadSize = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "deployment",
+		Name:      "upload_size_bytes",
+		Help:      "Size of uploaded deployment tarballs.",
+		Buckets:   prometheus.ExponentialBuckets(1024, 4, 10),
+	})
+
+	CronJobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "cron",
+		Name:      "job_duration_seconds",
+		Help:      "Duration of cron job runs.",
+		Buckets:   []float64{0.1, 0.5, 1, 5, 10, 30, 60, 300, 900},
+	}, []string{"job"})
+
+	CronJobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cron",
+		Name:      "job_runs_total",
+		Help:      "Number of cron job runs by result.",
+	}, []string{"job", "result"})
+
+	CertEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "cert",
+		Name:      "events_total",
+		Help:      "Number of certificate management events.",
+	}, []string{"event"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		SiteRequests,
+		SiteRequestDuration,
+		CacheRequests,
+		CacheSize,
+		StorageReadDuration,
+		DeploymentUploadSize,
+		CronJobDuration,
+		CronJobRuns,
+		CertEvents,
+	)
+}
+
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/oursky/pageship/internal/config"
+)
+
+const APITokenPrefix = "pageship_"
+
+type APIToken struct {
+	ID         string              `json:"id" db:"id"`
+	CreatedAt  time.Time           `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time           `json:"updatedAt" db:"updated_at"`
+	DeletedAt  *time.Time          `json:"deletedAt" db:"deleted_at"`
+	UserID     string              `json:"userID" db:"user_id"`
+	Name       string              `json:"name" db:"name"`
+	TokenHash  string              `json:"-" db:"token_hash"`
+	AppID      *string             `json:"appID" db:"app_id"`
+	Access     *config.AccessLevel `json:"access" db:"access"`
+	ExpiresAt  *time.Time          `json:"expiresAt" db:"expires_at"`
+	LastUsedAt *time.Time          `json:"lastUsedAt" db:"last_used_at"`
+}
+
+// NewAPIToken creates a new API token, returning the token value to be shown
+// to user once; only its hash is stored.
+func NewAPIToken(
+	now time.Time,
+	userID string,
+	name string,
+	appID *string,
+	access *config.AccessLevel,
+	expiresAt *time.Time,
+) (*APIToken, string) {
+	token := APITokenPrefix + RandomID(32)
+	return &APIToken{
+		ID:         newID("token"),
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		DeletedAt:  nil,
+		UserID:     userID,
+		Name:       name,
+		TokenHash:  HashAPIToken(token),
+		AppID:      appID,
+		Access:     access,
+		ExpiresAt:  expiresAt,
+		LastUsedAt: nil,
+	}, token
+}
+
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
+
+func HashAPIToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+func (t *APIToken) CheckAlive(now time.Time) error {
+	if t.ExpiresAt != nil && !now.Before(*t.ExpiresAt) {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
+
+// CheckScope checks whether the token may be used to access the app with
+// the access level.
+func (t *APIToken) CheckScope(appID string, level config.AccessLevel) error {
+	if t.AppID != nil && *t.AppID != appID {
+		return ErrAccessDenied
+	}
+	if t.Access != nil && !t.Access.CanAccess(level) {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// IsScoped indicates whether the token is limited to an app or access level.
+func (t *APIToken) IsScoped() bool {
+	return t.AppID != nil || (t.Access != nil && *t.Access != config.AccessLevelAdmin)
+}
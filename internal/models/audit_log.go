@@ -0,0 +1,70 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	AuditActionAppConfigUpdate  = "app.config.update"
+	AuditActionAppDelete        = "app.delete"
+	AuditActionAppUserAdd       = "app.user.add"
+	AuditActionAppUserRemove    = "app.user.remove"
+	AuditActionDomainActivate   = "domain.activate"
+	AuditActionDomainDeactivate = "domain.deactivate"
+	AuditActionDeploymentCreate = "deployment.create"
+	AuditActionDeploymentUpload = "deployment.upload"
+	AuditActionDeploymentDelete = "deployment.delete"
+	AuditActionSiteUpdate       = "site.update"
+	AuditActionSiteDelete       = "site.delete"
+)
+
+// AuditLog records a mutation of app resources through the controller.
+type AuditLog struct {
+	ID        string    `json:"id" db:"id"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+	AppID     string    `json:"appID" db:"app_id"`
+
+	Action  string          `json:"action" db:"action"`
+	Target  string          `json:"target" db:"target"`
+	Details AuditLogDetails `json:"details" db:"details"`
+
+	Subject        string `json:"subject" db:"subject"`
+	CredentialID   string `json:"credentialID" db:"credential_id"`
+	CredentialRule string `json:"credentialRule" db:"credential_rule"`
+	RequestID      string `json:"requestID" db:"request_id"`
+	SourceIP       string `json:"sourceIP" db:"source_ip"`
+}
+
+func NewAuditLog(now time.Time, appID string, action string, target string, details AuditLogDetails) *AuditLog {
+	if details == nil {
+		details = AuditLogDetails{}
+	}
+	return &AuditLog{
+		ID:        newID("audit"),
+		CreatedAt: now,
+		AppID:     appID,
+		Action:    action,
+		Target:    target,
+		Details:   details,
+	}
+}
+
+type AuditLogDetails map[string]string
+
+func (d *AuditLogDetails) Scan(val any) error {
+	switch v := val.(type) {
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return fmt.Errorf("unsupported type: %T", v)
+	}
+}
+
+func (d AuditLogDetails) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
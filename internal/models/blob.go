@@ -0,0 +1,32 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type Blob struct {
+	AppID      string    `json:"appID" db:"app_id"`
+	Hash       string    `json:"hash" db:"hash"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+	LastUsedAt time.Time `json:"lastUsedAt" db:"last_used_at"`
+	Size       int64     `json:"size" db:"size"`
+	StorageKey string    `json:"-" db:"storage_key"`
+}
+
+func NewBlob(now time.Time, appID string, hash string, size int64, storageKeyPrefix string) *Blob {
+	return &Blob{
+		AppID:      appID,
+		Hash:       hash,
+		CreatedAt:  now,
+		LastUsedAt: now,
+		Size:       size,
+		StorageKey: BlobKeyPrefix(storageKeyPrefix, appID) + hash,
+	}
+}
+
+// BlobKeyPrefix returns the storage key prefix of content-addressed file
+// blobs; blobs are shared among deployments of same app.
+func BlobKeyPrefix(storageKeyPrefix string, appID string) string {
+	return fmt.Sprintf("%s%s/blobs/", storageKeyPrefix, appID)
+}
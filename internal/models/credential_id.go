@@ -12,8 +12,13 @@ type CredentialIDKind string
 const (
 	CredentialIDKindUserID              CredentialIDKind = ""
 	CredentialIDKindGitHubUser          CredentialIDKind = "github"
+	CredentialIDGitHubOrg               CredentialIDKind = "github-org"
 	CredentialIDGitHubRepositoryActions CredentialIDKind = "github-repo-actions"
 	CredentialIDIP                      CredentialIDKind = "ip"
+	CredentialIDBasicAuth               CredentialIDKind = "basic-auth"
+	CredentialIDGitLabProject           CredentialIDKind = "gitlab-project"
+	CredentialIDOIDC                    CredentialIDKind = "oidc"
+	CredentialIDSSHPrincipal            CredentialIDKind = "ssh-principal"
 )
 
 type CredentialID string
@@ -26,6 +31,12 @@ func CredentialGitHubUser(username string) CredentialID {
 	return CredentialID(string(CredentialIDKindGitHubUser) + ":" + username)
 }
 
+// CredentialGitHubOrg makes credential from membership of GitHub
+// organization.
+func CredentialGitHubOrg(org string) CredentialID {
+	return CredentialID(string(CredentialIDGitHubOrg) + ":" + org)
+}
+
 func CredentialGitHubRepositoryActions(repo string) CredentialID {
 	return CredentialID(string(CredentialIDGitHubRepositoryActions) + ":" + repo)
 }
@@ -33,6 +44,28 @@ func CredentialIP(ip string) CredentialID {
 	return CredentialID(string(CredentialIDIP) + ":" + ip)
 }
 
+func CredentialBasicAuth(username string) CredentialID {
+	return CredentialID(string(CredentialIDBasicAuth) + ":" + username)
+}
+
+// CredentialGitLabProject makes credential from GitLab project path of CI job,
+// in token issued by named OIDC issuer.
+func CredentialGitLabProject(issuerName string, project string) CredentialID {
+	return CredentialID(string(CredentialIDGitLabProject) + ":" + issuerName + ":" + project)
+}
+
+// CredentialOIDC makes credential from a claim value of token issued by
+// named OIDC issuer.
+func CredentialOIDC(issuerName string, value string) CredentialID {
+	return CredentialID(string(CredentialIDOIDC) + ":" + issuerName + ":" + value)
+}
+
+// CredentialSSHPrincipal makes credential from principal of SSH certificate
+// signed by trusted CA.
+func CredentialSSHPrincipal(principal string) CredentialID {
+	return CredentialID(string(CredentialIDSSHPrincipal) + ":" + principal)
+}
+
 func (c CredentialID) Matches(r *config.ACLSubjectRule) bool {
 	kind, data, found := strings.Cut(string(c), ":")
 	if !found {
@@ -45,6 +78,8 @@ func (c CredentialID) Matches(r *config.ACLSubjectRule) bool {
 		return r.PageshipUser != "" && r.PageshipUser == data
 	case CredentialIDKindGitHubUser:
 		return r.GitHubUser != "" && strings.EqualFold(r.GitHubUser, data)
+	case CredentialIDGitHubOrg:
+		return r.GitHubOrg != "" && strings.EqualFold(r.GitHubOrg, data)
 	case CredentialIDGitHubRepositoryActions:
 		if r.GitHubRepositoryActions == "*" {
 			return true
@@ -77,7 +112,34 @@ func (c CredentialID) Matches(r *config.ACLSubjectRule) bool {
 
 		return cidr.Contains(addr)
 
+	case CredentialIDBasicAuth:
+		return r.BasicAuth != "" && (r.BasicAuth == "*" || r.BasicAuth == data)
+
+	case CredentialIDGitLabProject:
+		return r.GitLabProject != "" && matchIssuerPathPattern(r.GitLabProject, data)
+
+	case CredentialIDOIDC:
+		return r.OIDC != "" && matchIssuerPathPattern(r.OIDC, data)
+
+	case CredentialIDSSHPrincipal:
+		return r.SSHPrincipal != "" && (r.SSHPrincipal == "*" || r.SSHPrincipal == data)
+
 	default:
 		return false
 	}
 }
+
+// matchIssuerPathPattern matches `<issuer>:<path>` against rule
+// `<issuer>:<pattern>`. The path is matched case-insensitively, using the
+// same glob syntax as path patterns in site config.
+func matchIssuerPathPattern(rule string, data string) bool {
+	ruleIssuer, pattern, ok := strings.Cut(rule, ":")
+	if !ok {
+		return false
+	}
+	issuer, path, ok := strings.Cut(data, ":")
+	if !ok || ruleIssuer != issuer {
+		return false
+	}
+	return config.MatchPathPattern("/"+strings.ToLower(pattern), "/"+strings.ToLower(path))
+}
@@ -31,6 +31,21 @@ func TestGitHubUserCredentials(t *testing.T) {
 	))
 }
 
+func TestGitHubOrgCredentials(t *testing.T) {
+	assert.True(t, matchRule(
+		&config.ACLSubjectRule{GitHubOrg: "oursky"},
+		models.CredentialGitHubOrg("Oursky"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{GitHubOrg: "oursky"},
+		models.CredentialGitHubOrg("github"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{GitHubUser: "oursky"},
+		models.CredentialGitHubOrg("oursky"),
+	))
+}
+
 func TestGitHubActionsCredentials(t *testing.T) {
 	assert.True(t, matchRule(
 		&config.ACLSubjectRule{GitHubRepositoryActions: "*"},
@@ -63,3 +78,107 @@ func TestGitHubActionsCredentials(t *testing.T) {
 		models.CredentialGitHubRepositoryActions("Oursky/Example"),
 	))
 }
+
+func TestBasicAuthCredentials(t *testing.T) {
+	assert.True(t, matchRule(
+		&config.ACLSubjectRule{BasicAuth: "client"},
+		models.CredentialBasicAuth("client"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{BasicAuth: "client"},
+		models.CredentialBasicAuth("Client"),
+	))
+	assert.True(t, matchRule(
+		&config.ACLSubjectRule{BasicAuth: "*"},
+		models.CredentialBasicAuth("client"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{IpRange: "0.0.0.0/0"},
+		models.CredentialBasicAuth("client"),
+	))
+}
+
+func TestGitLabProjectCredentials(t *testing.T) {
+	assert.True(t, matchRule(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:**"},
+		models.CredentialGitLabProject("gitlab", "oursky/pageship"),
+	))
+	assert.True(t, matchRule(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:oursky/**"},
+		models.CredentialGitLabProject("gitlab", "Oursky/web/pageship"),
+	))
+	assert.True(t, matchRule(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:oursky/*"},
+		models.CredentialGitLabProject("gitlab", "oursky/pageship"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:oursky/*"},
+		models.CredentialGitLabProject("gitlab", "oursky/web/pageship"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:oursky/**"},
+		models.CredentialGitLabProject("gitlab", "oursky-other/pageship"),
+	))
+	assert.True(t, matchRule(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:oursky/pageship"},
+		models.CredentialGitLabProject("gitlab", "oursky/pageship"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:oursky/pageship"},
+		models.CredentialGitLabProject("gitlab", "oursky/pageship/sub"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:oursky/pageship"},
+		models.CredentialGitLabProject("self-hosted", "oursky/pageship"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{GitLabProject: "oursky/pageship"},
+		models.CredentialGitLabProject("gitlab", "oursky/pageship"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{OIDC: "gitlab:oursky/pageship"},
+		models.CredentialGitLabProject("gitlab", "oursky/pageship"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{GitHubRepositoryActions: "oursky/pageship"},
+		models.CredentialGitLabProject("gitlab", "oursky/pageship"),
+	))
+}
+
+func TestOIDCCredentials(t *testing.T) {
+	assert.True(t, matchRule(
+		&config.ACLSubjectRule{OIDC: "forgejo:oursky/*"},
+		models.CredentialOIDC("forgejo", "oursky/pageship"),
+	))
+	assert.True(t, matchRule(
+		&config.ACLSubjectRule{OIDC: "forgejo:**"},
+		models.CredentialOIDC("forgejo", "oursky/pageship"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{OIDC: "forgejo:oursky/*"},
+		models.CredentialOIDC("other", "oursky/pageship"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{OIDC: "forgejo:oursky/pageship"},
+		models.CredentialOIDC("forgejo", "oursky/other"),
+	))
+}
+
+func TestSSHPrincipalCredentials(t *testing.T) {
+	assert.True(t, matchRule(
+		&config.ACLSubjectRule{SSHPrincipal: "alice"},
+		models.CredentialSSHPrincipal("alice"),
+	))
+	assert.True(t, matchRule(
+		&config.ACLSubjectRule{SSHPrincipal: "*"},
+		models.CredentialSSHPrincipal("alice"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{SSHPrincipal: "alice"},
+		models.CredentialSSHPrincipal("bob"),
+	))
+	assert.False(t, matchRule(
+		&config.ACLSubjectRule{GitHubUser: "alice"},
+		models.CredentialSSHPrincipal("alice"),
+	))
+}
@@ -21,6 +21,9 @@ func MakeCredentialIDIndexKeys(id CredentialID) []CredentialIndexKey {
 		CredentialIDKindGitHubUser:
 		return []CredentialIndexKey{CredentialIndexKey(id)}
 
+	case CredentialIDGitHubOrg:
+		return []CredentialIndexKey{CredentialIndexKey(string(CredentialIDGitHubOrg) + ":" + strings.ToLower(data))}
+
 	case CredentialIDGitHubRepositoryActions:
 		owner, repo, ok := strings.Cut(data, "/")
 		if !ok {
@@ -33,6 +36,20 @@ func MakeCredentialIDIndexKeys(id CredentialID) []CredentialIndexKey {
 			CredentialIndexKey(prefix + owner + "/" + repo),
 		}
 
+	case CredentialIDSSHPrincipal:
+		prefix := string(CredentialIDSSHPrincipal) + ":"
+		return []CredentialIndexKey{
+			CredentialIndexKey(prefix + "*"),
+			CredentialIndexKey(prefix + data),
+		}
+
+	case CredentialIDGitLabProject, CredentialIDOIDC:
+		issuer, value, ok := strings.Cut(data, ":")
+		if !ok {
+			return nil
+		}
+		return makePathKeys(kind+":"+issuer+":", value)
+
 	case CredentialIDIP:
 		addr, err := netip.ParseAddr(data)
 		if err != nil {
@@ -61,6 +78,8 @@ func MakeCredentialRuleIndexKeys(r *config.ACLSubjectRule) []CredentialIndexKey
 		return MakeCredentialIDIndexKeys(CredentialUserID(r.PageshipUser))
 	case r.GitHubUser != "":
 		return MakeCredentialIDIndexKeys(CredentialGitHubUser(r.GitHubUser))
+	case r.GitHubOrg != "":
+		return MakeCredentialIDIndexKeys(CredentialGitHubOrg(r.GitHubOrg))
 	case r.GitHubRepositoryActions != "":
 		prefix := string(CredentialIDGitHubRepositoryActions) + ":"
 		if r.GitHubRepositoryActions == "*" {
@@ -76,6 +95,12 @@ func MakeCredentialRuleIndexKeys(r *config.ACLSubjectRule) []CredentialIndexKey
 		} else {
 			return []CredentialIndexKey{CredentialIndexKey(prefix + owner + "/" + repo)}
 		}
+	case r.GitLabProject != "":
+		return makeIssuerPathPatternKeys(CredentialIDGitLabProject, r.GitLabProject)
+	case r.OIDC != "":
+		return makeIssuerPathPatternKeys(CredentialIDOIDC, r.OIDC)
+	case r.SSHPrincipal != "":
+		return []CredentialIndexKey{CredentialIndexKey(string(CredentialIDSSHPrincipal) + ":" + r.SSHPrincipal)}
 	case r.IpRange != "":
 		cidr, err := netip.ParsePrefix(r.IpRange)
 		if err != nil {
@@ -107,3 +132,40 @@ func makeIPKeys(addr netip.Addr, bits int) []CredentialIndexKey {
 	}
 	return keys
 }
+
+func makePathKeys(prefix string, path string) []CredentialIndexKey {
+	keys := []CredentialIndexKey{CredentialIndexKey(prefix + "*")}
+
+	path = strings.ToLower(path)
+	for i, c := range path {
+		if c == '/' {
+			keys = append(keys, CredentialIndexKey(prefix+path[:i]))
+		}
+	}
+	return append(keys, CredentialIndexKey(prefix+path))
+}
+
+func makeIssuerPathPatternKeys(kind CredentialIDKind, rule string) []CredentialIndexKey {
+	issuer, pattern, ok := strings.Cut(rule, ":")
+	if !ok {
+		return nil
+	}
+	return []CredentialIndexKey{makePathPatternKey(string(kind)+":"+issuer+":", pattern)}
+}
+
+// makePathPatternKey makes key from the literal leading segments of pattern,
+// which is a common ancestor of all matching paths.
+func makePathPatternKey(prefix string, pattern string) CredentialIndexKey {
+	var literal []string
+	for _, seg := range strings.Split(strings.ToLower(pattern), "/") {
+		if strings.ContainsAny(seg, `*?[\`) {
+			break
+		}
+		literal = append(literal, seg)
+	}
+
+	if len(literal) == 0 {
+		return CredentialIndexKey(prefix + "*")
+	}
+	return CredentialIndexKey(prefix + strings.Join(literal, "/"))
+}
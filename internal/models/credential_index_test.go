@@ -154,3 +154,67 @@ func FuzzIPCredentialsIndex(f *testing.F) {
 			models.MakeCredentialRuleIndexKeys(rule), models.MakeCredentialIDIndexKeys(cred))
 	})
 }
+
+func TestGitLabProjectCredentialsIndex(t *testing.T) {
+	assert.True(t, matchIndex(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:oursky/pageship"},
+		models.CredentialGitLabProject("gitlab", "Oursky/Pageship"),
+	))
+	assert.True(t, matchIndex(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:oursky/**"},
+		models.CredentialGitLabProject("gitlab", "oursky/web/pageship"),
+	))
+	assert.True(t, matchIndex(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:oursky/web-*/pageship"},
+		models.CredentialGitLabProject("gitlab", "oursky/web-1/pageship"),
+	))
+	assert.False(t, matchIndex(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:oursky/**"},
+		models.CredentialGitLabProject("gitlab", "other/pageship"),
+	))
+	assert.False(t, matchIndex(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:oursky/**"},
+		models.CredentialGitLabProject("self-hosted", "oursky/pageship"),
+	))
+	assert.True(t, matchIndex(
+		&config.ACLSubjectRule{GitLabProject: "gitlab:**"},
+		models.CredentialGitLabProject("gitlab", "other/pageship"),
+	))
+}
+
+func TestOIDCCredentialsIndex(t *testing.T) {
+	assert.True(t, matchIndex(
+		&config.ACLSubjectRule{OIDC: "forgejo:oursky/*"},
+		models.CredentialOIDC("forgejo", "oursky/pageship"),
+	))
+	assert.False(t, matchIndex(
+		&config.ACLSubjectRule{OIDC: "forgejo:oursky/*"},
+		models.CredentialOIDC("other", "oursky/pageship"),
+	))
+}
+
+func TestSSHPrincipalCredentialsIndex(t *testing.T) {
+	assert.True(t, matchIndex(
+		&config.ACLSubjectRule{SSHPrincipal: "alice"},
+		models.CredentialSSHPrincipal("alice"),
+	))
+	assert.True(t, matchIndex(
+		&config.ACLSubjectRule{SSHPrincipal: "*"},
+		models.CredentialSSHPrincipal("alice"),
+	))
+	assert.False(t, matchIndex(
+		&config.ACLSubjectRule{SSHPrincipal: "alice"},
+		models.CredentialSSHPrincipal("bob"),
+	))
+}
+
+func TestGitHubOrgCredentialsIndex(t *testing.T) {
+	assert.True(t, matchIndex(
+		&config.ACLSubjectRule{GitHubOrg: "Oursky"},
+		models.CredentialGitHubOrg("oursky"),
+	))
+	assert.False(t, matchIndex(
+		&config.ACLSubjectRule{GitHubOrg: "oursky"},
+		models.CredentialGitHubOrg("github"),
+	))
+}
@@ -19,6 +19,7 @@ type Deployment struct {
 	AppID string `json:"appID" db:"app_id"`
 
 	StorageKeyPrefix string              `json:"-" db:"storage_key_prefix"`
+	BlobKeyPrefix    *string             `json:"-" db:"blob_key_prefix"`
 	Metadata         *DeploymentMetadata `json:"metadata" db:"metadata"`
 	UploadedAt       *time.Time          `json:"uploadedAt" db:"uploaded_at"`
 	ExpireAt         *time.Time          `json:"expireAt" db:"expire_at"`
@@ -41,6 +42,7 @@ func NewDeployment(
 		AppID:     appID,
 
 		StorageKeyPrefix: fmt.Sprintf("%s%s/%s", storageKeyPrefix, appID, id),
+		BlobKeyPrefix:    nil,
 		Metadata:         metadata,
 		UploadedAt:       nil,
 		ExpireAt:         nil,
@@ -63,6 +65,49 @@ func (d *Deployment) CheckAlive(now time.Time) error {
 	return nil
 }
 
+// StorageKey returns the storage key of file content.
+func (d *Deployment) StorageKey(entry FileEntry) string {
+	if d.BlobKeyPrefix != nil {
+		return *d.BlobKeyPrefix + entry.Hash
+	}
+	// Legacy deployments store files by path
+	return d.StorageKeyPrefix + entry.Path
+}
+
+// EncodingStorageKey returns the storage key of pre-compressed file content.
+func (d *Deployment) EncodingStorageKey(encoding FileEncoding) string {
+	if d.BlobKeyPrefix == nil {
+		// Legacy deployments have no pre-compressed content
+		return ""
+	}
+	return *d.BlobKeyPrefix + encoding.Hash
+}
+
+// BlobHashes returns the distinct hashes of file blobs in the deployment,
+// including pre-compressed content.
+func (d *Deployment) BlobHashes() []string {
+	var hashes []string
+	seen := make(map[string]struct{})
+	add := func(hash string) {
+		if hash == "" {
+			return
+		}
+		if _, ok := seen[hash]; ok {
+			return
+		}
+		seen[hash] = struct{}{}
+		hashes = append(hashes, hash)
+	}
+
+	for _, entry := range d.Metadata.Files {
+		add(entry.Hash)
+		for _, enc := range entry.Encodings {
+			add(enc.Hash)
+		}
+	}
+	return hashes
+}
+
 type DeploymentMetadata struct {
 	Files  []FileEntry       `json:"files,omitempty"`
 	Config config.SiteConfig `json:"config"`
@@ -4,15 +4,18 @@ import "errors"
 
 var ErrAppUsedID = errors.New("used app ID")
 var ErrAppNotFound = errors.New("app not found")
+var ErrInvalidAppConfig = errors.New("invalid app config")
 
 var ErrUndefinedSite = errors.New("undefined site")
 var ErrSiteNotFound = errors.New("site not found")
+var ErrNoPreviousDeployment = errors.New("no previous deployment")
 
 var ErrDeploymentNotFound = errors.New("deployment not found")
 var ErrDeploymentUsedName = errors.New("used deployment name")
 var ErrDeploymentNotUploaded = errors.New("deployment is not uploaded")
 var ErrDeploymentAlreadyUploaded = errors.New("deployment is already uploaded")
 var ErrDeploymentExpired = errors.New("deployment expired")
+var ErrDeploymentActive = errors.New("deployment is active")
 
 var ErrUndefinedDomain = errors.New("undefined domain")
 var ErrDomainNotFound = errors.New("domain not found")
@@ -24,3 +27,11 @@ var ErrInvalidCredentials = errors.New("invalid credentials")
 
 var ErrCertificateDataNotFound = errors.New("cert data not found")
 var ErrCertificateDataLocked = errors.New("cert locked")
+
+var ErrAPITokenNotFound = errors.New("API token not found")
+
+var ErrRefreshTokenNotFound = errors.New("refresh token not found")
+var ErrRefreshTokenUsed = errors.New("refresh token is already used")
+
+var ErrSSHKeyNotFound = errors.New("SSH key not found")
+var ErrSSHKeyUsed = errors.New("SSH key is already registered")
@@ -3,17 +3,48 @@ package models
 import (
 	"mime"
 	"path"
+	"strings"
 
 	"github.com/h2non/filetype"
 )
 
 const MaxFiles = 10000
 
+// ReservedPathPrefix is the path prefix reserved for content generated or
+// served by pageship, e.g. pre-compressed variants; deployed files cannot be
+// placed in it.
+const ReservedPathPrefix = "/.pageship/"
+
+func IsReservedPath(filePath string) bool {
+	p := path.Clean("/" + filePath)
+	return p == strings.TrimSuffix(ReservedPathPrefix, "/") || strings.HasPrefix(p, ReservedPathPrefix)
+}
+
 type FileEntry struct {
 	Path        string `json:"path" validate:"required,max=256"` // Directory has trailing slash in path
 	Size        int64  `json:"size" validate:"required,gte=0"`
 	Hash        string `json:"hash" validate:"required,max=100"`
 	ContentType string `json:"contentType" validate:"required,max=100"`
+
+	// Encodings are the pre-compressed variants of file content.
+	Encodings []FileEncoding `json:"encodings,omitempty"`
+}
+
+type FileEncoding struct {
+	Encoding string `json:"encoding"` // Content-Encoding of the variant, e.g. "br"
+	Size     int64  `json:"size"`
+	Hash     string `json:"hash"`
+}
+
+var FileEncodings = []string{"br", "gzip"}
+
+func IsValidFileEncoding(encoding string) bool {
+	for _, e := range FileEncodings {
+		if e == encoding {
+			return true
+		}
+	}
+	return false
 }
 
 func DetectContentType(fileName string, initialBytes []byte) string {
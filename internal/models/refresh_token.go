@@ -0,0 +1,55 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const RefreshTokenPrefix = "pageship-refresh_"
+
+// RefreshToken is a single-use token to obtain new access token for a user.
+// Each use rotates the token within the same family; reusing a rotated token
+// revokes the whole family.
+type RefreshToken struct {
+	ID        string     `db:"id"`
+	CreatedAt time.Time  `db:"created_at"`
+	FamilyID  string     `db:"family_id"`
+	UserID    string     `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+	RevokedAt *time.Time `db:"revoked_at"`
+}
+
+// NewRefreshToken creates a new refresh token, returning the token value to
+// be sent to client; only its hash is stored. A new token family is started
+// if familyID is empty.
+func NewRefreshToken(now time.Time, userID string, familyID string, expiresAt time.Time) (*RefreshToken, string) {
+	id := newID("refresh")
+	if familyID == "" {
+		familyID = id
+	}
+
+	token := RefreshTokenPrefix + RandomID(32)
+	return &RefreshToken{
+		ID:        id,
+		CreatedAt: now,
+		FamilyID:  familyID,
+		UserID:    userID,
+		TokenHash: HashAPIToken(token),
+		ExpiresAt: expiresAt,
+		UsedAt:    nil,
+		RevokedAt: nil,
+	}, token
+}
+
+func IsRefreshToken(token string) bool {
+	return strings.HasPrefix(token, RefreshTokenPrefix)
+}
+
+func (t *RefreshToken) CheckAlive(now time.Time) error {
+	if t.RevokedAt != nil || !now.Before(t.ExpiresAt) {
+		return ErrInvalidCredentials
+	}
+	return nil
+}
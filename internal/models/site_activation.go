@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// SiteActivation records a change of the active deployment of a site.
+type SiteActivation struct {
+	ID                   string    `json:"id" db:"id"`
+	CreatedAt            time.Time `json:"createdAt" db:"created_at"`
+	AppID                string    `json:"appID" db:"app_id"`
+	SiteID               string    `json:"siteID" db:"site_id"`
+	DeploymentID         *string   `json:"deploymentID" db:"deployment_id"`
+	PreviousDeploymentID *string   `json:"previousDeploymentID" db:"previous_deployment_id"`
+	Subject              string    `json:"subject" db:"subject"`
+	CredentialID         string    `json:"credentialID" db:"credential_id"`
+	CredentialRule       string    `json:"credentialRule" db:"credential_rule"`
+}
+
+func NewSiteActivation(now time.Time, site *Site, previousDeploymentID *string) *SiteActivation {
+	return &SiteActivation{
+		ID:                   newID("activation"),
+		CreatedAt:            now,
+		AppID:                site.AppID,
+		SiteID:               site.ID,
+		DeploymentID:         site.DeploymentID,
+		PreviousDeploymentID: previousDeploymentID,
+		Subject:              "",
+		CredentialID:         "",
+		CredentialRule:       "",
+	}
+}
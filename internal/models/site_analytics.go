@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type SiteAnalyticsDimension string
+
+const (
+	SiteAnalyticsTotal    SiteAnalyticsDimension = "total"
+	SiteAnalyticsPath     SiteAnalyticsDimension = "path"
+	SiteAnalyticsReferrer SiteAnalyticsDimension = "referrer"
+	SiteAnalyticsStatus   SiteAnalyticsDimension = "status"
+)
+
+// SiteAnalytics is hourly rollup of site traffic, grouped by dimension value.
+type SiteAnalytics struct {
+	AppID     string                 `db:"app_id"`
+	SiteName  string                 `db:"site_name"`
+	Hour      time.Time              `db:"hour"`
+	Dimension SiteAnalyticsDimension `db:"dimension"`
+	Value     string                 `db:"value"`
+	Requests  int64                  `db:"requests"`
+	Bytes     int64                  `db:"bytes"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHKey is a public key registered by user for SSH login.
+type SSHKey struct {
+	ID          string     `json:"id" db:"id"`
+	CreatedAt   time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time  `json:"updatedAt" db:"updated_at"`
+	DeletedAt   *time.Time `json:"deletedAt" db:"deleted_at"`
+	UserID      string     `json:"userID" db:"user_id"`
+	Name        string     `json:"name" db:"name"`
+	Fingerprint string     `json:"fingerprint" db:"fingerprint"`
+	PublicKey   string     `json:"publicKey" db:"public_key"`
+	LastUsedAt  *time.Time `json:"lastUsedAt" db:"last_used_at"`
+}
+
+func NewSSHKey(now time.Time, userID string, name string, key ssh.PublicKey) *SSHKey {
+	return &SSHKey{
+		ID:          newID("key"),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		DeletedAt:   nil,
+		UserID:      userID,
+		Name:        name,
+		Fingerprint: ssh.FingerprintSHA256(key),
+		PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))),
+		LastUsedAt:  nil,
+	}
+}
+
+// SSHKeyProofData is the data signed with the private key, to prove
+// possession of the key when registering it for the user.
+func SSHKeyProofData(userID string) []byte {
+	return []byte("pageship-ssh-key-registration:" + userID)
+}
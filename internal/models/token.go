@@ -27,11 +27,12 @@ type TokenSubjectKind string
 const (
 	TokenSubjectKindUser          TokenSubjectKind = ""
 	TokenSubjectKindGitHubActions TokenSubjectKind = "github-actions"
+	TokenSubjectKindOIDC          TokenSubjectKind = "oidc"
 )
 
 func (k TokenSubjectKind) IsValid() bool {
 	switch k {
-	case TokenSubjectKindUser, TokenSubjectKindGitHubActions:
+	case TokenSubjectKindUser, TokenSubjectKindGitHubActions, TokenSubjectKindOIDC:
 		return true
 	}
 	return false
@@ -47,6 +48,10 @@ func TokenSubjectGitHubActions(jti string) TokenSubject {
 	return TokenSubject(fmt.Sprintf("%s:%s", TokenSubjectKindGitHubActions, jti))
 }
 
+func TokenSubjectOIDC(issuerName string, jti string) TokenSubject {
+	return TokenSubject(fmt.Sprintf("%s:%s:%s", TokenSubjectKindOIDC, issuerName, jti))
+}
+
 func (s TokenSubject) Parse() (TokenSubjectKind, string, bool) {
 	k, data, ok := strings.Cut(string(s), ":")
 	if !ok {
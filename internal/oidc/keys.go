@@ -14,15 +14,18 @@ import (
 )
 
 type Key struct {
-	Issuer string
-	JWKS   *keyfunc.JWKS
+	Issuer                string
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	JWKS                  *keyfunc.JWKS
 }
 
 type Keys struct {
-	ctx    context.Context
-	l      *rate.Limiter
-	client *http.Client
-	cache  *cache.Cache[*Key]
+	ctx       context.Context
+	l         *rate.Limiter
+	client    *http.Client
+	cache     *cache.Cache[*Key]
+	jwksCache *cache.Cache[*keyfunc.JWKS]
 }
 
 func NewKeys(ctx context.Context) (*Keys, error) {
@@ -32,21 +35,52 @@ func NewKeys(ctx context.Context) (*Keys, error) {
 		client: &http.Client{},
 	}
 
-	cache, err := cache.NewCache(100, time.Hour, keys.load)
+	keysCache, err := cache.NewCache("oidc-keys", 100, time.Hour, keys.load)
 	if err != nil {
 		return nil, err
 	}
-	keys.cache = cache
+	keys.cache = keysCache
+
+	jwksCache, err := cache.NewCache("oidc-jwks", 100, time.Hour, keys.loadJWKS)
+	if err != nil {
+		return nil, err
+	}
+	keys.jwksCache = jwksCache
 
 	return keys, nil
 }
 
 func (k *Keys) Get(issuer string) (*Key, error) {
-	return k.cache.Load(issuer)
+	return k.cache.Load(k.ctx, issuer)
+}
+
+// GetWithJWKS returns keys of the issuer from the JWKS URL, skipping
+// discovery through OpenID configuration.
+func (k *Keys) GetWithJWKS(issuer string, jwksURL string) (*Key, error) {
+	jwks, err := k.jwksCache.Load(k.ctx, jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	return &Key{Issuer: issuer, JWKS: jwks}, nil
+}
+
+func (k *Keys) loadJWKS(ctx context.Context, jwksURL string) (*keyfunc.JWKS, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	jwks, err := keyfunc.Get(jwksURL, keyfunc.Options{
+		Ctx:            ctx,
+		Client:         k.client,
+		RefreshTimeout: time.Second * 10,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get jwks: %w", err)
+	}
+	return jwks, nil
 }
 
-func (k *Keys) load(issuer string) (*Key, error) {
-	ctx, cancel := context.WithTimeout(k.ctx, time.Second*10)
+func (k *Keys) load(ctx context.Context, issuer string) (*Key, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
 	defer cancel()
 
 	u, err := url.Parse(issuer)
@@ -67,8 +101,10 @@ func (k *Keys) load(issuer string) (*Key, error) {
 	defer resp.Body.Close()
 
 	var conf struct {
-		Issuer  string `json:"issuer"`
-		JWKSUri string `json:"jwks_uri"`
+		Issuer                string `json:"issuer"`
+		AuthorizationEndpoint string `json:"authorization_endpoint"`
+		TokenEndpoint         string `json:"token_endpoint"`
+		JWKSUri               string `json:"jwks_uri"`
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&conf); err != nil {
 		return nil, fmt.Errorf("parse openid config: %w", err)
@@ -83,5 +119,10 @@ func (k *Keys) load(issuer string) (*Key, error) {
 		return nil, fmt.Errorf("get jwks: %w", err)
 	}
 
-	return &Key{Issuer: conf.Issuer, JWKS: jwks}, nil
+	return &Key{
+		Issuer:                conf.Issuer,
+		AuthorizationEndpoint: conf.AuthorizationEndpoint,
+		TokenEndpoint:         conf.TokenEndpoint,
+		JWKS:                  jwks,
+	}, nil
 }
@@ -0,0 +1,288 @@
+package db
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/site"
+	"go.uber.org/zap"
+)
+
+const (
+	analyticsQueueSize      = 1024
+	analyticsFlushInterval  = time.Minute
+	analyticsMaxEntries     = 10000
+	analyticsMaxValueLength = 256
+	// analyticsMaxValues is the max number of distinct values of path and
+	// referrer dimensions per site hour, including stored values; exceeding
+	// values are counted as analyticsOtherValue.
+	analyticsMaxValues  = 100
+	analyticsOtherValue = "(other)"
+)
+
+type analyticsEvent struct {
+	appID    string
+	siteName string
+	time     time.Time
+	path     string
+	referrer string
+	status   int
+	bytes    int64
+}
+
+type analyticsKey struct {
+	appID     string
+	siteName  string
+	hour      time.Time
+	dimension models.SiteAnalyticsDimension
+	value     string
+}
+
+// Analytics collects site traffic asynchronously, and periodically writes
+// hourly rollups to DB.
+type Analytics struct {
+	logger *zap.Logger
+	db     db.DB
+	events chan analyticsEvent
+}
+
+func NewAnalytics(logger *zap.Logger, database db.DB) *Analytics {
+	return &Analytics{
+		logger: logger,
+		db:     database,
+		events: make(chan analyticsEvent, analyticsQueueSize),
+	}
+}
+
+// Record records a served request of the site. Events are dropped if the
+// queue is full, to avoid slowing down requests.
+func (a *Analytics) Record(desc *site.Descriptor, r *http.Request, status int, bytes int64) {
+	// Descriptor ID is constructed by Resolver; preview deployments
+	// (with empty site name) are not tracked.
+	parts := strings.SplitN(desc.ID, "/", 3)
+	if len(parts) != 3 || parts[1] == "" {
+		return
+	}
+
+	path := sanitizeValue(r.URL.Path)
+
+	referrer := ""
+	if u, err := url.Parse(r.Referer()); err == nil && u.Host != r.Host {
+		referrer = sanitizeValue(u.Host)
+	}
+
+	event := analyticsEvent{
+		appID:    parts[0],
+		siteName: parts[1],
+		time:     time.Now().UTC(),
+		path:     path,
+		referrer: referrer,
+		status:   status,
+		bytes:    bytes,
+	}
+
+	select {
+	case a.events <- event:
+	default:
+		a.logger.Debug("analytics queue full; dropping event")
+	}
+}
+
+// sanitizeValue makes value valid UTF-8 for storing in DB, and truncates
+// overlong value at rune boundary.
+func sanitizeValue(value string) string {
+	value = strings.ToValidUTF8(value, "\uFFFD")
+	if len(value) <= analyticsMaxValueLength {
+		return value
+	}
+
+	n := analyticsMaxValueLength
+	for n > 0 && !utf8.RuneStart(value[n]) {
+		n--
+	}
+	return value[:n]
+}
+
+func (a *Analytics) Run(ctx context.Context) error {
+	ticker := time.NewTicker(analyticsFlushInterval)
+	defer ticker.Stop()
+
+	batch := newAnalyticsBatch()
+	for {
+		select {
+		case <-ctx.Done():
+			a.drain(batch)
+			a.flush(batch)
+			return nil
+
+		case e := <-a.events:
+			batch.aggregate(e)
+			if len(batch.entries) >= analyticsMaxEntries {
+				a.flush(batch)
+				batch = newAnalyticsBatch()
+			}
+
+		case <-ticker.C:
+			a.flush(batch)
+			batch = newAnalyticsBatch()
+		}
+	}
+}
+
+// drain aggregates queued events without waiting.
+func (a *Analytics) drain(batch *analyticsBatch) {
+	for {
+		select {
+		case e := <-a.events:
+			batch.aggregate(e)
+		default:
+			return
+		}
+	}
+}
+
+type analyticsBatch struct {
+	entries map[analyticsKey]*models.SiteAnalytics
+	// counts is number of distinct values, keyed with empty value.
+	counts map[analyticsKey]int
+}
+
+func newAnalyticsBatch() *analyticsBatch {
+	return &analyticsBatch{
+		entries: make(map[analyticsKey]*models.SiteAnalytics),
+		counts:  make(map[analyticsKey]int),
+	}
+}
+
+// isLimitedDimension reports whether distinct values of the dimension are
+// limited by analyticsMaxValues.
+func isLimitedDimension(dimension models.SiteAnalyticsDimension) bool {
+	return dimension == models.SiteAnalyticsPath || dimension == models.SiteAnalyticsReferrer
+}
+
+func (b *analyticsBatch) aggregate(e analyticsEvent) {
+	hour := e.time.Truncate(time.Hour)
+	add := func(dimension models.SiteAnalyticsDimension, value string) {
+		key := analyticsKey{appID: e.appID, siteName: e.siteName, hour: hour, dimension: dimension, value: value}
+		entry, ok := b.entries[key]
+		if !ok && isLimitedDimension(dimension) {
+			countKey := key
+			countKey.value = ""
+			if b.counts[countKey] >= analyticsMaxValues {
+				key.value = analyticsOtherValue
+				entry, ok = b.entries[key]
+			} else {
+				b.counts[countKey]++
+			}
+		}
+		if !ok {
+			entry = &models.SiteAnalytics{
+				AppID:     e.appID,
+				SiteName:  e.siteName,
+				Hour:      hour,
+				Dimension: dimension,
+				Value:     key.value,
+			}
+			b.entries[key] = entry
+		}
+		entry.Requests++
+		entry.Bytes += e.bytes
+	}
+
+	add(models.SiteAnalyticsTotal, "")
+	add(models.SiteAnalyticsPath, e.path)
+	add(models.SiteAnalyticsStatus, strconv.Itoa(e.status))
+	if e.referrer != "" {
+		add(models.SiteAnalyticsReferrer, e.referrer)
+	}
+}
+
+// limitValues returns the entries to write, with new values exceeding
+// analyticsMaxValues together with values already stored counted as
+// analyticsOtherValue. Most requested values are kept.
+func (b *analyticsBatch) limitValues(ctx context.Context, tx db.Tx) ([]*models.SiteAnalytics, error) {
+	list := make([]*models.SiteAnalytics, 0, len(b.entries))
+	groups := make(map[analyticsKey][]*models.SiteAnalytics)
+	for key, e := range b.entries {
+		if !isLimitedDimension(key.dimension) || key.value == analyticsOtherValue {
+			list = append(list, e)
+			continue
+		}
+		groupKey := key
+		groupKey.value = ""
+		groups[groupKey] = append(groups[groupKey], e)
+	}
+
+	for key, group := range groups {
+		stored, err := tx.ListSiteAnalyticsValues(ctx, key.appID, key.siteName, key.hour, key.dimension)
+		if err != nil {
+			return nil, err
+		}
+		values := make(map[string]struct{}, len(stored))
+		for _, v := range stored {
+			if v != analyticsOtherValue {
+				values[v] = struct{}{}
+			}
+		}
+
+		sort.Slice(group, func(i, j int) bool { return group[i].Requests > group[j].Requests })
+		otherKey := key
+		otherKey.value = analyticsOtherValue
+		other := b.entries[otherKey]
+		for _, e := range group {
+			if _, ok := values[e.Value]; ok || len(values) < analyticsMaxValues {
+				values[e.Value] = struct{}{}
+				list = append(list, e)
+				continue
+			}
+
+			if other == nil {
+				other = &models.SiteAnalytics{
+					AppID:     key.appID,
+					SiteName:  key.siteName,
+					Hour:      key.hour,
+					Dimension: key.dimension,
+					Value:     analyticsOtherValue,
+				}
+				list = append(list, other)
+			}
+			other.Requests += e.Requests
+			other.Bytes += e.Bytes
+		}
+	}
+
+	return list, nil
+}
+
+func (a *Analytics) flush(batch *analyticsBatch) {
+	if len(batch.entries) == 0 {
+		return
+	}
+
+	// Use a fresh context, so that pending entries are written on shutdown.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var count int
+	err := db.WithTx(ctx, a.db, func(tx db.Tx) error {
+		list, err := batch.limitValues(ctx, tx)
+		if err != nil {
+			return err
+		}
+		count = len(list)
+		return tx.AddSiteAnalytics(ctx, list)
+	})
+	if err != nil {
+		a.logger.Error("failed to write analytics", zap.Error(err))
+		return
+	}
+	a.logger.Debug("analytics written", zap.Int("count", count))
+}
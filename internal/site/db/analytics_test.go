@@ -0,0 +1,82 @@
+package db_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/db"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/site"
+	sitedb "github.com/oursky/pageship/internal/site/db"
+	"github.com/oursky/pageship/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestAnalyticsMaxValues(t *testing.T) {
+	testutil.LoadTestEnvs()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	hour := now.Truncate(time.Hour)
+
+	testutil.WithTestDB(func(database db.DB) {
+		user := models.NewUser(now, "mock user")
+		if err := database.CreateUser(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		if err := database.CreateApp(ctx, models.NewApp(now, "test", user.ID)); err != nil {
+			t.Fatal(err)
+		}
+
+		// Values stored by previous flushes count towards the limit.
+		var stored []*models.SiteAnalytics
+		for i := 0; i < 99; i++ {
+			stored = append(stored, &models.SiteAnalytics{
+				AppID:     "test",
+				SiteName:  "main",
+				Hour:      hour,
+				Dimension: models.SiteAnalyticsPath,
+				Value:     fmt.Sprintf("/stored-%d", i),
+				Requests:  1,
+			})
+		}
+		if err := database.AddSiteAnalytics(ctx, stored); err != nil {
+			t.Fatal(err)
+		}
+
+		analytics := sitedb.NewAnalytics(zap.NewNop(), database)
+		desc := &site.Descriptor{ID: "test/main/deployment"}
+		record := func(path string, n int) {
+			for i := 0; i < n; i++ {
+				analytics.Record(desc, httptest.NewRequest("GET", "http://test.localtest.me"+path, nil), 200, 10)
+			}
+		}
+		record("/stored-0", 1)
+		record("/a", 3)
+		record("/b", 2)
+		record("/c", 1)
+
+		runCtx, cancel := context.WithCancel(ctx)
+		cancel()
+		assert.NoError(t, analytics.Run(runCtx))
+
+		entries, err := database.ListSiteAnalytics(ctx, "test", "main", hour, hour.Add(time.Hour))
+		if !assert.NoError(t, err) {
+			return
+		}
+		paths := make(map[string]int64)
+		for _, e := range entries {
+			if e.Dimension == models.SiteAnalyticsPath {
+				paths[e.Value] = e.Requests
+			}
+		}
+		assert.Len(t, paths, 101)
+		assert.Equal(t, int64(2), paths["/stored-0"])
+		assert.Equal(t, int64(3), paths["/a"])
+		assert.Equal(t, int64(3), paths["(other)"])
+	})
+}
@@ -0,0 +1,29 @@
+package db
+
+import (
+	"context"
+	"errors"
+
+	"github.com/oursky/pageship/internal/models"
+)
+
+// ResolveCredentials returns credentials of Pageship users linked to the
+// provided credentials.
+func (r *Resolver) ResolveCredentials(ctx context.Context, ids []models.CredentialID) ([]models.CredentialID, error) {
+	var credentials []models.CredentialID
+	for _, id := range ids {
+		cred, err := r.DB.GetCredential(ctx, id)
+		if errors.Is(err, models.ErrUserNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		userCredentials, err := r.DB.ListCredentialIDs(ctx, cred.UserID)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, userCredentials...)
+	}
+	return credentials, nil
+}
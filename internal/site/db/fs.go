@@ -6,20 +6,23 @@ import (
 	"io/fs"
 	"time"
 
+	"github.com/oursky/pageship/internal/cache"
+	"github.com/oursky/pageship/internal/metrics"
 	"github.com/oursky/pageship/internal/models"
 	"github.com/oursky/pageship/internal/site"
 	"github.com/oursky/pageship/internal/storage"
 )
 
 type storageFS struct {
-	storage *storage.Storage
-	modTime time.Time
-	prefix  string
-	fileMap map[string]models.FileEntry
-	files   []models.FileEntry
+	storage    *storage.Storage
+	cache      *cache.BlobCache
+	modTime    time.Time
+	deployment *models.Deployment
+	fileMap    map[string]models.FileEntry
+	files      []models.FileEntry
 }
 
-func newStorageFS(storage *storage.Storage, deployment *models.Deployment) site.FS {
+func newStorageFS(storage *storage.Storage, cache *cache.BlobCache, deployment *models.Deployment) site.FS {
 	files := deployment.Metadata.Files
 	fileMap := make(map[string]models.FileEntry)
 	for _, entry := range deployment.Metadata.Files {
@@ -27,11 +30,12 @@ func newStorageFS(storage *storage.Storage, deployment *models.Deployment) site.
 	}
 
 	return &storageFS{
-		storage: storage,
-		modTime: *deployment.UploadedAt,
-		prefix:  deployment.StorageKeyPrefix,
-		fileMap: fileMap,
-		files:   files,
+		storage:    storage,
+		cache:      cache,
+		modTime:    *deployment.UploadedAt,
+		deployment: deployment,
+		fileMap:    fileMap,
+		files:      files,
 	}
 }
 
@@ -57,12 +61,20 @@ func (f *storageFS) Stat(path string) (*site.FileInfo, error) {
 		}
 	}
 
+	var encodings []site.FileEncoding
+	if f.deployment.BlobKeyPrefix != nil {
+		for _, enc := range entry.Encodings {
+			encodings = append(encodings, site.FileEncoding{Encoding: enc.Encoding, Hash: enc.Hash})
+		}
+	}
+
 	return &site.FileInfo{
 		IsDir:       entry.Path[len(entry.Path)-1] == '/',
 		ModTime:     f.modTime,
 		Size:        entry.Size,
 		ContentType: entry.ContentType,
 		Hash:        entry.Hash,
+		Encodings:   encodings,
 	}, nil
 }
 
@@ -76,8 +88,42 @@ func (f *storageFS) Open(ctx context.Context, path string) (io.ReadSeekCloser, e
 		}
 	}
 
-	key := f.prefix + entry.Path
+	// Blobs are keyed by content hash; contents of legacy path keys may
+	// change and cannot be cached.
+	cacheable := f.deployment.BlobKeyPrefix != nil
+	return f.open(ctx, f.deployment.StorageKey(entry), entry.Size, cacheable)
+}
+
+func (f *storageFS) OpenEncoded(ctx context.Context, path string, encoding string) (io.ReadSeekCloser, error) {
+	entry, ok := f.lookup(path)
+	if ok && f.deployment.BlobKeyPrefix != nil {
+		for _, enc := range entry.Encodings {
+			if enc.Encoding == encoding {
+				return f.open(ctx, f.deployment.EncodingStorageKey(enc), enc.Size, true)
+			}
+		}
+	}
+
+	return nil, &fs.PathError{
+		Op:   "open",
+		Path: path,
+		Err:  fs.ErrNotExist,
+	}
+}
+
+func (f *storageFS) open(ctx context.Context, key string, size int64, cacheable bool) (io.ReadSeekCloser, error) {
+	if f.cache != nil && cacheable {
+		return f.cache.Open(ctx, key, size, func(ctx context.Context) (io.ReadSeekCloser, error) {
+			return f.read(ctx, key)
+		})
+	}
+	return f.read(ctx, key)
+}
+
+func (f *storageFS) read(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	start := time.Now()
 	reader, err := f.storage.OpenRead(ctx, key)
+	metrics.StorageReadDuration.Observe(time.Since(start).Seconds())
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,123 @@
+package db_test
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/db"
+	sitehandler "github.com/oursky/pageship/internal/handler/site"
+	"github.com/oursky/pageship/internal/handler/site/middleware"
+	"github.com/oursky/pageship/internal/models"
+	sitedb "github.com/oursky/pageship/internal/site/db"
+	"github.com/oursky/pageship/internal/storage"
+	"github.com/oursky/pageship/testutil"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorageFSNotFound(t *testing.T) {
+	testutil.LoadTestEnvs()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+
+	store, err := storage.New(ctx, viper.GetString("storage-url"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	testutil.WithTestDB(func(database db.DB) {
+		user := models.NewUser(now, "mock user")
+		if err := database.CreateUser(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		app := models.NewApp(now, "test", user.ID)
+		if err := database.CreateApp(ctx, app); err != nil {
+			t.Fatal(err)
+		}
+
+		blobKeyPrefix := models.BlobKeyPrefix(viper.GetString("storage-key-prefix"), app.ID)
+		blobs := map[string]string{
+			"index":        "index",
+			"not-found":    "not found",
+			"not-found-br": "brotli not found",
+		}
+		for hash, data := range blobs {
+			if err := store.Upload(ctx, blobKeyPrefix+hash, bytes.NewReader([]byte(data))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		siteConfig := config.DefaultSiteConfig()
+		siteConfig.NoSPA = true
+		siteConfig.NotFound = "404.html"
+		deployment := models.NewDeployment(now, "test", app.ID, "", &models.DeploymentMetadata{
+			Files: []models.FileEntry{
+				{Path: "/", Size: 0, Hash: "", ContentType: ""},
+				{Path: "/index.html", Size: 5, Hash: "index", ContentType: "text/html; charset=utf-8"},
+				{
+					Path: "/404.html", Size: 9, Hash: "not-found", ContentType: "text/html; charset=utf-8",
+					Encodings: []models.FileEncoding{{Encoding: "br", Size: 16, Hash: "not-found-br"}},
+				},
+			},
+			Config: siteConfig,
+		})
+		deployment.BlobKeyPrefix = &blobKeyPrefix
+		if err := database.CreateDeployment(ctx, deployment); err != nil {
+			t.Fatal(err)
+		}
+		if err := database.SetDeploymentBlobKeyPrefix(ctx, deployment); err != nil {
+			t.Fatal(err)
+		}
+		if err := database.MarkDeploymentUploaded(ctx, now, deployment); err != nil {
+			t.Fatal(err)
+		}
+
+		site := models.NewSite(now, app.ID, "main")
+		if _, err := database.CreateSiteIfNotExist(ctx, site); err != nil {
+			t.Fatal(err)
+		}
+		site.DeploymentID = &deployment.ID
+		if err := database.SetSiteDeployment(ctx, site); err != nil {
+			t.Fatal(err)
+		}
+
+		resolver := &sitedb.Resolver{DB: database, Storage: store, HostIDScheme: config.HostIDSchemeDefault}
+		desc, err := resolver.Resolve(ctx, app.ID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		h := sitehandler.NewSiteHandler(desc, middleware.Default)
+
+		serve := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("GET", "http://test.localhost"+path, nil)
+			for name, value := range headers {
+				req.Header.Set(name, value)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			return rec
+		}
+
+		rec := serve("/missing", nil)
+		assert.Equal(t, 404, rec.Code)
+		assert.Equal(t, "", rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, "9", rec.Header().Get("Content-Length"))
+		assert.Equal(t, "not found", rec.Body.String())
+
+		rec = serve("/missing", map[string]string{"Accept-Encoding": "br"})
+		assert.Equal(t, 404, rec.Code)
+		assert.Equal(t, "br", rec.Header().Get("Content-Encoding"))
+		assert.NotEqual(t, "9", rec.Header().Get("Content-Length"))
+		assert.Equal(t, "brotli not found", rec.Body.String())
+
+		rec = serve("/missing", map[string]string{"Range": "bytes=0-2", "If-None-Match": `"not-found"`})
+		assert.Equal(t, 404, rec.Code)
+		assert.Equal(t, "not found", rec.Body.String())
+	})
+}
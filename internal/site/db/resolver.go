@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/oursky/pageship/internal/cache"
 	"github.com/oursky/pageship/internal/config"
 	"github.com/oursky/pageship/internal/db"
 	"github.com/oursky/pageship/internal/models"
@@ -16,6 +17,7 @@ import (
 type Resolver struct {
 	DB           db.DB
 	Storage      *storage.Storage
+	BlobCache    *cache.BlobCache
 	HostIDScheme config.HostIDScheme
 }
 
@@ -113,10 +115,12 @@ func (r *Resolver) Resolve(ctx context.Context, matchedID string) (*site.Descrip
 
 	id := strings.Join([]string{deployment.AppID, siteName, deployment.ID}, "/")
 	desc := &site.Descriptor{
-		ID:     id,
-		Config: &config,
-		Domain: domainName,
-		FS:     newStorageFS(r.Storage, deployment),
+		ID:        id,
+		Config:    &config,
+		BasicAuth: app.Config.BasicAuth,
+		SSO:       app.Config.SSO,
+		Domain:    domainName,
+		FS:        newStorageFS(r.Storage, r.BlobCache, deployment),
 	}
 
 	return desc, nil
@@ -3,6 +3,7 @@ package site
 import (
 	"context"
 	"io"
+	"io/fs"
 	"path"
 	"time"
 
@@ -15,6 +16,13 @@ type FileInfo struct {
 	Size        int64
 	ContentType string
 	Hash        string
+	Encodings   []FileEncoding
+}
+
+// FileEncoding is a pre-compressed variant of file content.
+type FileEncoding struct {
+	Encoding string
+	Hash     string
 }
 
 type FS interface {
@@ -22,11 +30,19 @@ type FS interface {
 	Open(ctx context.Context, path string) (io.ReadSeekCloser, error)
 }
 
+// EncodedFS is implemented by FS with pre-compressed file content, as
+// reported in FileInfo.Encodings.
+type EncodedFS interface {
+	OpenEncoded(ctx context.Context, path string, encoding string) (io.ReadSeekCloser, error)
+}
+
 type Descriptor struct {
-	ID     string
-	Domain string
-	Config *config.SiteConfig
-	FS     FS
+	ID        string
+	Domain    string
+	Config    *config.SiteConfig
+	BasicAuth []config.AppBasicAuthConfig
+	SSO       *config.AppSSOConfig
+	FS        FS
 }
 
 type subFS struct {
@@ -43,3 +59,11 @@ func (s *subFS) Stat(p string) (*FileInfo, error) {
 func (s *subFS) Open(ctx context.Context, p string) (io.ReadSeekCloser, error) {
 	return s.fs.Open(ctx, path.Join(s.dir, p))
 }
+
+func (s *subFS) OpenEncoded(ctx context.Context, p string, encoding string) (io.ReadSeekCloser, error) {
+	efs, ok := s.fs.(EncodedFS)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+	}
+	return efs.OpenEncoded(ctx, path.Join(s.dir, p), encoding)
+}
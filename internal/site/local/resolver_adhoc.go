@@ -54,8 +54,10 @@ func (h *resolverAdhoc) Resolve(ctx context.Context, matchedID string) (*site.De
 	}
 
 	return &site.Descriptor{
-		ID:     matchedID,
-		Config: &config.Site,
-		FS:     siteFS{fs: fsys},
+		ID:        matchedID,
+		Config:    &config.Site,
+		BasicAuth: config.App.BasicAuth,
+		SSO:       config.App.SSO,
+		FS:        siteFS{fs: fsys},
 	}, nil
 }
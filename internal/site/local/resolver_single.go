@@ -31,8 +31,10 @@ func (h *resolverSingle) Resolve(ctx context.Context, matchedID string) (*site.D
 	}
 
 	return &site.Descriptor{
-		ID:     config.App.ID,
-		Config: &config.Site,
-		FS:     siteFS{fs: h.fs},
+		ID:        config.App.ID,
+		Config:    &config.Site,
+		BasicAuth: config.App.BasicAuth,
+		SSO:       config.App.SSO,
+		FS:        siteFS{fs: h.fs},
 	}, nil
 }
@@ -44,9 +44,11 @@ func (h *resolverStatic) Resolve(ctx context.Context, matchedID string) (*site.D
 	}
 
 	return &site.Descriptor{
-		ID:     matchedID,
-		Domain: entry.Domain,
-		Config: &config.Site,
-		FS:     siteFS{fs: fsys},
+		ID:        matchedID,
+		Domain:    entry.Domain,
+		Config:    &config.Site,
+		BasicAuth: config.App.BasicAuth,
+		SSO:       config.App.SSO,
+		FS:        siteFS{fs: fsys},
 	}, nil
 }
@@ -0,0 +1,192 @@
+package local
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/oursky/pageship/internal/config"
+	"github.com/oursky/pageship/internal/deploy"
+	"github.com/oursky/pageship/internal/models"
+	"github.com/oursky/pageship/internal/site"
+)
+
+type resolverTarball struct {
+	desc *site.Descriptor
+}
+
+// NewTarballResolver serves a deployment tarball as single site, with the
+// site config embedded in the tarball.
+func NewTarballResolver(r io.Reader) (site.Resolver, error) {
+	fsys, err := loadTarballFS(r)
+	if err != nil {
+		return nil, fmt.Errorf("load tarball: %w", err)
+	}
+
+	confFile, ok := fsys[fmt.Sprintf("/%s.json", config.SiteConfigName)]
+	if !ok {
+		return nil, config.ErrConfigNotFound
+	}
+
+	conf := config.DefaultConfig()
+	if err := json.Unmarshal(confFile.data, &conf); err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	if err := config.ValidateSiteConfig(&conf.Site); err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	return &resolverTarball{
+		desc: &site.Descriptor{
+			ID:        conf.App.ID,
+			Config:    &conf.Site,
+			BasicAuth: conf.App.BasicAuth,
+			SSO:       conf.App.SSO,
+			FS:        fsys,
+		},
+	}, nil
+}
+
+func (h *resolverTarball) Kind() string { return "tarball" }
+
+func (h *resolverTarball) IsWildcard() bool { return true }
+
+func (h *resolverTarball) Resolve(ctx context.Context, matchedID string) (*site.Descriptor, error) {
+	desc := *h.desc
+	return &desc, nil
+}
+
+type tarballFile struct {
+	info      site.FileInfo
+	data      []byte
+	encodings map[string][]byte
+}
+
+// tarballFS keeps content of tarball in memory, since compressed tarball
+// cannot be read randomly.
+type tarballFS map[string]*tarballFile
+
+func loadTarballFS(r io.Reader) (tarballFS, error) {
+	fsys := make(tarballFS)
+	encoded := make(map[string]map[string][]byte)
+	err := deploy.ReadFiles(r, func(hdr *tar.Header, reader io.Reader) error {
+		if hdr.Typeflag == tar.TypeDir {
+			fsys[hdr.Name] = &tarballFile{
+				info: site.FileInfo{IsDir: true, ModTime: hdr.ModTime},
+			}
+			return nil
+		}
+
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+
+		if path, encoding, ok := deploy.ParseEncodedPath(hdr.Name); ok {
+			if encoded[path] == nil {
+				encoded[path] = make(map[string][]byte)
+			}
+			encoded[path][encoding] = data
+			return nil
+		}
+
+		fsys[hdr.Name] = &tarballFile{
+			info: site.FileInfo{
+				IsDir:       false,
+				ModTime:     hdr.ModTime,
+				Size:        int64(len(data)),
+				ContentType: models.DetectContentType(hdr.Name, data),
+				Hash:        contentHash(data),
+			},
+			data: data,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for path, encodings := range encoded {
+		file, ok := fsys[path]
+		if !ok {
+			continue
+		}
+		file.encodings = encodings
+		for _, encoding := range models.FileEncodings {
+			if data, ok := encodings[encoding]; ok {
+				file.info.Encodings = append(file.info.Encodings, site.FileEncoding{
+					Encoding: encoding,
+					Hash:     contentHash(data),
+				})
+			}
+		}
+	}
+
+	return fsys, nil
+}
+
+func contentHash(data []byte) string {
+	h := deploy.NewFileHash()
+	h.Write(data)
+	return h.Sum()
+}
+
+func (f tarballFS) lookup(path string) (*tarballFile, error) {
+	if file, ok := f[path]; ok {
+		return file, nil
+	}
+
+	if file, ok := f[path+"/"]; ok {
+		return file, nil
+	}
+
+	return nil, &fs.PathError{
+		Op:   "open",
+		Path: path,
+		Err:  fs.ErrNotExist,
+	}
+}
+
+func (f tarballFS) Stat(path string) (*site.FileInfo, error) {
+	file, err := f.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info := file.info
+	return &info, nil
+}
+
+func (f tarballFS) Open(ctx context.Context, path string) (io.ReadSeekCloser, error) {
+	file, err := f.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return bytesFile{Reader: bytes.NewReader(file.data)}, nil
+}
+
+func (f tarballFS) OpenEncoded(ctx context.Context, path string, encoding string) (io.ReadSeekCloser, error) {
+	file, err := f.lookup(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, ok := file.encodings[encoding]
+	if !ok {
+		return nil, &fs.PathError{
+			Op:   "open",
+			Path: path,
+			Err:  fs.ErrNotExist,
+		}
+	}
+	return bytesFile{Reader: bytes.NewReader(data)}, nil
+}
+
+type bytesFile struct{ *bytes.Reader }
+
+func (bytesFile) Close() error { return nil }
@@ -28,7 +28,7 @@ func NewGitHubKeys(ctx context.Context) (*GitHubKeys, error) {
 		client: &http.Client{},
 	}
 
-	cache, err := cache.NewCache(100, time.Minute, keys.doLoad)
+	cache, err := cache.NewCache("github-keys", 100, time.Minute, keys.doLoad)
 	if err != nil {
 		return nil, err
 	}
@@ -38,15 +38,15 @@ func NewGitHubKeys(ctx context.Context) (*GitHubKeys, error) {
 }
 
 func (g *GitHubKeys) PublicKey(username string) (map[string]struct{}, error) {
-	pkeys, err := g.cache.Load(strings.ToLower(username))
+	pkeys, err := g.cache.Load(g.ctx, strings.ToLower(username))
 	if err != nil {
 		return nil, err
 	}
 	return pkeys, nil
 }
 
-func (g *GitHubKeys) doLoad(username string) (map[string]struct{}, error) {
-	ctx, cancel := context.WithTimeout(g.ctx, time.Second*10)
+func (g *GitHubKeys) doLoad(ctx context.Context, username string) (map[string]struct{}, error) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second*10)
 	defer cancel()
 
 	g.l.Wait(ctx)
@@ -0,0 +1,49 @@
+package sshkey
+
+import (
+	"bytes"
+	"errors"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// KeyFile is the private key in specified file.
+type KeyFile struct {
+	path             string
+	promptPassphrase func() ([]byte, error)
+}
+
+func NewKeyFile(path string, promptPassphrase func() ([]byte, error)) *KeyFile {
+	return &KeyFile{path: path, promptPassphrase: promptPassphrase}
+}
+
+func (k *KeyFile) Close() error { return nil }
+
+func (k *KeyFile) Signers() ([]ssh.Signer, error) {
+	key, err := loadPrivateKey(k.path, k.promptPassphrase)
+	if err != nil {
+		return nil, err
+	}
+	return []ssh.Signer{key}, nil
+}
+
+// FindSigner finds signer of the public key from sources, in order.
+func FindSigner(sources []Source, pubKey ssh.PublicKey) (ssh.Signer, error) {
+	var errs []error
+	for _, source := range sources {
+		signers, err := source.Signers()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, signer := range signers {
+			if bytes.Equal(signer.PublicKey().Marshal(), pubKey.Marshal()) {
+				return signer, nil
+			}
+		}
+	}
+
+	errs = append(errs, errors.New("private key not found"))
+	return nil, errors.Join(errs...)
+}
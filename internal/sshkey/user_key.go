@@ -61,6 +61,24 @@ func (k *UserKey) Signers() ([]ssh.Signer, error) {
 		return nil, err
 	}
 
+	key, err := loadPrivateKey(file, k.promptPassphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	signers := []ssh.Signer{key}
+	if cert, err := loadCertificate(file + "-cert.pub"); err == nil {
+		certSigner, err := ssh.NewCertSigner(cert, key)
+		if err == nil {
+			// Prefer certificate if present.
+			signers = []ssh.Signer{certSigner, key}
+		}
+	}
+
+	return signers, nil
+}
+
+func loadPrivateKey(file string, promptPassphrase func() ([]byte, error)) (ssh.Signer, error) {
 	pem, err := os.ReadFile(file)
 	if err != nil {
 		return nil, err
@@ -68,7 +86,7 @@ func (k *UserKey) Signers() ([]ssh.Signer, error) {
 
 	key, err := ssh.ParsePrivateKey(pem)
 	if errors.As(err, new(*ssh.PassphraseMissingError)) {
-		passphrase, perr := k.promptPassphrase()
+		passphrase, perr := promptPassphrase()
 		if perr != nil {
 			return nil, perr
 		}
@@ -77,8 +95,25 @@ func (k *UserKey) Signers() ([]ssh.Signer, error) {
 	if err != nil {
 		return nil, err
 	}
+	return key, nil
+}
+
+func loadCertificate(file string) (*ssh.Certificate, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
 
-	return []ssh.Signer{key}, nil
+	pubKey, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, ok := pubKey.(*ssh.Certificate)
+	if !ok {
+		return nil, errors.New("not a certificate")
+	}
+	return cert, nil
 }
 
 func homeSSHKeyFile() (string, error) {
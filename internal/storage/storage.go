@@ -2,16 +2,26 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
 
+	"github.com/oursky/pageship/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gocloud.dev/blob"
 	_ "gocloud.dev/blob/azureblob"
 	_ "gocloud.dev/blob/fileblob"
 	_ "gocloud.dev/blob/gcsblob"
 	_ "gocloud.dev/blob/memblob"
 	_ "gocloud.dev/blob/s3blob"
+	"gocloud.dev/gcerrors"
 )
 
+type Object struct {
+	Key  string
+	Size int64
+}
+
 type Storage struct {
 	bucket *blob.Bucket
 }
@@ -29,6 +39,9 @@ func (s *Storage) Close() error {
 }
 
 func (s *Storage) Upload(ctx context.Context, key string, r io.Reader) (err error) {
+	ctx, span := startSpan(ctx, "storage.Upload", key)
+	defer func() { tracing.End(span, err) }()
+
 	writer, err := s.bucket.NewWriter(ctx, key, nil)
 	if err != nil {
 		return err
@@ -45,10 +58,80 @@ func (s *Storage) Upload(ctx context.Context, key string, r io.Reader) (err erro
 }
 
 func (s *Storage) OpenRead(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	ctx, span := startSpan(ctx, "storage.OpenRead", key)
 	reader, err := s.bucket.NewReader(ctx, key, nil)
+	tracing.End(span, err)
 	if err != nil {
 		return nil, err
 	}
 
 	return reader, nil
 }
+
+func (s *Storage) Copy(ctx context.Context, dstKey string, srcKey string) error {
+	ctx, span := startSpan(ctx, "storage.Copy", dstKey)
+	span.SetAttributes(attribute.String("storage.src_key", srcKey))
+	err := s.bucket.Copy(ctx, dstKey, srcKey, nil)
+	tracing.End(span, err)
+	return err
+}
+
+func (s *Storage) Delete(ctx context.Context, key string) error {
+	ctx, span := startSpan(ctx, "storage.Delete", key)
+	err := s.bucket.Delete(ctx, key)
+	if gcerrors.Code(err) == gcerrors.NotFound {
+		err = nil
+	}
+	tracing.End(span, err)
+	return err
+}
+
+// List returns up to limit objects with keys starting with prefix.
+func (s *Storage) List(ctx context.Context, prefix string, limit int) (objects []Object, err error) {
+	ctx, span := startSpan(ctx, "storage.List", prefix)
+	defer func() { tracing.End(span, err) }()
+
+	iter := s.bucket.List(&blob.ListOptions{Prefix: prefix})
+	for len(objects) < limit {
+		obj, err := iter.Next(ctx)
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		objects = append(objects, Object{Key: obj.Key, Size: obj.Size})
+	}
+
+	return objects, nil
+}
+
+// ListPage returns a page of up to limit objects with keys starting with
+// prefix, listing from the start if pageToken is nil. The returned page token
+// is empty after the last page.
+func (s *Storage) ListPage(ctx context.Context, prefix string, pageToken []byte, limit int) (objects []Object, nextPageToken []byte, err error) {
+	ctx, span := startSpan(ctx, "storage.ListPage", prefix)
+	defer func() { tracing.End(span, err) }()
+
+	if pageToken == nil {
+		pageToken = blob.FirstPageToken
+	}
+
+	page, nextPageToken, err := s.bucket.ListPage(ctx, pageToken, limit, &blob.ListOptions{Prefix: prefix})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for _, obj := range page {
+		objects = append(objects, Object{Key: obj.Key, Size: obj.Size})
+	}
+
+	return objects, nextPageToken, nil
+}
+
+func startSpan(ctx context.Context, name string, key string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("storage.key", key)),
+	)
+}
@@ -0,0 +1,138 @@
+package watch
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+const dirDebounce = 100 * time.Millisecond
+
+// Dir watches a directory tree recursively, and reports changed paths
+// (relative to the directory, slash-separated) in batches.
+type Dir struct {
+	logger   *zap.Logger
+	path     string
+	onChange func(paths []string)
+
+	watcher *fsnotify.Watcher
+	stop    func()
+}
+
+func NewDir(logger *zap.Logger, path string, onChange func(paths []string)) (*Dir, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	dir := &Dir{
+		logger:   logger,
+		path:     path,
+		onChange: onChange,
+		watcher:  watcher,
+		stop:     cancel,
+	}
+
+	if err := dir.addTree(path); err != nil {
+		watcher.Close()
+		cancel()
+		return nil, err
+	}
+	logger.Info("watching directory", zap.String("path", path))
+
+	go dir.watch(ctx)
+
+	return dir, nil
+}
+
+func (d *Dir) Close() {
+	d.stop()
+	d.watcher.Close()
+}
+
+func (d *Dir) addTree(root string) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !entry.IsDir() {
+			return nil
+		}
+		// Skip hidden directories, e.g. VCS metadata.
+		if path != d.path && strings.HasPrefix(entry.Name(), ".") {
+			return filepath.SkipDir
+		}
+		return d.watcher.Add(path)
+	})
+}
+
+func (d *Dir) watch(ctx context.Context) {
+	log := d.logger.Named("watch")
+
+	timer := time.NewTimer(dirDebounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	changed := make(map[string]struct{})
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case event, ok := <-d.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			if event.Op.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if err := d.addTree(event.Name); err != nil {
+						log.Warn("failed to watch directory", zap.String("path", event.Name), zap.Error(err))
+					}
+				}
+			}
+
+			path, err := filepath.Rel(d.path, event.Name)
+			if err != nil {
+				continue
+			}
+			if len(changed) == 0 {
+				timer.Reset(dirDebounce)
+			}
+			changed[filepath.ToSlash(path)] = struct{}{}
+
+		case err, ok := <-d.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Error("watch failed", zap.Error(err))
+
+		case <-timer.C:
+			paths := make([]string, 0, len(changed))
+			for path := range changed {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			changed = make(map[string]struct{})
+
+			log.Debug("files changed", zap.Strings("paths", paths))
+			d.onChange(paths)
+		}
+	}
+}
@@ -0,0 +1,7 @@
+BEGIN;
+
+DROP TABLE deployment_blob;
+DROP TABLE blob;
+ALTER TABLE deployment DROP COLUMN blob_key_prefix;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX deployment_storage_cleanup;
+ALTER TABLE deployment DROP COLUMN storage_deleted_at;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE deployment ADD COLUMN storage_deleted_at TIMESTAMPTZ;
+CREATE INDEX deployment_storage_cleanup ON deployment(deleted_at) WHERE deleted_at IS NOT NULL AND storage_deleted_at IS NULL;
+
+COMMIT;
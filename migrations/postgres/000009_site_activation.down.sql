@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE site_activation;
+
+COMMIT;
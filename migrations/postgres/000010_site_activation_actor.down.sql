@@ -0,0 +1,8 @@
+BEGIN;
+
+ALTER TABLE site_activation DROP COLUMN credential_rule;
+ALTER TABLE site_activation DROP COLUMN credential_id;
+ALTER TABLE site_activation DROP COLUMN subject;
+ALTER TABLE site_activation DROP COLUMN previous_deployment_id;
+
+COMMIT;
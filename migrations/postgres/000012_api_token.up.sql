@@ -0,0 +1,19 @@
+BEGIN;
+
+CREATE TABLE api_token (
+    id                  TEXT NOT NULL PRIMARY KEY,
+    created_at          TIMESTAMPTZ NOT NULL,
+    updated_at          TIMESTAMPTZ NOT NULL,
+    deleted_at          TIMESTAMPTZ,
+    user_id             TEXT NOT NULL REFERENCES "user"(id),
+    name                TEXT NOT NULL,
+    token_hash          TEXT NOT NULL,
+    app_id              TEXT,
+    access              TEXT,
+    expires_at          TIMESTAMPTZ,
+    last_used_at        TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX api_token_hash ON api_token(token_hash);
+CREATE INDEX api_token_user ON api_token(user_id, created_at) WHERE deleted_at IS NULL;
+
+COMMIT;
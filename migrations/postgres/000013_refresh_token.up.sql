@@ -0,0 +1,16 @@
+BEGIN;
+
+CREATE TABLE refresh_token (
+    id                  TEXT NOT NULL PRIMARY KEY,
+    created_at          TIMESTAMPTZ NOT NULL,
+    family_id           TEXT NOT NULL,
+    user_id             TEXT NOT NULL REFERENCES "user"(id),
+    token_hash          TEXT NOT NULL,
+    expires_at          TIMESTAMPTZ NOT NULL,
+    used_at             TIMESTAMPTZ,
+    revoked_at          TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX refresh_token_hash ON refresh_token(token_hash);
+CREATE INDEX refresh_token_family ON refresh_token(family_id);
+
+COMMIT;
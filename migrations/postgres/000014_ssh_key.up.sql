@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE ssh_key (
+    id                  TEXT NOT NULL PRIMARY KEY,
+    created_at          TIMESTAMPTZ NOT NULL,
+    updated_at          TIMESTAMPTZ NOT NULL,
+    deleted_at          TIMESTAMPTZ,
+    user_id             TEXT NOT NULL REFERENCES "user"(id),
+    name                TEXT NOT NULL,
+    fingerprint         TEXT NOT NULL,
+    public_key          TEXT NOT NULL,
+    last_used_at        TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX ssh_key_fingerprint ON ssh_key(fingerprint) WHERE deleted_at IS NULL;
+CREATE INDEX ssh_key_user ON ssh_key(user_id, created_at) WHERE deleted_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,15 @@
+BEGIN;
+
+CREATE TABLE site_analytics (
+    app_id              TEXT NOT NULL REFERENCES app(id),
+    site_name           TEXT NOT NULL,
+    hour                TIMESTAMPTZ NOT NULL,
+    dimension           TEXT NOT NULL,
+    value               TEXT NOT NULL,
+    requests            BIGINT NOT NULL,
+    bytes               BIGINT NOT NULL,
+    PRIMARY KEY (app_id, site_name, hour, dimension, value)
+);
+CREATE INDEX site_analytics_hour ON site_analytics(hour);
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE audit_log DROP COLUMN deleted_at;
+ALTER TABLE blob DROP COLUMN deleted_at;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE blob ADD COLUMN deleted_at TIMESTAMPTZ;
+ALTER TABLE audit_log ADD COLUMN deleted_at TIMESTAMPTZ;
+
+COMMIT;
@@ -0,0 +1,20 @@
+ALTER TABLE deployment ADD COLUMN blob_key_prefix TEXT;
+
+CREATE TABLE blob (
+    app_id              TEXT NOT NULL REFERENCES app(id),
+    hash                TEXT NOT NULL,
+    created_at          TIMESTAMP NOT NULL,
+    last_used_at        TIMESTAMP NOT NULL,
+    size                INTEGER NOT NULL,
+    storage_key         TEXT NOT NULL,
+    PRIMARY KEY (app_id, hash)
+);
+CREATE INDEX blob_last_used ON blob(last_used_at);
+
+CREATE TABLE deployment_blob (
+    deployment_id       TEXT NOT NULL REFERENCES deployment(id),
+    app_id              TEXT NOT NULL REFERENCES app(id),
+    hash                TEXT NOT NULL,
+    PRIMARY KEY (deployment_id, hash)
+);
+CREATE INDEX deployment_blob_hash ON deployment_blob(app_id, hash);
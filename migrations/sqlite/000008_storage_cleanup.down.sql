@@ -0,0 +1,2 @@
+DROP INDEX deployment_storage_cleanup;
+ALTER TABLE deployment DROP COLUMN storage_deleted_at;
@@ -0,0 +1,13 @@
+CREATE TABLE site_activation (
+    id                  TEXT NOT NULL PRIMARY KEY,
+    created_at          TIMESTAMP NOT NULL,
+    app_id              TEXT NOT NULL REFERENCES app(id),
+    site_id             TEXT NOT NULL REFERENCES site(id),
+    deployment_id       TEXT REFERENCES deployment(id)
+);
+CREATE INDEX site_activation_site ON site_activation(site_id, created_at);
+CREATE INDEX site_activation_deployment ON site_activation(deployment_id);
+
+INSERT INTO site_activation (id, created_at, app_id, site_id, deployment_id)
+    SELECT 'activation_' || substr(id, 6), updated_at, app_id, id, deployment_id FROM site
+        WHERE deleted_at IS NULL AND deployment_id IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE site_activation ADD COLUMN previous_deployment_id TEXT REFERENCES deployment(id);
+ALTER TABLE site_activation ADD COLUMN subject TEXT NOT NULL DEFAULT '';
+ALTER TABLE site_activation ADD COLUMN credential_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE site_activation ADD COLUMN credential_rule TEXT NOT NULL DEFAULT '';
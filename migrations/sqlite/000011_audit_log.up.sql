@@ -0,0 +1,14 @@
+CREATE TABLE audit_log (
+    id                  TEXT NOT NULL PRIMARY KEY,
+    created_at          TIMESTAMP NOT NULL,
+    app_id              TEXT NOT NULL REFERENCES app(id),
+    action              TEXT NOT NULL,
+    target              TEXT NOT NULL,
+    details             TEXT NOT NULL,
+    subject             TEXT NOT NULL,
+    credential_id       TEXT NOT NULL,
+    credential_rule     TEXT NOT NULL,
+    request_id          TEXT NOT NULL,
+    source_ip           TEXT NOT NULL
+);
+CREATE INDEX audit_log_app ON audit_log(app_id, created_at, id);
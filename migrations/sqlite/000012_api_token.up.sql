@@ -0,0 +1,15 @@
+CREATE TABLE api_token (
+    id                  TEXT NOT NULL PRIMARY KEY,
+    created_at          TIMESTAMP NOT NULL,
+    updated_at          TIMESTAMP NOT NULL,
+    deleted_at          TIMESTAMP,
+    user_id             TEXT NOT NULL REFERENCES user(id),
+    name                TEXT NOT NULL,
+    token_hash          TEXT NOT NULL,
+    app_id              TEXT,
+    access              TEXT,
+    expires_at          TIMESTAMP,
+    last_used_at        TIMESTAMP
+);
+CREATE UNIQUE INDEX api_token_hash ON api_token(token_hash);
+CREATE INDEX api_token_user ON api_token(user_id, created_at) WHERE deleted_at IS NULL;
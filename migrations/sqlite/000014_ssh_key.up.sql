@@ -0,0 +1,13 @@
+CREATE TABLE ssh_key (
+    id                  TEXT NOT NULL PRIMARY KEY,
+    created_at          TIMESTAMP NOT NULL,
+    updated_at          TIMESTAMP NOT NULL,
+    deleted_at          TIMESTAMP,
+    user_id             TEXT NOT NULL REFERENCES user(id),
+    name                TEXT NOT NULL,
+    fingerprint         TEXT NOT NULL,
+    public_key          TEXT NOT NULL,
+    last_used_at        TIMESTAMP
+);
+CREATE UNIQUE INDEX ssh_key_fingerprint ON ssh_key(fingerprint) WHERE deleted_at IS NULL;
+CREATE INDEX ssh_key_user ON ssh_key(user_id, created_at) WHERE deleted_at IS NULL;
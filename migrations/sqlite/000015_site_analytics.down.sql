@@ -0,0 +1 @@
+DROP TABLE site_analytics;
@@ -0,0 +1,2 @@
+ALTER TABLE audit_log DROP COLUMN deleted_at;
+ALTER TABLE blob DROP COLUMN deleted_at;
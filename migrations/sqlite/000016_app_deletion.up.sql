@@ -0,0 +1,2 @@
+ALTER TABLE blob ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE audit_log ADD COLUMN deleted_at TIMESTAMP;